@@ -11,48 +11,90 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type CategoryType string
+type ApiTokenKind string
 
 const (
-	CategoryTypePlant CategoryType = "plant"
-	CategoryTypeTool  CategoryType = "tool"
-	CategoryTypeSeed  CategoryType = "seed"
-	CategoryTypeSoil  CategoryType = "soil"
+	ApiTokenKindLogin ApiTokenKind = "login"
+	ApiTokenKindKey   ApiTokenKind = "key"
 )
 
-func (e *CategoryType) Scan(src interface{}) error {
+func (e *ApiTokenKind) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = CategoryType(s)
+		*e = ApiTokenKind(s)
 	case string:
-		*e = CategoryType(s)
+		*e = ApiTokenKind(s)
 	default:
-		return fmt.Errorf("unsupported scan type for CategoryType: %T", src)
+		return fmt.Errorf("unsupported scan type for ApiTokenKind: %T", src)
 	}
 	return nil
 }
 
-type NullCategoryType struct {
-	CategoryType CategoryType
-	Valid        bool // Valid is true if CategoryType is not NULL
+type NullApiTokenKind struct {
+	ApiTokenKind ApiTokenKind
+	Valid        bool // Valid is true if ApiTokenKind is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullCategoryType) Scan(value interface{}) error {
+func (ns *NullApiTokenKind) Scan(value interface{}) error {
 	if value == nil {
-		ns.CategoryType, ns.Valid = "", false
+		ns.ApiTokenKind, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.CategoryType.Scan(value)
+	return ns.ApiTokenKind.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullCategoryType) Value() (driver.Value, error) {
+func (ns NullApiTokenKind) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.CategoryType), nil
+	return string(ns.ApiTokenKind), nil
+}
+
+type AttributeKind string
+
+const (
+	AttributeKindText   AttributeKind = "text"
+	AttributeKindNumber AttributeKind = "number"
+	AttributeKindEnum   AttributeKind = "enum"
+	AttributeKindRange  AttributeKind = "range"
+)
+
+func (e *AttributeKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AttributeKind(s)
+	case string:
+		*e = AttributeKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AttributeKind: %T", src)
+	}
+	return nil
+}
+
+type NullAttributeKind struct {
+	AttributeKind AttributeKind
+	Valid         bool // Valid is true if AttributeKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAttributeKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.AttributeKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AttributeKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAttributeKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AttributeKind), nil
 }
 
 type ChatStatus string
@@ -144,9 +186,13 @@ func (ns NullDeliveryStatus) Value() (driver.Value, error) {
 type OrderType string
 
 const (
-	OrderTypePending   OrderType = "pending"
-	OrderTypeCompleted OrderType = "completed"
-	OrderTypeReturned  OrderType = "returned"
+	OrderTypePending         OrderType = "pending"
+	OrderTypePaid            OrderType = "paid"
+	OrderTypeShipped         OrderType = "shipped"
+	OrderTypeCompleted       OrderType = "completed"
+	OrderTypeReturnRequested OrderType = "return_requested"
+	OrderTypeReturned        OrderType = "returned"
+	OrderTypeCancelled       OrderType = "cancelled"
 )
 
 func (e *OrderType) Scan(src interface{}) error {
@@ -226,6 +272,51 @@ func (ns NullProdInteractionType) Value() (driver.Value, error) {
 	return string(ns.ProdInteractionType), nil
 }
 
+type StockMovementType string
+
+const (
+	StockMovementTypeReceipt      StockMovementType = "receipt"
+	StockMovementTypeSale         StockMovementType = "sale"
+	StockMovementTypeReturn       StockMovementType = "return"
+	StockMovementTypeCancellation StockMovementType = "cancellation"
+	StockMovementTypeAdjustment   StockMovementType = "adjustment"
+)
+
+func (e *StockMovementType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StockMovementType(s)
+	case string:
+		*e = StockMovementType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StockMovementType: %T", src)
+	}
+	return nil
+}
+
+type NullStockMovementType struct {
+	StockMovementType StockMovementType
+	Valid             bool // Valid is true if StockMovementType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStockMovementType) Scan(value interface{}) error {
+	if value == nil {
+		ns.StockMovementType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StockMovementType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStockMovementType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StockMovementType), nil
+}
+
 type UserRole string
 
 const (
@@ -269,12 +360,110 @@ func (ns NullUserRole) Value() (driver.Value, error) {
 	return string(ns.UserRole), nil
 }
 
-type Chat struct {
+type ApiToken struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	Kind       ApiTokenKind
+	Name       pgtype.Text
+	Prefix     pgtype.Text
+	TokenHash  []byte
+	Scopes     []string
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+}
+
+type Attribute struct {
+	ID         pgtype.UUID
+	CategoryID pgtype.UUID
+	Slug       string
+	Name       string
+	Kind       AttributeKind
+	Unit       string
+	Options    []string
+	Position   int32
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type CannedResponse struct {
 	ID        pgtype.UUID
-	Status    ChatStatus
+	Title     string
+	Content   string
 	CreatedBy pgtype.UUID
 	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
+}
+
+type Category struct {
+	ID          pgtype.UUID
+	ParentID    pgtype.UUID
+	Slug        string
+	Name        string
+	Description string
+	Img         pgtype.Text
+	Position    int32
+	Path        []pgtype.UUID
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type Chat struct {
+	ID         pgtype.UUID
+	Status     ChatStatus
+	CreatedBy  pgtype.UUID
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+	AssignedTo pgtype.UUID
+}
+
+type ChatRead struct {
+	ChatID     pgtype.UUID
+	UserID     pgtype.UUID
+	LastReadAt pgtype.Timestamptz
+}
+
+type Delivery struct {
+	ID                pgtype.UUID
+	OrderID           pgtype.UUID
+	Status            DeliveryStatus
+	TrackingNumber    pgtype.Text
+	EstimatedDelivery pgtype.Timestamptz
+	DeliveredAt       pgtype.Timestamptz
+	CreatedAt         pgtype.Timestamptz
+	UpdatedAt         pgtype.Timestamptz
+}
+
+type DeliveryEvent struct {
+	ID         pgtype.UUID
+	DeliveryID pgtype.UUID
+	Status     DeliveryStatus
+	Note       pgtype.Text
+	CreatedBy  pgtype.UUID
+	CreatedAt  pgtype.Timestamptz
+}
+
+type Favorite struct {
+	UserID    pgtype.UUID
+	ProductID pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+type FtsBulgarianDictionary struct {
+	Word string
+	Stem string
+}
+
+type FtsBulgarianStopword struct {
+	Word string
+}
+
+type HttpSession struct {
+	ID         int64
+	Key        pgtype.Text
+	Data       pgtype.Text
+	CreatedOn  pgtype.Timestamptz
+	ModifiedOn pgtype.Timestamptz
+	ExpiresOn  pgtype.Timestamptz
 }
 
 type Message struct {
@@ -284,6 +473,7 @@ type Message struct {
 	Content   string
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	Seq       int64
 }
 
 type Order struct {
@@ -302,6 +492,7 @@ type OrderDetail struct {
 	ReturnStatement pgtype.Text
 	CreatedAt       pgtype.Timestamp
 	UpdatedAt       pgtype.Timestamp
+	RefundAmount    pgtype.Numeric
 }
 
 type OrderItem struct {
@@ -313,24 +504,71 @@ type OrderItem struct {
 	CreatedAt       pgtype.Timestamptz
 }
 
-type Product struct {
+type OrderReturnItem struct {
 	ID          pgtype.UUID
-	Img         string
-	Name        string
-	Price       pgtype.Numeric
-	Discount    pgtype.Numeric
-	Description pgtype.Text
-	Type        pgtype.UUID
-	Category    pgtype.UUID
+	OrderID     pgtype.UUID
+	OrderItemID pgtype.UUID
+	Quantity    int32
+	Reason      string
 	CreatedAt   pgtype.Timestamptz
-	UpdatedAt   pgtype.Timestamptz
 }
 
-type Tag struct {
+type OrderStatusHistory struct {
+	ID         pgtype.UUID
+	OrderID    pgtype.UUID
+	FromStatus NullOrderType
+	ToStatus   OrderType
+	ChangedBy  pgtype.UUID
+	Note       pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	Seq        int64
+}
+
+type Product struct {
+	ID           pgtype.UUID
+	Img          string
+	Name         string
+	Price        pgtype.Numeric
+	Discount     pgtype.Numeric
+	Description  pgtype.Text
+	Category     pgtype.UUID
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+	Stock        int32
+	SearchVector interface{}
+}
+
+type ProductAttributeValue struct {
+	ProductID   pgtype.UUID
+	AttributeID pgtype.UUID
+	TextValue   pgtype.Text
+	MinValue    pgtype.Numeric
+	MaxValue    pgtype.Numeric
+}
+
+type ProductInteraction struct {
+	ID         pgtype.UUID
+	ProductID  pgtype.UUID
+	UserID     pgtype.UUID
+	Type       ProdInteractionType
+	Content    string
+	Rating     pgtype.Int2
+	IsAnswered pgtype.Bool
+	Response   pgtype.Text
+	AnsweredBy pgtype.UUID
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type StockMovement struct {
 	ID        pgtype.UUID
-	Name      string
+	ProductID pgtype.UUID
+	Quantity  int32
+	Reason    StockMovementType
+	OrderID   pgtype.UUID
+	Note      pgtype.Text
+	CreatedBy pgtype.UUID
 	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
 }
 
 type User struct {
@@ -11,9 +11,353 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addFavorite = `-- name: AddFavorite :exec
+INSERT INTO favorites (user_id, product_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddFavoriteParams struct {
+	UserID    pgtype.UUID
+	ProductID pgtype.UUID
+}
+
+func (q *Queries) AddFavorite(ctx context.Context, arg AddFavoriteParams) error {
+	_, err := q.db.Exec(ctx, addFavorite, arg.UserID, arg.ProductID)
+	return err
+}
+
+const addProductStock = `-- name: AddProductStock :one
+UPDATE products
+SET stock = stock + $1::int
+WHERE id = $2
+RETURNING stock
+`
+
+type AddProductStockParams struct {
+	Quantity int32
+	ID       pgtype.UUID
+}
+
+func (q *Queries) AddProductStock(ctx context.Context, arg AddProductStockParams) (int32, error) {
+	row := q.db.QueryRow(ctx, addProductStock, arg.Quantity, arg.ID)
+	var stock int32
+	err := row.Scan(&stock)
+	return stock, err
+}
+
+const answerProductQuestion = `-- name: AnswerProductQuestion :exec
+UPDATE product_interactions
+SET response    = $2,
+    answered_by = $3,
+    is_answered = TRUE
+WHERE id = $1
+  AND type = 'question'
+`
+
+type AnswerProductQuestionParams struct {
+	ID         pgtype.UUID
+	Response   pgtype.Text
+	AnsweredBy pgtype.UUID
+}
+
+func (q *Queries) AnswerProductQuestion(ctx context.Context, arg AnswerProductQuestionParams) error {
+	_, err := q.db.Exec(ctx, answerProductQuestion, arg.ID, arg.Response, arg.AnsweredBy)
+	return err
+}
+
+const assignChat = `-- name: AssignChat :exec
+UPDATE chats
+SET assigned_to = $2
+WHERE id = $1
+`
+
+type AssignChatParams struct {
+	ID         pgtype.UUID
+	AssignedTo pgtype.UUID
+}
+
+func (q *Queries) AssignChat(ctx context.Context, arg AssignChatParams) error {
+	_, err := q.db.Exec(ctx, assignChat, arg.ID, arg.AssignedTo)
+	return err
+}
+
+const backdateOrder = `-- name: BackdateOrder :exec
+WITH history AS (
+    UPDATE order_status_history
+        SET created_at = created_at - $1::interval
+        WHERE order_id = $2)
+UPDATE orders
+SET created_at = created_at - $1::interval
+WHERE id = $2
+`
+
+type BackdateOrderParams struct {
+	Age pgtype.Interval
+	ID  pgtype.UUID
+}
+
+func (q *Queries) BackdateOrder(ctx context.Context, arg BackdateOrderParams) error {
+	_, err := q.db.Exec(ctx, backdateOrder, arg.Age, arg.ID)
+	return err
+}
+
+const claimChat = `-- name: ClaimChat :execrows
+UPDATE chats
+SET assigned_to = $2
+WHERE id = $1
+  AND status = 'open'
+  AND assigned_to IS NULL
+`
+
+type ClaimChatParams struct {
+	ID         pgtype.UUID
+	AssignedTo pgtype.UUID
+}
+
+func (q *Queries) ClaimChat(ctx context.Context, arg ClaimChatParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimChat, arg.ID, arg.AssignedTo)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const countChatsByCreator = `-- name: CountChatsByCreator :one
+SELECT COUNT(*)
+FROM chats
+WHERE created_by = $1
+`
+
+func (q *Queries) CountChatsByCreator(ctx context.Context, createdBy pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countChatsByCreator, createdBy)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countMessagesByChatId = `-- name: CountMessagesByChatId :one
+SELECT COUNT(*)
+FROM messages
+WHERE chat_id = $1
+`
+
+func (q *Queries) CountMessagesByChatId(ctx context.Context, chatID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countMessagesByChatId, chatID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOrders = `-- name: CountOrders :one
+SELECT COUNT(*)
+FROM orders
+`
+
+func (q *Queries) CountOrders(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countOrders)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOrdersByUserId = `-- name: CountOrdersByUserId :one
+SELECT COUNT(*)
+FROM orders
+WHERE user_id = $1
+`
+
+func (q *Queries) CountOrdersByUserId(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countOrdersByUserId, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*)
+FROM users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createApiKey = `-- name: CreateApiKey :one
+INSERT INTO api_tokens (user_id, kind, name, prefix, token_hash, scopes, expires_at)
+VALUES ($1, 'key', $2, $3, $4, $5, $6)
+RETURNING id, user_id, kind, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at
+`
+
+type CreateApiKeyParams struct {
+	UserID    pgtype.UUID
+	Name      pgtype.Text
+	Prefix    pgtype.Text
+	TokenHash []byte
+	Scopes    []string
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiToken, error) {
+	row := q.db.QueryRow(ctx, createApiKey,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Kind,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createApiToken = `-- name: CreateApiToken :one
+INSERT INTO api_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, kind, name, prefix, token_hash, scopes, expires_at, last_used_at, created_at
+`
+
+type CreateApiTokenParams struct {
+	UserID    pgtype.UUID
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateApiToken(ctx context.Context, arg CreateApiTokenParams) (ApiToken, error) {
+	row := q.db.QueryRow(ctx, createApiToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Kind,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAttribute = `-- name: CreateAttribute :one
+INSERT INTO attributes (category_id, slug, name, kind, unit, options, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, category_id, slug, name, kind, unit, options, position, created_at, updated_at
+`
+
+type CreateAttributeParams struct {
+	CategoryID pgtype.UUID
+	Slug       string
+	Name       string
+	Kind       AttributeKind
+	Unit       string
+	Options    []string
+	Position   int32
+}
+
+func (q *Queries) CreateAttribute(ctx context.Context, arg CreateAttributeParams) (Attribute, error) {
+	row := q.db.QueryRow(ctx, createAttribute,
+		arg.CategoryID,
+		arg.Slug,
+		arg.Name,
+		arg.Kind,
+		arg.Unit,
+		arg.Options,
+		arg.Position,
+	)
+	var i Attribute
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Slug,
+		&i.Name,
+		&i.Kind,
+		&i.Unit,
+		&i.Options,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createCannedResponse = `-- name: CreateCannedResponse :exec
+INSERT INTO canned_responses (title, content, created_by)
+VALUES ($1, $2, $3)
+`
+
+type CreateCannedResponseParams struct {
+	Title     string
+	Content   string
+	CreatedBy pgtype.UUID
+}
+
+func (q *Queries) CreateCannedResponse(ctx context.Context, arg CreateCannedResponseParams) error {
+	_, err := q.db.Exec(ctx, createCannedResponse, arg.Title, arg.Content, arg.CreatedBy)
+	return err
+}
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (parent_id, slug, name, description, img, position)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, parent_id, slug, name, description, img, position, path, created_at, updated_at
+`
+
+type CreateCategoryParams struct {
+	ParentID    pgtype.UUID
+	Slug        string
+	Name        string
+	Description string
+	Img         pgtype.Text
+	Position    int32
+}
+
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	row := q.db.QueryRow(ctx, createCategory,
+		arg.ParentID,
+		arg.Slug,
+		arg.Name,
+		arg.Description,
+		arg.Img,
+		arg.Position,
+	)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.ParentID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Img,
+		&i.Position,
+		&i.Path,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createChat = `-- name: CreateChat :one
 INSERT INTO chats (status, created_by)
 VALUES ('open', $1)
+ON CONFLICT (created_by) WHERE status = 'open' DO NOTHING
 RETURNING id
 `
 
@@ -24,10 +368,60 @@ func (q *Queries) CreateChat(ctx context.Context, createdBy pgtype.UUID) (pgtype
 	return id, err
 }
 
+const createDelivery = `-- name: CreateDelivery :one
+INSERT INTO deliveries (order_id, status, tracking_number, estimated_delivery)
+VALUES ($1, 'shipped', $2, $3)
+RETURNING id, order_id, status, tracking_number, estimated_delivery, delivered_at, created_at, updated_at
+`
+
+type CreateDeliveryParams struct {
+	OrderID           pgtype.UUID
+	TrackingNumber    pgtype.Text
+	EstimatedDelivery pgtype.Timestamptz
+}
+
+func (q *Queries) CreateDelivery(ctx context.Context, arg CreateDeliveryParams) (Delivery, error) {
+	row := q.db.QueryRow(ctx, createDelivery, arg.OrderID, arg.TrackingNumber, arg.EstimatedDelivery)
+	var i Delivery
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Status,
+		&i.TrackingNumber,
+		&i.EstimatedDelivery,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createDeliveryEvent = `-- name: CreateDeliveryEvent :exec
+INSERT INTO delivery_events (delivery_id, status, note, created_by)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateDeliveryEventParams struct {
+	DeliveryID pgtype.UUID
+	Status     DeliveryStatus
+	Note       pgtype.Text
+	CreatedBy  pgtype.UUID
+}
+
+func (q *Queries) CreateDeliveryEvent(ctx context.Context, arg CreateDeliveryEventParams) error {
+	_, err := q.db.Exec(ctx, createDeliveryEvent,
+		arg.DeliveryID,
+		arg.Status,
+		arg.Note,
+		arg.CreatedBy,
+	)
+	return err
+}
+
 const createMessage = `-- name: CreateMessage :one
 INSERT INTO messages (chat_id, user_id, content)
 VALUES ($1, $2, $3)
-RETURNING id, chat_id, user_id, content, created_at, updated_at
+RETURNING id, chat_id, user_id, content, created_at, updated_at, seq
 `
 
 type CreateMessageParams struct {
@@ -46,13 +440,15 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Seq,
 	)
 	return i, err
 }
 
-const createOrder = `-- name: CreateOrder :exec
+const createOrder = `-- name: CreateOrder :one
 INSERT INTO orders (user_id, status)
 VALUES ($1, $2)
+RETURNING id
 `
 
 type CreateOrderParams struct {
@@ -60,53 +456,210 @@ type CreateOrderParams struct {
 	Status OrderType
 }
 
-func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) error {
-	_, err := q.db.Exec(ctx, createOrder, arg.UserID, arg.Status)
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, createOrder, arg.UserID, arg.Status)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createOrderDetails = `-- name: CreateOrderDetails :exec
+INSERT INTO order_details (order_id, address, phone_number)
+VALUES ($1, $2, $3)
+`
+
+type CreateOrderDetailsParams struct {
+	OrderID     pgtype.UUID
+	Address     string
+	PhoneNumber pgtype.Text
+}
+
+func (q *Queries) CreateOrderDetails(ctx context.Context, arg CreateOrderDetailsParams) error {
+	_, err := q.db.Exec(ctx, createOrderDetails, arg.OrderID, arg.Address, arg.PhoneNumber)
+	return err
+}
+
+const createOrderItem = `-- name: CreateOrderItem :one
+INSERT INTO order_items (order_id, product_id, quantity, price_at_purchase)
+SELECT $1::uuid,
+       P.id,
+       $2::int,
+       ROUND(P.price * (100 - COALESCE(P.discount, 0)) / 100, 2)
+FROM products P
+WHERE P.id = $3
+RETURNING price_at_purchase
+`
+
+type CreateOrderItemParams struct {
+	OrderID   pgtype.UUID
+	Quantity  int32
+	ProductID pgtype.UUID
+}
+
+func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, createOrderItem, arg.OrderID, arg.Quantity, arg.ProductID)
+	var price_at_purchase pgtype.Numeric
+	err := row.Scan(&price_at_purchase)
+	return price_at_purchase, err
+}
+
+const createOrderReturnItem = `-- name: CreateOrderReturnItem :exec
+INSERT INTO order_return_items (order_id, order_item_id, quantity, reason)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateOrderReturnItemParams struct {
+	OrderID     pgtype.UUID
+	OrderItemID pgtype.UUID
+	Quantity    int32
+	Reason      string
+}
+
+func (q *Queries) CreateOrderReturnItem(ctx context.Context, arg CreateOrderReturnItemParams) error {
+	_, err := q.db.Exec(ctx, createOrderReturnItem,
+		arg.OrderID,
+		arg.OrderItemID,
+		arg.Quantity,
+		arg.Reason,
+	)
 	return err
 }
 
-const createProduct = `-- name: CreateProduct :exec
-INSERT INTO products (name, price, discount, description, type, category, img)
-VALUES ($1, $2, 0, $3, $4, $5, $6)
+const createOrderStatusHistory = `-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, order_id, from_status, to_status, changed_by, note, created_at, seq
+`
+
+type CreateOrderStatusHistoryParams struct {
+	OrderID    pgtype.UUID
+	FromStatus NullOrderType
+	ToStatus   OrderType
+	ChangedBy  pgtype.UUID
+	Note       pgtype.Text
+}
+
+func (q *Queries) CreateOrderStatusHistory(ctx context.Context, arg CreateOrderStatusHistoryParams) (OrderStatusHistory, error) {
+	row := q.db.QueryRow(ctx, createOrderStatusHistory,
+		arg.OrderID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.ChangedBy,
+		arg.Note,
+	)
+	var i OrderStatusHistory
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.ChangedBy,
+		&i.Note,
+		&i.CreatedAt,
+		&i.Seq,
+	)
+	return i, err
+}
+
+const createProduct = `-- name: CreateProduct :one
+INSERT INTO products (name, price, discount, description, category, img)
+VALUES ($1, $2, 0, $3, $4, $5)
+RETURNING id
 `
 
 type CreateProductParams struct {
 	Name        string
 	Price       pgtype.Numeric
 	Description pgtype.Text
-	Type        pgtype.UUID
 	Category    pgtype.UUID
 	Img         string
 }
 
-func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) error {
-	_, err := q.db.Exec(ctx, createProduct,
+func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, createProduct,
 		arg.Name,
 		arg.Price,
 		arg.Description,
-		arg.Type,
 		arg.Category,
 		arg.Img,
 	)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createProductAttributeValue = `-- name: CreateProductAttributeValue :exec
+INSERT INTO product_attribute_values (product_id, attribute_id, text_value, min_value, max_value)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateProductAttributeValueParams struct {
+	ProductID   pgtype.UUID
+	AttributeID pgtype.UUID
+	TextValue   pgtype.Text
+	MinValue    pgtype.Numeric
+	MaxValue    pgtype.Numeric
+}
+
+func (q *Queries) CreateProductAttributeValue(ctx context.Context, arg CreateProductAttributeValueParams) error {
+	_, err := q.db.Exec(ctx, createProductAttributeValue,
+		arg.ProductID,
+		arg.AttributeID,
+		arg.TextValue,
+		arg.MinValue,
+		arg.MaxValue,
+	)
 	return err
 }
 
-const createTag = `-- name: CreateTag :one
-INSERT INTO tags (name)
-VALUES ($1)
-RETURNING id, name, created_at, updated_at
+const createProductInteraction = `-- name: CreateProductInteraction :exec
+INSERT INTO product_interactions (product_id, user_id, type, content, rating)
+VALUES ($1, $2, $3, $4, $5)
 `
 
-func (q *Queries) CreateTag(ctx context.Context, name string) (Tag, error) {
-	row := q.db.QueryRow(ctx, createTag, name)
-	var i Tag
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+type CreateProductInteractionParams struct {
+	ProductID pgtype.UUID
+	UserID    pgtype.UUID
+	Type      ProdInteractionType
+	Content   string
+	Rating    pgtype.Int2
+}
+
+func (q *Queries) CreateProductInteraction(ctx context.Context, arg CreateProductInteractionParams) error {
+	_, err := q.db.Exec(ctx, createProductInteraction,
+		arg.ProductID,
+		arg.UserID,
+		arg.Type,
+		arg.Content,
+		arg.Rating,
 	)
-	return i, err
+	return err
+}
+
+const createStockMovement = `-- name: CreateStockMovement :exec
+INSERT INTO stock_movements (product_id, quantity, reason, order_id, note, created_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateStockMovementParams struct {
+	ProductID pgtype.UUID
+	Quantity  int32
+	Reason    StockMovementType
+	OrderID   pgtype.UUID
+	Note      pgtype.Text
+	CreatedBy pgtype.UUID
+}
+
+func (q *Queries) CreateStockMovement(ctx context.Context, arg CreateStockMovementParams) error {
+	_, err := q.db.Exec(ctx, createStockMovement,
+		arg.ProductID,
+		arg.Quantity,
+		arg.Reason,
+		arg.OrderID,
+		arg.Note,
+		arg.CreatedBy,
+	)
+	return err
 }
 
 const createUser = `-- name: CreateUser :one
@@ -136,24 +689,99 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (pgtype.
 	return id, err
 }
 
-const deleteChat = `-- name: DeleteChat :exec
+const deleteApiKey = `-- name: DeleteApiKey :exec
 DELETE
-FROM chats
+FROM api_tokens
 WHERE id = $1
+  AND kind = 'key'
 `
 
-func (q *Queries) DeleteChat(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteChat, id)
+func (q *Queries) DeleteApiKey(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteApiKey, id)
 	return err
 }
 
-const deleteOrder = `-- name: DeleteOrder :exec
+const deleteApiToken = `-- name: DeleteApiToken :exec
 DELETE
-FROM orders
-WHERE id = $1
+FROM api_tokens
+WHERE token_hash = $1
+  AND kind = 'login'
 `
 
-func (q *Queries) DeleteOrder(ctx context.Context, id pgtype.UUID) error {
+// Only tokens from a login are revoked this way, API keys are revoked by an admin.
+func (q *Queries) DeleteApiToken(ctx context.Context, tokenHash []byte) error {
+	_, err := q.db.Exec(ctx, deleteApiToken, tokenHash)
+	return err
+}
+
+const deleteAttribute = `-- name: DeleteAttribute :exec
+DELETE
+FROM attributes
+WHERE id = $1
+`
+
+func (q *Queries) DeleteAttribute(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteAttribute, id)
+	return err
+}
+
+const deleteCannedResponse = `-- name: DeleteCannedResponse :exec
+DELETE
+FROM canned_responses
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCannedResponse(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCannedResponse, id)
+	return err
+}
+
+const deleteCategory = `-- name: DeleteCategory :exec
+DELETE
+FROM categories
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCategory(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCategory, id)
+	return err
+}
+
+const deleteChat = `-- name: DeleteChat :exec
+DELETE
+FROM chats
+WHERE id = $1
+`
+
+func (q *Queries) DeleteChat(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteChat, id)
+	return err
+}
+
+const deleteFavorite = `-- name: DeleteFavorite :exec
+DELETE
+FROM favorites
+WHERE user_id = $1
+  AND product_id = $2
+`
+
+type DeleteFavoriteParams struct {
+	UserID    pgtype.UUID
+	ProductID pgtype.UUID
+}
+
+func (q *Queries) DeleteFavorite(ctx context.Context, arg DeleteFavoriteParams) error {
+	_, err := q.db.Exec(ctx, deleteFavorite, arg.UserID, arg.ProductID)
+	return err
+}
+
+const deleteOrder = `-- name: DeleteOrder :exec
+DELETE
+FROM orders
+WHERE id = $1
+`
+
+func (q *Queries) DeleteOrder(ctx context.Context, id pgtype.UUID) error {
 	_, err := q.db.Exec(ctx, deleteOrder, id)
 	return err
 }
@@ -169,6 +797,17 @@ func (q *Queries) DeleteOrderItem(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const deleteOrderReturnItemsByOrderId = `-- name: DeleteOrderReturnItemsByOrderId :exec
+DELETE
+FROM order_return_items
+WHERE order_id = $1
+`
+
+func (q *Queries) DeleteOrderReturnItemsByOrderId(ctx context.Context, orderID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteOrderReturnItemsByOrderId, orderID)
+	return err
+}
+
 const deleteProduct = `-- name: DeleteProduct :exec
 DELETE
 FROM products
@@ -180,6 +819,32 @@ func (q *Queries) DeleteProduct(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const deleteProductAttributeValues = `-- name: DeleteProductAttributeValues :exec
+DELETE
+FROM product_attribute_values
+WHERE product_id = $1
+`
+
+func (q *Queries) DeleteProductAttributeValues(ctx context.Context, productID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteProductAttributeValues, productID)
+	return err
+}
+
+const deleteStaleAttributeValues = `-- name: DeleteStaleAttributeValues :exec
+DELETE
+FROM product_attribute_values V
+    USING attributes A
+WHERE A.id = V.attribute_id
+  AND A.id = $1
+  AND A.kind = 'enum'
+  AND NOT (V.text_value = ANY (A.options))
+`
+
+func (q *Queries) DeleteStaleAttributeValues(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteStaleAttributeValues, id)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE
 FROM users
@@ -191,8 +856,83 @@ func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const getAttributeBySlug = `-- name: GetAttributeBySlug :one
+SELECT id, category_id, slug, name, kind, unit, options, position, created_at, updated_at
+FROM attributes
+WHERE slug = $1
+LIMIT 1
+`
+
+func (q *Queries) GetAttributeBySlug(ctx context.Context, slug string) (Attribute, error) {
+	row := q.db.QueryRow(ctx, getAttributeBySlug, slug)
+	var i Attribute
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Slug,
+		&i.Name,
+		&i.Kind,
+		&i.Unit,
+		&i.Options,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCategoryById = `-- name: GetCategoryById :one
+SELECT id, parent_id, slug, name, description, img, position, path, created_at, updated_at
+FROM categories
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCategoryById(ctx context.Context, id pgtype.UUID) (Category, error) {
+	row := q.db.QueryRow(ctx, getCategoryById, id)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.ParentID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Img,
+		&i.Position,
+		&i.Path,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCategoryBySlug = `-- name: GetCategoryBySlug :one
+SELECT id, parent_id, slug, name, description, img, position, path, created_at, updated_at
+FROM categories
+WHERE slug = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCategoryBySlug(ctx context.Context, slug string) (Category, error) {
+	row := q.db.QueryRow(ctx, getCategoryBySlug, slug)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.ParentID,
+		&i.Slug,
+		&i.Name,
+		&i.Description,
+		&i.Img,
+		&i.Position,
+		&i.Path,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getChatByCreator = `-- name: GetChatByCreator :one
-SELECT id, status, created_by, created_at, updated_at
+SELECT id, status, created_by, created_at, updated_at, assigned_to
 from chats
 WHERE created_by = $1
   and status = 'open'
@@ -208,12 +948,13 @@ func (q *Queries) GetChatByCreator(ctx context.Context, createdBy pgtype.UUID) (
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AssignedTo,
 	)
 	return i, err
 }
 
 const getChatById = `-- name: GetChatById :one
-SELECT id, status, created_by, created_at, updated_at
+SELECT id, status, created_by, created_at, updated_at, assigned_to
 from chats
 WHERE id = $1
 LIMIT 1
@@ -228,6 +969,76 @@ func (q *Queries) GetChatById(ctx context.Context, id pgtype.UUID) (Chat, error)
 		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AssignedTo,
+	)
+	return i, err
+}
+
+const getDeliveryByIdForUpdate = `-- name: GetDeliveryByIdForUpdate :one
+SELECT id, order_id, status, tracking_number, estimated_delivery, delivered_at, created_at, updated_at
+FROM deliveries
+WHERE id = $1
+LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetDeliveryByIdForUpdate(ctx context.Context, id pgtype.UUID) (Delivery, error) {
+	row := q.db.QueryRow(ctx, getDeliveryByIdForUpdate, id)
+	var i Delivery
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Status,
+		&i.TrackingNumber,
+		&i.EstimatedDelivery,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getDeliveryByOrderId = `-- name: GetDeliveryByOrderId :one
+SELECT id, order_id, status, tracking_number, estimated_delivery, delivered_at, created_at, updated_at
+FROM deliveries
+WHERE order_id = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetDeliveryByOrderId(ctx context.Context, orderID pgtype.UUID) (Delivery, error) {
+	row := q.db.QueryRow(ctx, getDeliveryByOrderId, orderID)
+	var i Delivery
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Status,
+		&i.TrackingNumber,
+		&i.EstimatedDelivery,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getMessageById = `-- name: GetMessageById :one
+SELECT id, chat_id, user_id, content, created_at, updated_at, seq
+FROM messages
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetMessageById(ctx context.Context, id pgtype.UUID) (Message, error) {
+	row := q.db.QueryRow(ctx, getMessageById, id)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Seq,
 	)
 	return i, err
 }
@@ -252,8 +1063,28 @@ func (q *Queries) GetOrderById(ctx context.Context, id pgtype.UUID) (Order, erro
 	return i, err
 }
 
+const getOrderByIdForUpdate = `-- name: GetOrderByIdForUpdate :one
+SELECT id, user_id, status, created_at, updated_at
+FROM orders
+WHERE id = $1
+LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetOrderByIdForUpdate(ctx context.Context, id pgtype.UUID) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderByIdForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getOrderDetailsById = `-- name: GetOrderDetailsById :one
-SELECT id, order_id, address, phone_number, return_statement, created_at, updated_at
+SELECT id, order_id, address, phone_number, return_statement, created_at, updated_at, refund_amount
 FROM order_details
 WHERE order_id = $1
 LIMIT 1
@@ -270,12 +1101,13 @@ func (q *Queries) GetOrderDetailsById(ctx context.Context, orderID pgtype.UUID)
 		&i.ReturnStatement,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RefundAmount,
 	)
 	return i, err
 }
 
 const getOrderItemById = `-- name: GetOrderItemById :one
-SELECT id, order_id, address, phone_number, return_statement, created_at, updated_at
+SELECT id, order_id, address, phone_number, return_statement, created_at, updated_at, refund_amount
 FROM order_details
 WHERE id = $1
 `
@@ -291,6 +1123,30 @@ func (q *Queries) GetOrderItemById(ctx context.Context, id pgtype.UUID) (OrderDe
 		&i.ReturnStatement,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RefundAmount,
+	)
+	return i, err
+}
+
+const getOrderStatusHistoryById = `-- name: GetOrderStatusHistoryById :one
+SELECT id, order_id, from_status, to_status, changed_by, note, created_at, seq
+FROM order_status_history
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetOrderStatusHistoryById(ctx context.Context, id pgtype.UUID) (OrderStatusHistory, error) {
+	row := q.db.QueryRow(ctx, getOrderStatusHistoryById, id)
+	var i OrderStatusHistory
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.ChangedBy,
+		&i.Note,
+		&i.CreatedAt,
+		&i.Seq,
 	)
 	return i, err
 }
@@ -304,11 +1160,12 @@ SELECT DISTINCT P.id,
                 P.created_at,
                 P.updated_at,
                 P.img,
-                TYP.name as type,
-                CAT.name as category
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category
 FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
 WHERE P.id = $1
 LIMIT 1
 `
@@ -322,6 +1179,7 @@ type GetProductByIdRow struct {
 	CreatedAt   pgtype.Timestamptz
 	UpdatedAt   pgtype.Timestamptz
 	Img         string
+	Stock       int32
 	Type        string
 	Category    string
 }
@@ -338,6 +1196,7 @@ func (q *Queries) GetProductById(ctx context.Context, id pgtype.UUID) (GetProduc
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Img,
+		&i.Stock,
 		&i.Type,
 		&i.Category,
 	)
@@ -353,11 +1212,12 @@ SELECT DISTINCT P.id,
                 P.created_at,
                 P.updated_at,
                 P.img,
-                TYP.name as type,
-                CAT.name as category
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category
 FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
 WHERE P.name = $1
 LIMIT 1
 `
@@ -371,6 +1231,7 @@ type GetProductByNameRow struct {
 	CreatedAt   pgtype.Timestamptz
 	UpdatedAt   pgtype.Timestamptz
 	Img         string
+	Stock       int32
 	Type        string
 	Category    string
 }
@@ -387,46 +1248,69 @@ func (q *Queries) GetProductByName(ctx context.Context, name string) (GetProduct
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Img,
+		&i.Stock,
 		&i.Type,
 		&i.Category,
 	)
 	return i, err
 }
 
-const getTagById = `-- name: GetTagById :one
-SELECT id, name, created_at, updated_at
-FROM tags
+const getProductInteractionById = `-- name: GetProductInteractionById :one
+SELECT id, product_id, user_id, type, content, rating, is_answered, response, answered_by, created_at, updated_at
+FROM product_interactions
 WHERE id = $1
 LIMIT 1
 `
 
-func (q *Queries) GetTagById(ctx context.Context, id pgtype.UUID) (Tag, error) {
-	row := q.db.QueryRow(ctx, getTagById, id)
-	var i Tag
+func (q *Queries) GetProductInteractionById(ctx context.Context, id pgtype.UUID) (ProductInteraction, error) {
+	row := q.db.QueryRow(ctx, getProductInteractionById, id)
+	var i ProductInteraction
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
+		&i.ProductID,
+		&i.UserID,
+		&i.Type,
+		&i.Content,
+		&i.Rating,
+		&i.IsAnswered,
+		&i.Response,
+		&i.AnsweredBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getTagByName = `-- name: GetTagByName :one
-SELECT id, name, created_at, updated_at
-FROM tags
-WHERE name = $1
+const getUserByApiToken = `-- name: GetUserByApiToken :one
+SELECT U.id, U.email, U.fname, U.lname, U.role, A.id as token_id, A.scopes
+FROM api_tokens A
+         JOIN users U on U.id = A.user_id
+WHERE A.token_hash = $1
+  AND A.expires_at > NOW()
 LIMIT 1
 `
 
-func (q *Queries) GetTagByName(ctx context.Context, name string) (Tag, error) {
-	row := q.db.QueryRow(ctx, getTagByName, name)
-	var i Tag
+type GetUserByApiTokenRow struct {
+	ID      pgtype.UUID
+	Email   string
+	Fname   string
+	Lname   string
+	Role    UserRole
+	TokenID pgtype.UUID
+	Scopes  []string
+}
+
+func (q *Queries) GetUserByApiToken(ctx context.Context, tokenHash []byte) (GetUserByApiTokenRow, error) {
+	row := q.db.QueryRow(ctx, getUserByApiToken, tokenHash)
+	var i GetUserByApiTokenRow
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.Email,
+		&i.Fname,
+		&i.Lname,
+		&i.Role,
+		&i.TokenID,
+		&i.Scopes,
 	)
 	return i, err
 }
@@ -479,39 +1363,29 @@ func (q *Queries) GetUserById(ctx context.Context, id pgtype.UUID) (GetUserByIdR
 	return i, err
 }
 
-const listAllCategoryTags = `-- name: ListAllCategoryTags :many
-SELECT DISTINCT P.id, P.name
-FROM tags T
-         JOIN products P ON T.id = P.category
+const hasCompletedOrderWithProduct = `-- name: HasCompletedOrderWithProduct :one
+SELECT EXISTS (SELECT 1
+               FROM orders O
+                        JOIN order_items OI on OI.order_id = O.id
+               WHERE O.user_id = $1
+                 AND OI.product_id = $2
+                 AND O.status = 'completed')
 `
 
-type ListAllCategoryTagsRow struct {
-	ID   pgtype.UUID
-	Name string
+type HasCompletedOrderWithProductParams struct {
+	UserID    pgtype.UUID
+	ProductID pgtype.UUID
 }
 
-func (q *Queries) ListAllCategoryTags(ctx context.Context) ([]ListAllCategoryTagsRow, error) {
-	rows, err := q.db.Query(ctx, listAllCategoryTags)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListAllCategoryTagsRow
-	for rows.Next() {
-		var i ListAllCategoryTagsRow
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) HasCompletedOrderWithProduct(ctx context.Context, arg HasCompletedOrderWithProductParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasCompletedOrderWithProduct, arg.UserID, arg.ProductID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
 }
 
 const listAllChats = `-- name: ListAllChats :many
-SELECT id, status, created_by, created_at, updated_at
+SELECT id, status, created_by, created_at, updated_at, assigned_to
 FROM chats
 `
 
@@ -530,6 +1404,7 @@ func (q *Queries) ListAllChats(ctx context.Context) ([]Chat, error) {
 			&i.CreatedBy,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AssignedTo,
 		); err != nil {
 			return nil, err
 		}
@@ -541,31 +1416,46 @@ func (q *Queries) ListAllChats(ctx context.Context) ([]Chat, error) {
 	return items, nil
 }
 
-const listAllMessagesByChatId = `-- name: ListAllMessagesByChatId :one
-SELECT id, chat_id, user_id, content, created_at, updated_at
+const listAllMessagesByChatId = `-- name: ListAllMessagesByChatId :many
+SELECT id, chat_id, user_id, content, created_at, updated_at, seq
 FROM messages
 WHERE chat_id = $1
-ORDER BY created_at desc
+ORDER BY seq
 `
 
-func (q *Queries) ListAllMessagesByChatId(ctx context.Context, chatID pgtype.UUID) (Message, error) {
-	row := q.db.QueryRow(ctx, listAllMessagesByChatId, chatID)
-	var i Message
-	err := row.Scan(
-		&i.ID,
-		&i.ChatID,
-		&i.UserID,
-		&i.Content,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) ListAllMessagesByChatId(ctx context.Context, chatID pgtype.UUID) ([]Message, error) {
+	rows, err := q.db.Query(ctx, listAllMessagesByChatId, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const listAllOrderItemsById = `-- name: ListAllOrderItemsById :many
 SELECT id, order_id, product_id, quantity, price_at_purchase, created_at
 FROM order_items
 WHERE order_id = $1
+ORDER BY created_at
 `
 
 func (q *Queries) ListAllOrderItemsById(ctx context.Context, orderID pgtype.UUID) ([]OrderItem, error) {
@@ -595,26 +1485,1555 @@ func (q *Queries) ListAllOrderItemsById(ctx context.Context, orderID pgtype.UUID
 	return items, nil
 }
 
-const listAllOrders = `-- name: ListAllOrders :many
-SELECT id, user_id, status, created_at, updated_at
-FROM orders
+const listAllOrders = `-- name: ListAllOrders :many
+SELECT id, user_id, status, created_at, updated_at
+FROM orders
+`
+
+func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listAllOrders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllOrdersByStatus = `-- name: ListAllOrdersByStatus :many
+SELECT id, user_id, status, created_at, updated_at
+FROM orders
+WHERE status = $1
+ORDER BY updated_at
+`
+
+func (q *Queries) ListAllOrdersByStatus(ctx context.Context, status OrderType) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listAllOrdersByStatus, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllOrdersByUserId = `-- name: ListAllOrdersByUserId :many
+SELECT id, user_id, status, created_at, updated_at
+FROM orders
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAllOrdersByUserId(ctx context.Context, userID pgtype.UUID) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listAllOrdersByUserId, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllProducts = `-- name: ListAllProducts :many
+SELECT DISTINCT P.id,
+                P.name,
+                P.price,
+                P.discount,
+                P.description,
+                P.created_at,
+                P.updated_at,
+                P.img,
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category,
+                COALESCE(R.avg_rating, 0)::float8 as avg_rating,
+                COALESCE(R.review_count, 0)::int  as review_count
+FROM products P
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id
+`
+
+type ListAllProductsRow struct {
+	ID          pgtype.UUID
+	Name        string
+	Price       pgtype.Numeric
+	Discount    pgtype.Numeric
+	Description pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+	Img         string
+	Stock       int32
+	Type        string
+	Category    string
+	AvgRating   float64
+	ReviewCount int32
+}
+
+func (q *Queries) ListAllProducts(ctx context.Context) ([]ListAllProductsRow, error) {
+	rows, err := q.db.Query(ctx, listAllProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllProductsRow
+	for rows.Next() {
+		var i ListAllProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Discount,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Img,
+			&i.Stock,
+			&i.Type,
+			&i.Category,
+			&i.AvgRating,
+			&i.ReviewCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllProductsByType = `-- name: ListAllProductsByType :many
+SELECT DISTINCT P.id,
+                P.name,
+                P.price,
+                P.discount,
+                P.description,
+                P.created_at,
+                P.updated_at,
+                P.img,
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category,
+                COALESCE(R.avg_rating, 0)::float8 as avg_rating,
+                COALESCE(R.review_count, 0)::int  as review_count
+FROM products P
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id
+WHERE TYP.slug = $1
+`
+
+type ListAllProductsByTypeRow struct {
+	ID          pgtype.UUID
+	Name        string
+	Price       pgtype.Numeric
+	Discount    pgtype.Numeric
+	Description pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+	Img         string
+	Stock       int32
+	Type        string
+	Category    string
+	AvgRating   float64
+	ReviewCount int32
+}
+
+func (q *Queries) ListAllProductsByType(ctx context.Context, name string) ([]ListAllProductsByTypeRow, error) {
+	rows, err := q.db.Query(ctx, listAllProductsByType, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllProductsByTypeRow
+	for rows.Next() {
+		var i ListAllProductsByTypeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Discount,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Img,
+			&i.Stock,
+			&i.Type,
+			&i.Category,
+			&i.AvgRating,
+			&i.ReviewCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllUsers = `-- name: ListAllUsers :many
+SELECT id, email, fname, lname, role
+FROM users
+ORDER BY fname, lname
+`
+
+type ListAllUsersRow struct {
+	ID    pgtype.UUID
+	Email string
+	Fname string
+	Lname string
+	Role  UserRole
+}
+
+func (q *Queries) ListAllUsers(ctx context.Context) ([]ListAllUsersRow, error) {
+	rows, err := q.db.Query(ctx, listAllUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllUsersRow
+	for rows.Next() {
+		var i ListAllUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Fname,
+			&i.Lname,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listApiKeys = `-- name: ListApiKeys :many
+SELECT A.id, A.name, A.prefix, A.scopes, A.expires_at, A.last_used_at, A.created_at, U.fname, U.lname
+FROM api_tokens A
+         JOIN users U on U.id = A.user_id
+WHERE A.kind = 'key'
+ORDER BY A.created_at DESC
+`
+
+type ListApiKeysRow struct {
+	ID         pgtype.UUID
+	Name       pgtype.Text
+	Prefix     pgtype.Text
+	Scopes     []string
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	Fname      string
+	Lname      string
+}
+
+func (q *Queries) ListApiKeys(ctx context.Context) ([]ListApiKeysRow, error) {
+	rows, err := q.db.Query(ctx, listApiKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListApiKeysRow
+	for rows.Next() {
+		var i ListApiKeysRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Prefix,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			&i.Fname,
+			&i.Lname,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAttributes = `-- name: ListAttributes :many
+SELECT A.id, A.category_id, A.slug, A.name, A.kind, A.unit, A.options, A.position, A.created_at, A.updated_at, C.slug as category
+FROM attributes A
+         JOIN categories C on C.id = A.category_id
+ORDER BY A.position, A.name
+`
+
+type ListAttributesRow struct {
+	ID         pgtype.UUID
+	CategoryID pgtype.UUID
+	Slug       string
+	Name       string
+	Kind       AttributeKind
+	Unit       string
+	Options    []string
+	Position   int32
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+	Category   string
+}
+
+func (q *Queries) ListAttributes(ctx context.Context) ([]ListAttributesRow, error) {
+	rows, err := q.db.Query(ctx, listAttributes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAttributesRow
+	for rows.Next() {
+		var i ListAttributesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CategoryID,
+			&i.Slug,
+			&i.Name,
+			&i.Kind,
+			&i.Unit,
+			&i.Options,
+			&i.Position,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Category,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCannedResponses = `-- name: ListCannedResponses :many
+SELECT id, title, content, created_by, created_at
+FROM canned_responses
+ORDER BY title
+`
+
+func (q *Queries) ListCannedResponses(ctx context.Context) ([]CannedResponse, error) {
+	rows, err := q.db.Query(ctx, listCannedResponses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CannedResponse
+	for rows.Next() {
+		var i CannedResponse
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCatalogAttributeFacets = `-- name: ListCatalogAttributeFacets :many
+WITH matches AS (SELECT P.id
+                 FROM products P
+                          JOIN categories CAT on CAT.id = P.category
+                          JOIN categories TYP on TYP.id = CAT.path[1]
+                 WHERE ($1::text[] IS NULL OR TYP.slug = ANY ($1::text[]))
+                   AND ($2::text[] IS NULL OR CAT.slug = ANY ($2::text[]))
+                   AND ($3::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 >= $3::numeric)
+                   AND ($4::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 <= $4::numeric)
+                   AND (NOT $5::bool OR COALESCE(P.discount, 0) > 0)
+                   AND (NOT $6::bool OR P.stock > 0)
+                   AND ($7::uuid IS NULL OR $7::uuid = ANY (CAT.path)))
+SELECT A.id,
+       A.slug,
+       A.name,
+       A.kind,
+       A.unit,
+       (CASE A.kind WHEN 'enum' THEN V.text_value ELSE '' END)::text as value,
+       COUNT(*)::int                                                as count,
+       COALESCE(MIN(V.min_value), 0)::float8                        as min_value,
+       COALESCE(MAX(V.max_value), 0)::float8                        as max_value
+FROM matches M
+         JOIN product_attribute_values V on V.product_id = M.id
+         JOIN attributes A on A.id = V.attribute_id
+WHERE product_matches_attributes(M.id, $8::uuid[], $9::text[],
+                                 $10::numeric[], $11::numeric[], A.id)
+GROUP BY A.id, A.slug, A.name, A.kind, A.unit, A.position, value
+ORDER BY A.position, A.name, A.slug, value
+`
+
+type ListCatalogAttributeFacetsParams struct {
+	Types          []string
+	Categories     []string
+	MinPrice       pgtype.Numeric
+	MaxPrice       pgtype.Numeric
+	OnDiscount     bool
+	InStock        bool
+	Subtree        pgtype.UUID
+	AttributeIds   []pgtype.UUID
+	AttributeTexts []string
+	AttributeMins  []pgtype.Numeric
+	AttributeMaxs  []pgtype.Numeric
+}
+
+type ListCatalogAttributeFacetsRow struct {
+	ID       pgtype.UUID
+	Slug     string
+	Name     string
+	Kind     AttributeKind
+	Unit     string
+	Value    string
+	Count    int32
+	MinValue float64
+	MaxValue float64
+}
+
+func (q *Queries) ListCatalogAttributeFacets(ctx context.Context, arg ListCatalogAttributeFacetsParams) ([]ListCatalogAttributeFacetsRow, error) {
+	rows, err := q.db.Query(ctx, listCatalogAttributeFacets,
+		arg.Types,
+		arg.Categories,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.OnDiscount,
+		arg.InStock,
+		arg.Subtree,
+		arg.AttributeIds,
+		arg.AttributeTexts,
+		arg.AttributeMins,
+		arg.AttributeMaxs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCatalogAttributeFacetsRow
+	for rows.Next() {
+		var i ListCatalogAttributeFacetsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Name,
+			&i.Kind,
+			&i.Unit,
+			&i.Value,
+			&i.Count,
+			&i.MinValue,
+			&i.MaxValue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCatalogFacets = `-- name: ListCatalogFacets :many
+WITH matches AS (SELECT TYP.slug                                                                       as type,
+                        CAT.slug                                                                       as category,
+                        COALESCE(P.discount, 0) > 0                                                    as discounted,
+                        P.stock > 0                                                                    as stocked,
+                        ($1::text[] IS NULL OR TYP.slug = ANY ($1::text[])) as type_match,
+                        ($2::text[] IS NULL OR
+                         CAT.slug = ANY ($2::text[]))                               as category_match,
+                        ($3::numeric IS NULL OR
+                         P.price * (100 - COALESCE(P.discount, 0)) / 100 >= $3::numeric) AND
+                        ($4::numeric IS NULL OR
+                         P.price * (100 - COALESCE(P.discount, 0)) / 100 <= $4::numeric) as price_match,
+                        (NOT $5::bool OR COALESCE(P.discount, 0) > 0)               as discount_match,
+                        (NOT $6::bool OR P.stock > 0)                                  as stock_match
+                 FROM products P
+                          JOIN categories CAT on CAT.id = P.category
+                          JOIN categories TYP on TYP.id = CAT.path[1]
+                 WHERE ($7::uuid IS NULL OR $7::uuid = ANY (CAT.path))
+                   AND product_matches_attributes(P.id, $8::uuid[], $9::text[],
+                                                  $10::numeric[], $11::numeric[]))
+SELECT 'total'::text as facet, ''::text as value, COUNT(*)::int as count
+FROM matches
+WHERE type_match AND category_match AND price_match AND discount_match AND stock_match
+UNION ALL
+SELECT 'type', type, COUNT(*)::int
+FROM matches
+WHERE category_match AND price_match AND discount_match AND stock_match
+GROUP BY type
+UNION ALL
+SELECT 'category', category, COUNT(*)::int
+FROM matches
+WHERE type_match AND price_match AND discount_match AND stock_match
+GROUP BY category
+UNION ALL
+SELECT 'on_discount', 'true', COUNT(*)::int
+FROM matches
+WHERE type_match AND category_match AND price_match AND stock_match AND discounted
+UNION ALL
+SELECT 'in_stock', 'true', COUNT(*)::int
+FROM matches
+WHERE type_match AND category_match AND price_match AND discount_match AND stocked
+ORDER BY facet, value
+`
+
+type ListCatalogFacetsParams struct {
+	Types          []string
+	Categories     []string
+	MinPrice       pgtype.Numeric
+	MaxPrice       pgtype.Numeric
+	OnDiscount     bool
+	InStock        bool
+	Subtree        pgtype.UUID
+	AttributeIds   []pgtype.UUID
+	AttributeTexts []string
+	AttributeMins  []pgtype.Numeric
+	AttributeMaxs  []pgtype.Numeric
+}
+
+type ListCatalogFacetsRow struct {
+	Facet string
+	Value string
+	Count int32
+}
+
+func (q *Queries) ListCatalogFacets(ctx context.Context, arg ListCatalogFacetsParams) ([]ListCatalogFacetsRow, error) {
+	rows, err := q.db.Query(ctx, listCatalogFacets,
+		arg.Types,
+		arg.Categories,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.OnDiscount,
+		arg.InStock,
+		arg.Subtree,
+		arg.AttributeIds,
+		arg.AttributeTexts,
+		arg.AttributeMins,
+		arg.AttributeMaxs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCatalogFacetsRow
+	for rows.Next() {
+		var i ListCatalogFacetsRow
+		if err := rows.Scan(&i.Facet, &i.Value, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCatalogProducts = `-- name: ListCatalogProducts :many
+WITH catalog AS (SELECT P.id,
+                        P.name,
+                        P.price,
+                        P.discount,
+                        P.description,
+                        P.created_at,
+                        P.updated_at,
+                        P.img,
+                        P.stock,
+                        TYP.slug                                                   as type,
+                        CAT.slug                                                   as category,
+                        COALESCE(R.avg_rating, 0)::float8                          as avg_rating,
+                        COALESCE(R.review_count, 0)::int                           as review_count,
+                        CASE $1::text
+                            WHEN 'price_asc' THEN (P.price * (100 - COALESCE(P.discount, 0)) / 100)::float8
+                            WHEN 'price_desc' THEN -(P.price * (100 - COALESCE(P.discount, 0)) / 100)::float8
+                            WHEN 'popular' THEN -COALESCE(S.sold, 0)::float8
+                            WHEN 'rating' THEN -COALESCE(R.avg_rating, 0)::float8
+                            ELSE -EXTRACT(EPOCH FROM COALESCE(P.created_at, 'epoch'))::float8
+                            END::float8                                            as sort_key
+                 FROM products P
+                          JOIN categories CAT on CAT.id = P.category
+                          JOIN categories TYP on TYP.id = CAT.path[1]
+                          LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                                     FROM product_interactions
+                                     WHERE type = 'review'
+                                     GROUP BY product_id) R on R.product_id = P.id
+                          LEFT JOIN (SELECT OI.product_id, SUM(OI.quantity) as sold
+                                     FROM order_items OI
+                                              JOIN orders O on O.id = OI.order_id
+                                     WHERE O.status <> 'cancelled'
+                                     GROUP BY OI.product_id) S on S.product_id = P.id
+                 WHERE ($2::text[] IS NULL OR TYP.slug = ANY ($2::text[]))
+                   AND ($3::text[] IS NULL OR CAT.slug = ANY ($3::text[]))
+                   AND ($4::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 >= $4::numeric)
+                   AND ($5::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 <= $5::numeric)
+                   AND (NOT $6::bool OR COALESCE(P.discount, 0) > 0)
+                   AND (NOT $7::bool OR P.stock > 0)
+                   AND ($8::uuid IS NULL OR $8::uuid = ANY (CAT.path))
+                   AND product_matches_attributes(P.id, $9::uuid[], $10::text[],
+                                                  $11::numeric[], $12::numeric[]))
+SELECT id,
+       name,
+       price,
+       discount,
+       description,
+       created_at,
+       updated_at,
+       img,
+       stock,
+       type,
+       category,
+       avg_rating,
+       review_count,
+       sort_key
+FROM catalog
+WHERE $13::float8 IS NULL
+   OR (sort_key, id) > ($13::float8, $14::uuid)
+ORDER BY sort_key, id
+LIMIT $15
+`
+
+type ListCatalogProductsParams struct {
+	Sort           string
+	Types          []string
+	Categories     []string
+	MinPrice       pgtype.Numeric
+	MaxPrice       pgtype.Numeric
+	OnDiscount     bool
+	InStock        bool
+	Subtree        pgtype.UUID
+	AttributeIds   []pgtype.UUID
+	AttributeTexts []string
+	AttributeMins  []pgtype.Numeric
+	AttributeMaxs  []pgtype.Numeric
+	AfterKey       pgtype.Float8
+	AfterID        pgtype.UUID
+	PageSize       int32
+}
+
+type ListCatalogProductsRow struct {
+	ID          pgtype.UUID
+	Name        string
+	Price       pgtype.Numeric
+	Discount    pgtype.Numeric
+	Description pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+	Img         string
+	Stock       int32
+	Type        string
+	Category    string
+	AvgRating   float64
+	ReviewCount int32
+	SortKey     float64
+}
+
+func (q *Queries) ListCatalogProducts(ctx context.Context, arg ListCatalogProductsParams) ([]ListCatalogProductsRow, error) {
+	rows, err := q.db.Query(ctx, listCatalogProducts,
+		arg.Sort,
+		arg.Types,
+		arg.Categories,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.OnDiscount,
+		arg.InStock,
+		arg.Subtree,
+		arg.AttributeIds,
+		arg.AttributeTexts,
+		arg.AttributeMins,
+		arg.AttributeMaxs,
+		arg.AfterKey,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCatalogProductsRow
+	for rows.Next() {
+		var i ListCatalogProductsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Discount,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Img,
+			&i.Stock,
+			&i.Type,
+			&i.Category,
+			&i.AvgRating,
+			&i.ReviewCount,
+			&i.SortKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategories = `-- name: ListCategories :many
+SELECT C.id, C.parent_id, C.slug, C.name, C.description, C.img, C.position, C.path, C.created_at, C.updated_at, COALESCE(N.product_count, 0)::int as product_count
+FROM categories C
+         LEFT JOIN (SELECT A.id, COUNT(*) as product_count
+                    FROM products P
+                             JOIN categories PC on PC.id = P.category
+                             JOIN categories A on A.id = ANY (PC.path)
+                    GROUP BY A.id) N on N.id = C.id
+ORDER BY C.position, C.name
+`
+
+type ListCategoriesRow struct {
+	ID           pgtype.UUID
+	ParentID     pgtype.UUID
+	Slug         string
+	Name         string
+	Description  string
+	Img          pgtype.Text
+	Position     int32
+	Path         []pgtype.UUID
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+	ProductCount int32
+}
+
+func (q *Queries) ListCategories(ctx context.Context) ([]ListCategoriesRow, error) {
+	rows, err := q.db.Query(ctx, listCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCategoriesRow
+	for rows.Next() {
+		var i ListCategoriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Slug,
+			&i.Name,
+			&i.Description,
+			&i.Img,
+			&i.Position,
+			&i.Path,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProductCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryAncestors = `-- name: ListCategoryAncestors :many
+SELECT A.id, A.parent_id, A.slug, A.name, A.description, A.img, A.position, A.path, A.created_at, A.updated_at
+FROM categories C
+         JOIN categories A on A.id = ANY (C.path)
+WHERE C.id = $1
+ORDER BY array_position(C.path, A.id)
+`
+
+func (q *Queries) ListCategoryAncestors(ctx context.Context, id pgtype.UUID) ([]Category, error) {
+	rows, err := q.db.Query(ctx, listCategoryAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentID,
+			&i.Slug,
+			&i.Name,
+			&i.Description,
+			&i.Img,
+			&i.Position,
+			&i.Path,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryAttributes = `-- name: ListCategoryAttributes :many
+SELECT A.id, A.category_id, A.slug, A.name, A.kind, A.unit, A.options, A.position, A.created_at, A.updated_at
+FROM categories C
+         JOIN attributes A on A.category_id = ANY (C.path)
+WHERE C.id = $1
+ORDER BY array_position(C.path, A.category_id), A.position, A.name
+`
+
+func (q *Queries) ListCategoryAttributes(ctx context.Context, id pgtype.UUID) ([]Attribute, error) {
+	rows, err := q.db.Query(ctx, listCategoryAttributes, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attribute
+	for rows.Next() {
+		var i Attribute
+		if err := rows.Scan(
+			&i.ID,
+			&i.CategoryID,
+			&i.Slug,
+			&i.Name,
+			&i.Kind,
+			&i.Unit,
+			&i.Options,
+			&i.Position,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChatQueue = `-- name: ListChatQueue :many
+SELECT C.id,
+       C.status,
+       C.created_by,
+       C.assigned_to,
+       C.created_at,
+       U.fname,
+       U.lname,
+       A.fname                       as agent_fname,
+       A.lname                       as agent_lname,
+       W.waiting_since::timestamptz  as waiting_since,
+       (SELECT COUNT(*)
+        FROM messages M
+        WHERE M.chat_id = C.id
+          AND M.user_id <> $1
+          AND M.created_at > COALESCE(R.last_read_at, '-infinity'))::int as unread
+FROM chats C
+         JOIN users U on U.id = C.created_by
+         LEFT JOIN users A on A.id = C.assigned_to
+         LEFT JOIN chat_reads R on R.chat_id = C.id AND R.user_id = $1
+         LEFT JOIN LATERAL (SELECT MIN(M.created_at) as waiting_since
+                            FROM messages M
+                            WHERE M.chat_id = C.id
+                              AND M.user_id = C.created_by
+                              AND M.created_at > COALESCE((SELECT MAX(S.created_at)
+                                                           FROM messages S
+                                                           WHERE S.chat_id = C.id
+                                                             AND S.user_id <> C.created_by),
+                                                          '-infinity')) W on TRUE
+WHERE C.status = 'open'
+ORDER BY W.waiting_since NULLS LAST, C.created_at
+`
+
+type ListChatQueueRow struct {
+	ID           pgtype.UUID
+	Status       ChatStatus
+	CreatedBy    pgtype.UUID
+	AssignedTo   pgtype.UUID
+	CreatedAt    pgtype.Timestamptz
+	Fname        string
+	Lname        string
+	AgentFname   pgtype.Text
+	AgentLname   pgtype.Text
+	WaitingSince pgtype.Timestamptz
+	Unread       int32
+}
+
+func (q *Queries) ListChatQueue(ctx context.Context, viewerID pgtype.UUID) ([]ListChatQueueRow, error) {
+	rows, err := q.db.Query(ctx, listChatQueue, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChatQueueRow
+	for rows.Next() {
+		var i ListChatQueueRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Status,
+			&i.CreatedBy,
+			&i.AssignedTo,
+			&i.CreatedAt,
+			&i.Fname,
+			&i.Lname,
+			&i.AgentFname,
+			&i.AgentLname,
+			&i.WaitingSince,
+			&i.Unread,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeliveryEventsByDeliveryId = `-- name: ListDeliveryEventsByDeliveryId :many
+SELECT id, delivery_id, status, note, created_by, created_at
+FROM delivery_events
+WHERE delivery_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListDeliveryEventsByDeliveryId(ctx context.Context, deliveryID pgtype.UUID) ([]DeliveryEvent, error) {
+	rows, err := q.db.Query(ctx, listDeliveryEventsByDeliveryId, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeliveryEvent
+	for rows.Next() {
+		var i DeliveryEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.Status,
+			&i.Note,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFavoriteProductIdsByUserId = `-- name: ListFavoriteProductIdsByUserId :many
+SELECT product_id
+FROM favorites
+WHERE user_id = $1
+`
+
+func (q *Queries) ListFavoriteProductIdsByUserId(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listFavoriteProductIdsByUserId, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var product_id pgtype.UUID
+		if err := rows.Scan(&product_id); err != nil {
+			return nil, err
+		}
+		items = append(items, product_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFavoriteProductsByUserId = `-- name: ListFavoriteProductsByUserId :many
+SELECT P.id,
+       P.name,
+       P.price,
+       P.discount,
+       P.description,
+       P.created_at,
+       P.updated_at,
+       P.img,
+       P.stock,
+       TYP.slug as type,
+       CAT.slug as category,
+       COALESCE(R.avg_rating, 0)::float8 as avg_rating,
+       COALESCE(R.review_count, 0)::int  as review_count
+FROM favorites F
+         JOIN products P on P.id = F.product_id
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id
+WHERE F.user_id = $1
+ORDER BY F.created_at DESC
+`
+
+type ListFavoriteProductsByUserIdRow struct {
+	ID          pgtype.UUID
+	Name        string
+	Price       pgtype.Numeric
+	Discount    pgtype.Numeric
+	Description pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+	Img         string
+	Stock       int32
+	Type        string
+	Category    string
+	AvgRating   float64
+	ReviewCount int32
+}
+
+func (q *Queries) ListFavoriteProductsByUserId(ctx context.Context, userID pgtype.UUID) ([]ListFavoriteProductsByUserIdRow, error) {
+	rows, err := q.db.Query(ctx, listFavoriteProductsByUserId, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFavoriteProductsByUserIdRow
+	for rows.Next() {
+		var i ListFavoriteProductsByUserIdRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Discount,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Img,
+			&i.Stock,
+			&i.Type,
+			&i.Category,
+			&i.AvgRating,
+			&i.ReviewCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMessagesByChatIdAfter = `-- name: ListMessagesByChatIdAfter :many
+WITH after AS (SELECT seq FROM messages WHERE chat_id = $1 AND id = $2)
+SELECT M.id, M.chat_id, M.user_id, M.content, M.created_at, M.updated_at, M.seq
+FROM messages M
+WHERE M.chat_id = $1
+  AND (NOT EXISTS (SELECT 1 FROM after) OR M.seq > (SELECT seq FROM after))
+ORDER BY M.seq
+`
+
+type ListMessagesByChatIdAfterParams struct {
+	ChatID  pgtype.UUID
+	AfterID pgtype.UUID
+}
+
+// Messages are resumed in the order they were stored, by seq. An unknown after_id, a
+// deleted message for example, returns the whole chat.
+func (q *Queries) ListMessagesByChatIdAfter(ctx context.Context, arg ListMessagesByChatIdAfterParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, listMessagesByChatIdAfter, arg.ChatID, arg.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMessagesByChatIdPage = `-- name: ListMessagesByChatIdPage :many
+SELECT id, chat_id, user_id, content, created_at, updated_at, seq
+FROM messages
+WHERE chat_id = $1
+ORDER BY seq
+LIMIT $2 OFFSET $3
+`
+
+type ListMessagesByChatIdPageParams struct {
+	ChatID pgtype.UUID
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListMessagesByChatIdPage(ctx context.Context, arg ListMessagesByChatIdPageParams) ([]Message, error) {
+	rows, err := q.db.Query(ctx, listMessagesByChatIdPage, arg.ChatID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderReturnItemsByOrderId = `-- name: ListOrderReturnItemsByOrderId :many
+SELECT R.id,
+       R.order_id,
+       R.order_item_id,
+       R.quantity,
+       R.reason,
+       R.created_at,
+       I.product_id,
+       I.price_at_purchase
+FROM order_return_items R
+         JOIN order_items I on I.id = R.order_item_id
+WHERE R.order_id = $1
+ORDER BY R.created_at
+`
+
+type ListOrderReturnItemsByOrderIdRow struct {
+	ID              pgtype.UUID
+	OrderID         pgtype.UUID
+	OrderItemID     pgtype.UUID
+	Quantity        int32
+	Reason          string
+	CreatedAt       pgtype.Timestamptz
+	ProductID       pgtype.UUID
+	PriceAtPurchase pgtype.Numeric
+}
+
+func (q *Queries) ListOrderReturnItemsByOrderId(ctx context.Context, orderID pgtype.UUID) ([]ListOrderReturnItemsByOrderIdRow, error) {
+	rows, err := q.db.Query(ctx, listOrderReturnItemsByOrderId, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderReturnItemsByOrderIdRow
+	for rows.Next() {
+		var i ListOrderReturnItemsByOrderIdRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.OrderItemID,
+			&i.Quantity,
+			&i.Reason,
+			&i.CreatedAt,
+			&i.ProductID,
+			&i.PriceAtPurchase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderStatusHistoryByOrderId = `-- name: ListOrderStatusHistoryByOrderId :many
+SELECT H.id,
+       H.order_id,
+       H.from_status,
+       H.to_status,
+       H.changed_by,
+       H.note,
+       H.created_at,
+       U.fname,
+       U.lname,
+       U.role
+FROM order_status_history H
+         LEFT JOIN users U on U.id = H.changed_by
+WHERE H.order_id = $1
+ORDER BY H.created_at
+`
+
+type ListOrderStatusHistoryByOrderIdRow struct {
+	ID         pgtype.UUID
+	OrderID    pgtype.UUID
+	FromStatus NullOrderType
+	ToStatus   OrderType
+	ChangedBy  pgtype.UUID
+	Note       pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	Fname      pgtype.Text
+	Lname      pgtype.Text
+	Role       NullUserRole
+}
+
+func (q *Queries) ListOrderStatusHistoryByOrderId(ctx context.Context, orderID pgtype.UUID) ([]ListOrderStatusHistoryByOrderIdRow, error) {
+	rows, err := q.db.Query(ctx, listOrderStatusHistoryByOrderId, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderStatusHistoryByOrderIdRow
+	for rows.Next() {
+		var i ListOrderStatusHistoryByOrderIdRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.ChangedBy,
+			&i.Note,
+			&i.CreatedAt,
+			&i.Fname,
+			&i.Lname,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderStatusHistoryByUserIdAfter = `-- name: ListOrderStatusHistoryByUserIdAfter :many
+WITH after AS (SELECT A.seq
+               FROM order_status_history A
+                        JOIN orders AO on AO.id = A.order_id
+               WHERE AO.user_id = $1
+                 AND A.id = $2)
+SELECT H.id, H.order_id, H.from_status, H.to_status, H.changed_by, H.note, H.created_at, H.seq
+FROM order_status_history H
+         JOIN orders O on O.id = H.order_id
+WHERE O.user_id = $1
+  AND (NOT EXISTS (SELECT 1 FROM after) OR H.seq > (SELECT seq FROM after))
+ORDER BY H.seq
+`
+
+type ListOrderStatusHistoryByUserIdAfterParams struct {
+	UserID  pgtype.UUID
+	AfterID pgtype.UUID
+}
+
+// Changes are resumed in the order they were stored, by seq. An unknown after_id returns
+// every change of the user's orders.
+func (q *Queries) ListOrderStatusHistoryByUserIdAfter(ctx context.Context, arg ListOrderStatusHistoryByUserIdAfterParams) ([]OrderStatusHistory, error) {
+	rows, err := q.db.Query(ctx, listOrderStatusHistoryByUserIdAfter, arg.UserID, arg.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderStatusHistory
+	for rows.Next() {
+		var i OrderStatusHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.ChangedBy,
+			&i.Note,
+			&i.CreatedAt,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersByUserIdPage = `-- name: ListOrdersByUserIdPage :many
+SELECT id, user_id, status, created_at, updated_at
+FROM orders
+WHERE user_id = $1
+ORDER BY created_at DESC, id
+LIMIT $2 OFFSET $3
+`
+
+type ListOrdersByUserIdPageParams struct {
+	UserID pgtype.UUID
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListOrdersByUserIdPage(ctx context.Context, arg ListOrdersByUserIdPageParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrdersByUserIdPage, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersPage = `-- name: ListOrdersPage :many
+SELECT id, user_id, status, created_at, updated_at
+FROM orders
+ORDER BY created_at DESC, id
+LIMIT $1 OFFSET $2
+`
+
+type ListOrdersPageParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListOrdersPage(ctx context.Context, arg ListOrdersPageParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrdersPage, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductAttributeValues = `-- name: ListProductAttributeValues :many
+SELECT A.id as attribute_id, A.slug, A.name, A.kind, A.unit, V.text_value, V.min_value, V.max_value
+FROM product_attribute_values V
+         JOIN attributes A on A.id = V.attribute_id
+         JOIN products P on P.id = V.product_id
+         JOIN categories C on C.id = P.category
+WHERE V.product_id = $1
+ORDER BY array_position(C.path, A.category_id), A.position, A.name
+`
+
+type ListProductAttributeValuesRow struct {
+	AttributeID pgtype.UUID
+	Slug        string
+	Name        string
+	Kind        AttributeKind
+	Unit        string
+	TextValue   pgtype.Text
+	MinValue    pgtype.Numeric
+	MaxValue    pgtype.Numeric
+}
+
+func (q *Queries) ListProductAttributeValues(ctx context.Context, productID pgtype.UUID) ([]ListProductAttributeValuesRow, error) {
+	rows, err := q.db.Query(ctx, listProductAttributeValues, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductAttributeValuesRow
+	for rows.Next() {
+		var i ListProductAttributeValuesRow
+		if err := rows.Scan(
+			&i.AttributeID,
+			&i.Slug,
+			&i.Name,
+			&i.Kind,
+			&i.Unit,
+			&i.TextValue,
+			&i.MinValue,
+			&i.MaxValue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductInteractionsByProductId = `-- name: ListProductInteractionsByProductId :many
+SELECT PI.id,
+       PI.product_id,
+       PI.user_id,
+       PI.type,
+       PI.content,
+       PI.rating,
+       PI.is_answered,
+       PI.response,
+       PI.created_at,
+       U.fname,
+       U.lname
+FROM product_interactions PI
+         LEFT JOIN users U on U.id = PI.user_id
+WHERE PI.product_id = $1
+ORDER BY PI.created_at DESC
 `
 
-func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
-	rows, err := q.db.Query(ctx, listAllOrders)
+type ListProductInteractionsByProductIdRow struct {
+	ID         pgtype.UUID
+	ProductID  pgtype.UUID
+	UserID     pgtype.UUID
+	Type       ProdInteractionType
+	Content    string
+	Rating     pgtype.Int2
+	IsAnswered pgtype.Bool
+	Response   pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	Fname      pgtype.Text
+	Lname      pgtype.Text
+}
+
+func (q *Queries) ListProductInteractionsByProductId(ctx context.Context, productID pgtype.UUID) ([]ListProductInteractionsByProductIdRow, error) {
+	rows, err := q.db.Query(ctx, listProductInteractionsByProductId, productID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Order
+	var items []ListProductInteractionsByProductIdRow
 	for rows.Next() {
-		var i Order
+		var i ListProductInteractionsByProductIdRow
 		if err := rows.Scan(
 			&i.ID,
+			&i.ProductID,
 			&i.UserID,
-			&i.Status,
+			&i.Type,
+			&i.Content,
+			&i.Rating,
+			&i.IsAnswered,
+			&i.Response,
 			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.Fname,
+			&i.Lname,
 		); err != nil {
 			return nil, err
 		}
@@ -626,27 +3045,32 @@ func (q *Queries) ListAllOrders(ctx context.Context) ([]Order, error) {
 	return items, nil
 }
 
-const listAllOrdersByUserId = `-- name: ListAllOrdersByUserId :many
-SELECT id, user_id, status, created_at, updated_at
-FROM orders
-WHERE user_id = $1
+const listStockMovementsByProductId = `-- name: ListStockMovementsByProductId :many
+SELECT id, product_id, quantity, reason, order_id, note, created_by, created_at
+FROM stock_movements
+WHERE product_id = $1
+ORDER BY created_at DESC
+LIMIT 20
 `
 
-func (q *Queries) ListAllOrdersByUserId(ctx context.Context, userID pgtype.UUID) ([]Order, error) {
-	rows, err := q.db.Query(ctx, listAllOrdersByUserId, userID)
+func (q *Queries) ListStockMovementsByProductId(ctx context.Context, productID pgtype.UUID) ([]StockMovement, error) {
+	rows, err := q.db.Query(ctx, listStockMovementsByProductId, productID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Order
+	var items []StockMovement
 	for rows.Next() {
-		var i Order
+		var i StockMovement
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
-			&i.Status,
+			&i.ProductID,
+			&i.Quantity,
+			&i.Reason,
+			&i.OrderID,
+			&i.Note,
+			&i.CreatedBy,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -658,55 +3082,34 @@ func (q *Queries) ListAllOrdersByUserId(ctx context.Context, userID pgtype.UUID)
 	return items, nil
 }
 
-const listAllProducts = `-- name: ListAllProducts :many
-SELECT DISTINCT P.id,
-                P.name,
-                P.price,
-                P.discount,
-                P.description,
-                P.created_at,
-                P.updated_at,
-                P.img,
-                TYP.name as type,
-                CAT.name as category
-FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category
+const listSupportAgents = `-- name: ListSupportAgents :many
+SELECT id, fname, lname, role
+FROM users
+WHERE role IN ('support', 'admin')
+ORDER BY fname, lname
 `
 
-type ListAllProductsRow struct {
-	ID          pgtype.UUID
-	Name        string
-	Price       pgtype.Numeric
-	Discount    pgtype.Numeric
-	Description pgtype.Text
-	CreatedAt   pgtype.Timestamptz
-	UpdatedAt   pgtype.Timestamptz
-	Img         string
-	Type        string
-	Category    string
+type ListSupportAgentsRow struct {
+	ID    pgtype.UUID
+	Fname string
+	Lname string
+	Role  UserRole
 }
 
-func (q *Queries) ListAllProducts(ctx context.Context) ([]ListAllProductsRow, error) {
-	rows, err := q.db.Query(ctx, listAllProducts)
+func (q *Queries) ListSupportAgents(ctx context.Context) ([]ListSupportAgentsRow, error) {
+	rows, err := q.db.Query(ctx, listSupportAgents)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListAllProductsRow
+	var items []ListSupportAgentsRow
 	for rows.Next() {
-		var i ListAllProductsRow
+		var i ListSupportAgentsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.Price,
-			&i.Discount,
-			&i.Description,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Img,
-			&i.Type,
-			&i.Category,
+			&i.Fname,
+			&i.Lname,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -718,56 +3121,41 @@ func (q *Queries) ListAllProducts(ctx context.Context) ([]ListAllProductsRow, er
 	return items, nil
 }
 
-const listAllProductsByType = `-- name: ListAllProductsByType :many
-SELECT DISTINCT P.id,
-                P.name,
-                P.price,
-                P.discount,
-                P.description,
-                P.created_at,
-                P.updated_at,
-                P.img,
-                TYP.name as type,
-                CAT.name as category
-FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category
-WHERE TYP.name = $1
+const listUsersPage = `-- name: ListUsersPage :many
+SELECT id, email, fname, lname, role
+FROM users
+ORDER BY fname, lname, id
+LIMIT $1 OFFSET $2
 `
 
-type ListAllProductsByTypeRow struct {
-	ID          pgtype.UUID
-	Name        string
-	Price       pgtype.Numeric
-	Discount    pgtype.Numeric
-	Description pgtype.Text
-	CreatedAt   pgtype.Timestamptz
-	UpdatedAt   pgtype.Timestamptz
-	Img         string
-	Type        string
-	Category    string
+type ListUsersPageParams struct {
+	Limit  int32
+	Offset int32
 }
 
-func (q *Queries) ListAllProductsByType(ctx context.Context, name string) ([]ListAllProductsByTypeRow, error) {
-	rows, err := q.db.Query(ctx, listAllProductsByType, name)
+type ListUsersPageRow struct {
+	ID    pgtype.UUID
+	Email string
+	Fname string
+	Lname string
+	Role  UserRole
+}
+
+func (q *Queries) ListUsersPage(ctx context.Context, arg ListUsersPageParams) ([]ListUsersPageRow, error) {
+	rows, err := q.db.Query(ctx, listUsersPage, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListAllProductsByTypeRow
+	var items []ListUsersPageRow
 	for rows.Next() {
-		var i ListAllProductsByTypeRow
+		var i ListUsersPageRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.Price,
-			&i.Discount,
-			&i.Description,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Img,
-			&i.Type,
-			&i.Category,
+			&i.Email,
+			&i.Fname,
+			&i.Lname,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -779,25 +3167,143 @@ func (q *Queries) ListAllProductsByType(ctx context.Context, name string) ([]Lis
 	return items, nil
 }
 
-const listAllTags = `-- name: ListAllTags :many
-SELECT id, name, created_at, updated_at
-FROM tags
+const markChatRead = `-- name: MarkChatRead :exec
+INSERT INTO chat_reads (chat_id, user_id, last_read_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (chat_id, user_id) DO UPDATE SET last_read_at = NOW()
+`
+
+type MarkChatReadParams struct {
+	ChatID pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) MarkChatRead(ctx context.Context, arg MarkChatReadParams) error {
+	_, err := q.db.Exec(ctx, markChatRead, arg.ChatID, arg.UserID)
+	return err
+}
+
+const notifyChannel = `-- name: NotifyChannel :exec
+SELECT pg_notify($1::text, $2::text)
+`
+
+type NotifyChannelParams struct {
+	Channel string
+	Payload string
+}
+
+func (q *Queries) NotifyChannel(ctx context.Context, arg NotifyChannelParams) error {
+	_, err := q.db.Exec(ctx, notifyChannel, arg.Channel, arg.Payload)
+	return err
+}
+
+const reserveProductStock = `-- name: ReserveProductStock :one
+UPDATE products
+SET stock = stock - $1::int
+WHERE id = $2
+  AND stock >= $1::int
+RETURNING stock
+`
+
+type ReserveProductStockParams struct {
+	Quantity int32
+	ID       pgtype.UUID
+}
+
+func (q *Queries) ReserveProductStock(ctx context.Context, arg ReserveProductStockParams) (int32, error) {
+	row := q.db.QueryRow(ctx, reserveProductStock, arg.Quantity, arg.ID)
+	var stock int32
+	err := row.Scan(&stock)
+	return stock, err
+}
+
+const searchProducts = `-- name: SearchProducts :many
+WITH search AS (SELECT fts_websearch($1::text::regconfig, $2::text) ||
+                       websearch_to_tsquery('simple', $2::text) AS q,
+                       fts_headline_query($1::text::regconfig, $2::text) AS headline_q)
+SELECT P.id,
+       P.name,
+       P.price,
+       P.discount,
+       P.description,
+       P.created_at,
+       P.updated_at,
+       P.img,
+       P.stock,
+       TYP.slug as type,
+       CAT.slug as category,
+       COALESCE(R.avg_rating, 0)::float8                         as avg_rating,
+       COALESCE(R.review_count, 0)::int                          as review_count,
+       ts_rank(P.search_vector, search.q, 1)::float8             as rank,
+       ts_headline($1::text::regconfig, P.name, search.headline_q,
+                   'HighlightAll=true, StartSel="' || chr(2) || '", StopSel="' || chr(3) || '"')::text as name_headline,
+       ts_headline($1::text::regconfig, COALESCE(P.description, ''), search.headline_q,
+                   'MaxFragments=2, MaxWords=25, MinWords=8, StartSel="' || chr(2) || '", StopSel="' || chr(3) ||
+                   '"')::text                                    as snippet
+FROM products P
+         CROSS JOIN search
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id
+WHERE P.search_vector @@ search.q
+ORDER BY rank DESC, P.name
+LIMIT $3
 `
 
-func (q *Queries) ListAllTags(ctx context.Context) ([]Tag, error) {
-	rows, err := q.db.Query(ctx, listAllTags)
+type SearchProductsParams struct {
+	Config     string
+	Query      string
+	MaxResults int32
+}
+
+type SearchProductsRow struct {
+	ID           pgtype.UUID
+	Name         string
+	Price        pgtype.Numeric
+	Discount     pgtype.Numeric
+	Description  pgtype.Text
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+	Img          string
+	Stock        int32
+	Type         string
+	Category     string
+	AvgRating    float64
+	ReviewCount  int32
+	Rank         float64
+	NameHeadline string
+	Snippet      string
+}
+
+func (q *Queries) SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error) {
+	rows, err := q.db.Query(ctx, searchProducts, arg.Config, arg.Query, arg.MaxResults)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Tag
+	var items []SearchProductsRow
 	for rows.Next() {
-		var i Tag
+		var i SearchProductsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
+			&i.Price,
+			&i.Discount,
+			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Img,
+			&i.Stock,
+			&i.Type,
+			&i.Category,
+			&i.AvgRating,
+			&i.ReviewCount,
+			&i.Rank,
+			&i.NameHeadline,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -809,36 +3315,51 @@ func (q *Queries) ListAllTags(ctx context.Context) ([]Tag, error) {
 	return items, nil
 }
 
-const listAllUsers = `-- name: ListAllUsers :many
-SELECT id, email, fname, lname, role
-FROM users
-ORDER BY fname, lname
+const setOrderRefundAmount = `-- name: SetOrderRefundAmount :one
+UPDATE order_details
+SET refund_amount=(SELECT COALESCE(SUM(R.quantity * I.price_at_purchase), 0)
+                   FROM order_return_items R
+                            JOIN order_items I on I.id = R.order_item_id
+                   WHERE R.order_id = $1)
+WHERE order_id = $1
+RETURNING refund_amount
 `
 
-type ListAllUsersRow struct {
-	ID    pgtype.UUID
-	Email string
-	Fname string
-	Lname string
-	Role  UserRole
+func (q *Queries) SetOrderRefundAmount(ctx context.Context, orderID pgtype.UUID) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, setOrderRefundAmount, orderID)
+	var refund_amount pgtype.Numeric
+	err := row.Scan(&refund_amount)
+	return refund_amount, err
 }
 
-func (q *Queries) ListAllUsers(ctx context.Context) ([]ListAllUsersRow, error) {
-	rows, err := q.db.Query(ctx, listAllUsers)
+const suggestProducts = `-- name: SuggestProducts :many
+SELECT P.id, P.name
+FROM products P
+WHERE P.search_vector @@ to_tsquery('simple', $1::text)
+ORDER BY ts_rank(P.search_vector, to_tsquery('simple', $1::text)) DESC, P.name
+LIMIT $2
+`
+
+type SuggestProductsParams struct {
+	PrefixQuery string
+	MaxResults  int32
+}
+
+type SuggestProductsRow struct {
+	ID   pgtype.UUID
+	Name string
+}
+
+func (q *Queries) SuggestProducts(ctx context.Context, arg SuggestProductsParams) ([]SuggestProductsRow, error) {
+	rows, err := q.db.Query(ctx, suggestProducts, arg.PrefixQuery, arg.MaxResults)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListAllUsersRow
+	var items []SuggestProductsRow
 	for rows.Next() {
-		var i ListAllUsersRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Email,
-			&i.Fname,
-			&i.Lname,
-			&i.Role,
-		); err != nil {
+		var i SuggestProductsRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -849,6 +3370,80 @@ func (q *Queries) ListAllUsers(ctx context.Context) ([]ListAllUsersRow, error) {
 	return items, nil
 }
 
+const touchApiToken = `-- name: TouchApiToken :exec
+UPDATE api_tokens
+SET last_used_at = NOW()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+`
+
+func (q *Queries) TouchApiToken(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchApiToken, id)
+	return err
+}
+
+const updateAttribute = `-- name: UpdateAttribute :exec
+UPDATE attributes
+SET name=$2,
+    unit=$3,
+    options=$4,
+    position=$5
+WHERE id = $1
+`
+
+type UpdateAttributeParams struct {
+	ID       pgtype.UUID
+	Name     string
+	Unit     string
+	Options  []string
+	Position int32
+}
+
+func (q *Queries) UpdateAttribute(ctx context.Context, arg UpdateAttributeParams) error {
+	_, err := q.db.Exec(ctx, updateAttribute,
+		arg.ID,
+		arg.Name,
+		arg.Unit,
+		arg.Options,
+		arg.Position,
+	)
+	return err
+}
+
+const updateCategory = `-- name: UpdateCategory :exec
+UPDATE categories
+SET parent_id=$2,
+    slug=$3,
+    name=$4,
+    description=$5,
+    img=$6,
+    position=$7
+WHERE id = $1
+`
+
+type UpdateCategoryParams struct {
+	ID          pgtype.UUID
+	ParentID    pgtype.UUID
+	Slug        string
+	Name        string
+	Description string
+	Img         pgtype.Text
+	Position    int32
+}
+
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) error {
+	_, err := q.db.Exec(ctx, updateCategory,
+		arg.ID,
+		arg.ParentID,
+		arg.Slug,
+		arg.Name,
+		arg.Description,
+		arg.Img,
+		arg.Position,
+	)
+	return err
+}
+
 const updateChatStatus = `-- name: UpdateChatStatus :exec
 UPDATE chats
 SET status = $2
@@ -865,6 +3460,35 @@ func (q *Queries) UpdateChatStatus(ctx context.Context, arg UpdateChatStatusPara
 	return err
 }
 
+const updateDeliveryStatus = `-- name: UpdateDeliveryStatus :one
+UPDATE deliveries
+SET status       = $2,
+    delivered_at = CASE WHEN $2 = 'delivered'::DELIVERY_STATUS THEN NOW() ELSE delivered_at END
+WHERE id = $1
+RETURNING id, order_id, status, tracking_number, estimated_delivery, delivered_at, created_at, updated_at
+`
+
+type UpdateDeliveryStatusParams struct {
+	ID     pgtype.UUID
+	Status DeliveryStatus
+}
+
+func (q *Queries) UpdateDeliveryStatus(ctx context.Context, arg UpdateDeliveryStatusParams) (Delivery, error) {
+	row := q.db.QueryRow(ctx, updateDeliveryStatus, arg.ID, arg.Status)
+	var i Delivery
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Status,
+		&i.TrackingNumber,
+		&i.EstimatedDelivery,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateOrderDetails = `-- name: UpdateOrderDetails :exec
 UPDATE order_details
 SET address         = $2,
@@ -908,6 +3532,22 @@ func (q *Queries) UpdateOrderItemById(ctx context.Context, arg UpdateOrderItemBy
 	return err
 }
 
+const updateOrderReturnStatement = `-- name: UpdateOrderReturnStatement :exec
+UPDATE order_details
+SET return_statement=$2
+WHERE order_id = $1
+`
+
+type UpdateOrderReturnStatementParams struct {
+	OrderID         pgtype.UUID
+	ReturnStatement pgtype.Text
+}
+
+func (q *Queries) UpdateOrderReturnStatement(ctx context.Context, arg UpdateOrderReturnStatementParams) error {
+	_, err := q.db.Exec(ctx, updateOrderReturnStatement, arg.OrderID, arg.ReturnStatement)
+	return err
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :exec
 UPDATE orders
 SET status =$2
@@ -931,8 +3571,7 @@ SET name= $2,
     discount=$4,
     description=$5,
     img=$6,
-    category=$7,
-    type=$8
+    category=$7
 WHERE id = $1
 `
 
@@ -944,7 +3583,6 @@ type UpdateProductParams struct {
 	Description pgtype.Text
 	Img         string
 	Category    pgtype.UUID
-	Type        pgtype.UUID
 }
 
 func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) error {
@@ -956,7 +3594,6 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) er
 		arg.Description,
 		arg.Img,
 		arg.Category,
-		arg.Type,
 	)
 	return err
 }
@@ -0,0 +1,101 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrOutOfStock is returned when a product doesn't have enough stock for a reservation.
+var ErrOutOfStock = errors.New("not enough stock")
+
+// ErrNegativeStock is returned when an adjustment would leave a product with negative stock.
+var ErrNegativeStock = errors.New("stock can't go below zero")
+
+// Reserve takes quantity units of a product for an order and records the sale.
+// The stock is checked and decremented in a single UPDATE, which locks the product row
+// until the surrounding transaction ends, so concurrent checkouts can't oversell.
+func Reserve(ctx context.Context, q db.Querier, productID pgtype.UUID, orderID pgtype.UUID, actorID pgtype.UUID, quantity int32) error {
+	_, err := q.ReserveProductStock(ctx, db.ReserveProductStockParams{Quantity: quantity, ID: productID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: product %s", ErrOutOfStock, productID.String())
+	}
+	if err != nil {
+		return err
+	}
+	return q.CreateStockMovement(ctx, db.CreateStockMovementParams{ProductID: productID,
+		Quantity:  -quantity,
+		Reason:    db.StockMovementTypeSale,
+		OrderID:   orderID,
+		CreatedBy: actorID})
+}
+
+// Adjust changes the stock of a product by change units and records why.
+// A change that would leave negative stock is rejected by the database with ErrNegativeStock.
+func Adjust(ctx context.Context, q db.Querier, productID pgtype.UUID, actorID pgtype.UUID, change int32, reason db.StockMovementType, note string) (int32, error) {
+	stock, err := q.AddProductStock(ctx, db.AddProductStockParams{Quantity: change, ID: productID})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "products_stock_check" {
+		return 0, fmt.Errorf("%w: product %s", ErrNegativeStock, productID.String())
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = q.CreateStockMovement(ctx, db.CreateStockMovementParams{ProductID: productID,
+		Quantity:  change,
+		Reason:    reason,
+		Note:      pgtype.Text{String: note, Valid: note != ""},
+		CreatedBy: actorID})
+	if err != nil {
+		return 0, err
+	}
+	return stock, nil
+}
+
+// ReleaseOrder puts every item of a cancelled order back in stock.
+func ReleaseOrder(ctx context.Context, q db.Querier, orderID pgtype.UUID, actorID pgtype.UUID) error {
+	items, err := q.ListAllOrderItemsById(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		err = restock(ctx, q, item.ProductID, orderID, actorID, item.Quantity, db.StockMovementTypeCancellation)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestockReturn puts the returned items of an order back in stock.
+func RestockReturn(ctx context.Context, q db.Querier, orderID pgtype.UUID, actorID pgtype.UUID) error {
+	returnItems, err := q.ListOrderReturnItemsByOrderId(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, item := range returnItems {
+		err = restock(ctx, q, item.ProductID, orderID, actorID, item.Quantity, db.StockMovementTypeReturn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restock adds quantity units of a product that come back from an order.
+func restock(ctx context.Context, q db.Querier, productID pgtype.UUID, orderID pgtype.UUID, actorID pgtype.UUID, quantity int32, reason db.StockMovementType) error {
+	_, err := q.AddProductStock(ctx, db.AddProductStockParams{Quantity: quantity, ID: productID})
+	if err != nil {
+		return err
+	}
+	return q.CreateStockMovement(ctx, db.CreateStockMovementParams{ProductID: productID,
+		Quantity:  quantity,
+		Reason:    reason,
+		OrderID:   orderID,
+		CreatedBy: actorID})
+}
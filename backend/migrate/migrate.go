@@ -0,0 +1,476 @@
+// Package migrate evolves the database schema with the numbered migrations embedded in
+// the binary. Applied migrations are recorded in schema_migrations together with the
+// checksum of their up script, so an edited migration is noticed instead of silently skipped.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+// lockID keys the advisory lock that keeps two processes from migrating at once.
+const lockID = 7347016642090524
+
+// ErrChecksumMismatch is returned when an applied migration was changed afterwards.
+var ErrChecksumMismatch = errors.New("applied migration was modified")
+
+// ErrSchemaMismatch is returned by Baseline when the schema of the database isn't the one
+// of the first migration.
+var ErrSchemaMismatch = errors.New("the schema of the database differs from the first migration")
+
+// ErrNotBaselined is returned by Up on a database built from sql/schema.sql before there
+// were migrations. Baseline records that schema as migration 1 so Up can continue from it.
+var ErrNotBaselined = errors.New("the database has a schema without recorded migrations, run migrate baseline first")
+
+// ErrNothingToRollBack is returned by Down and Redo on a database without applied migrations.
+var ErrNothingToRollBack = errors.New("no applied migration to roll back")
+
+// Migration is a pair of NNNN_name.up.sql and NNNN_name.down.sql files.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the hex SHA-256 of Up.
+	Checksum string
+}
+
+// Status is a migration and whether and when it was applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Modified reports that the up script differs from the one that was applied.
+	Modified bool
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations in the root of fsys, ordered by version. Every version
+// needs both an up and a down script.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+			sum := sha256.Sum256(content)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrations returns the migrations embedded in the binary.
+func Migrations() ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Migrator applies and rolls back migrations over a single connection, which holds the
+// advisory lock while it works.
+type Migrator struct {
+	conn       *pgx.Conn
+	migrations []Migration
+}
+
+// New creates a migrator for the embedded migrations.
+func New(conn *pgx.Conn) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	return &Migrator{conn: conn, migrations: migrations}, nil
+}
+
+// NewWithMigrations creates a migrator for migrations loaded elsewhere.
+func NewWithMigrations(conn *pgx.Conn, migrations []Migration) *Migrator {
+	return &Migrator{conn: conn, migrations: migrations}
+}
+
+type applied struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.conn.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    BIGINT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    checksum   TEXT NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]applied, error) {
+	rows, err := m.conn.Query(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+	done := map[int64]applied{}
+	for rows.Next() {
+		var version int64
+		var a applied
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = a
+	}
+	return done, rows.Err()
+}
+
+// locked runs fn while holding the migration lock, on a database with schema_migrations.
+func (m *Migrator) locked(ctx context.Context, fn func(done map[int64]applied) error) error {
+	_, err := m.conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer m.conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	err = m.ensureTable(ctx)
+	if err != nil {
+		return err
+	}
+	done, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(done)
+}
+
+// verify fails when an applied migration no longer matches its file.
+func (m *Migrator) verify(done map[int64]applied) error {
+	for _, mig := range m.migrations {
+		if a, ok := done[mig.Version]; ok && a.checksum != mig.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// run executes a script and records the change in one transaction.
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) error {
+	tx, err := m.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	script, direction := mig.Down, "down"
+	if up {
+		script, direction = mig.Up, "up"
+	}
+	_, err = tx.Exec(ctx, script)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", mig.Version, mig.Name, direction, err)
+	}
+	if up {
+		_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)", mig.Version, mig.Name, mig.Checksum)
+	} else {
+		_, err = tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("record migration %d_%s: %w", mig.Version, mig.Name, err)
+	}
+	return tx.Commit(ctx)
+}
+
+// hasSchema reports whether the tables of the first migration exist already.
+func (m *Migrator) hasSchema(ctx context.Context) (bool, error) {
+	var exists bool
+	err := m.conn.QueryRow(ctx, "SELECT to_regclass('users') IS NOT NULL").Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("look for an existing schema: %w", err)
+	}
+	return exists, nil
+}
+
+// Up applies every pending migration in order and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var ran []Migration
+	err := m.locked(ctx, func(done map[int64]applied) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			exists, err := m.hasSchema(ctx)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrNotBaselined
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, mig, true); err != nil {
+				return err
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Baseline records the first migration as applied without running it, adopting a database
+// built from sql/schema.sql before there were migrations. It only works on a database that
+// has exactly that schema and no recorded migrations.
+func (m *Migrator) Baseline(ctx context.Context) (Migration, error) {
+	if len(m.migrations) == 0 {
+		return Migration{}, errors.New("there are no migrations to baseline")
+	}
+	first := m.migrations[0]
+	err := m.locked(ctx, func(done map[int64]applied) error {
+		if len(done) > 0 {
+			return errors.New("the database has recorded migrations already")
+		}
+		exists, err := m.hasSchema(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.New("the database has no schema to baseline, run migrate up instead")
+		}
+		differences, err := m.schemaDifferences(ctx, first)
+		if err != nil {
+			return err
+		}
+		if len(differences) > 0 {
+			return fmt.Errorf("%w:\n  %s", ErrSchemaMismatch, strings.Join(differences, "\n  "))
+		}
+		_, err = m.conn.Exec(ctx, "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)", first.Version, first.Name, first.Checksum)
+		if err != nil {
+			return fmt.Errorf("record migration %s: %w", first.Describe(), err)
+		}
+		return nil
+	})
+	return first, err
+}
+
+// baselineSchema is the scratch schema schemaDifferences builds the first migration in.
+const baselineSchema = "migrate_baseline_check"
+
+// schemaObjects lists the tables, columns and enum labels of a schema, one line each.
+// Tables the migrations don't manage are left out: schema_migrations, and http_sessions
+// which the session store used to create itself.
+func schemaObjects(ctx context.Context, tx pgx.Tx, schema string) (map[string]bool, error) {
+	rows, err := tx.Query(ctx, `
+SELECT 'column ' || table_name || '.' || column_name || ' ' || udt_name || CASE is_nullable WHEN 'NO' THEN ' not null' ELSE '' END
+FROM information_schema.columns
+WHERE table_schema = $1
+  AND table_name NOT IN ('schema_migrations', 'http_sessions')
+UNION ALL
+SELECT 'enum ' || T.typname || ' ' || string_agg(E.enumlabel, ',' ORDER BY E.enumsortorder)
+FROM pg_type T
+         JOIN pg_enum E ON E.enumtypid = T.oid
+         JOIN pg_namespace N ON N.oid = T.typnamespace
+WHERE N.nspname = $1
+GROUP BY T.typname`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	objects := map[string]bool{}
+	for rows.Next() {
+		var object string
+		if err := rows.Scan(&object); err != nil {
+			return nil, err
+		}
+		objects[object] = true
+	}
+	return objects, rows.Err()
+}
+
+// schemaDifferences builds mig in a scratch schema and compares it with the current schema
+// of the database, returning what one has and the other lacks. Nothing it does is kept.
+func (m *Migrator) schemaDifferences(ctx context.Context, mig Migration) ([]string, error) {
+	tx, err := m.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	err = tx.QueryRow(ctx, "SELECT current_schema()").Scan(&current)
+	if err != nil {
+		return nil, fmt.Errorf("read the current schema: %w", err)
+	}
+	actual, err := schemaObjects(ctx, tx, current)
+	if err != nil {
+		return nil, fmt.Errorf("list the current schema: %w", err)
+	}
+	_, err = tx.Exec(ctx, "CREATE SCHEMA "+baselineSchema+"; SET LOCAL search_path TO "+baselineSchema)
+	if err != nil {
+		return nil, fmt.Errorf("create scratch schema: %w", err)
+	}
+	_, err = tx.Exec(ctx, mig.Up)
+	if err != nil {
+		return nil, fmt.Errorf("build %s in the scratch schema: %w", mig.Describe(), err)
+	}
+	expected, err := schemaObjects(ctx, tx, baselineSchema)
+	if err != nil {
+		return nil, fmt.Errorf("list the scratch schema: %w", err)
+	}
+
+	var differences []string
+	for object := range expected {
+		if !actual[object] {
+			differences = append(differences, "missing "+object)
+		}
+	}
+	for object := range actual {
+		if !expected[object] {
+			differences = append(differences, "unexpected "+object)
+		}
+	}
+	sort.Strings(differences)
+	return differences, nil
+}
+
+// Down rolls back the latest applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var rolledBack Migration
+	err := m.locked(ctx, func(done map[int64]applied) error {
+		mig, err := m.latest(done)
+		if err != nil {
+			return err
+		}
+		rolledBack = mig
+		return m.run(ctx, mig, false)
+	})
+	return rolledBack, err
+}
+
+// Redo rolls back the latest applied migration and applies it again, handy while
+// writing a migration. Unlike Up it accepts a modified latest migration.
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	var redone Migration
+	err := m.locked(ctx, func(done map[int64]applied) error {
+		mig, err := m.latest(done)
+		if err != nil {
+			return err
+		}
+		redone = mig
+		if err := m.run(ctx, mig, false); err != nil {
+			return err
+		}
+		return m.run(ctx, mig, true)
+	})
+	return redone, err
+}
+
+// latest returns the applied migration with the highest version.
+func (m *Migrator) latest(done map[int64]applied) (Migration, error) {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := done[m.migrations[i].Version]; ok {
+			return m.migrations[i], nil
+		}
+	}
+	if len(done) > 0 {
+		return Migration{}, errors.New("the applied migrations aren't in this binary, it's older than the database")
+	}
+	return Migration{}, ErrNothingToRollBack
+}
+
+// Status reports every migration in order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(done map[int64]applied) error {
+		for _, mig := range m.migrations {
+			st := Status{Migration: mig}
+			if a, ok := done[mig.Version]; ok {
+				st.Applied = true
+				st.AppliedAt = a.appliedAt
+				st.Modified = a.checksum != mig.Checksum
+			}
+			statuses = append(statuses, st)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Pending returns the migrations that aren't applied yet, or an error when an applied
+// one was modified.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, st := range statuses {
+		if st.Modified {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, st.Version, st.Name)
+		}
+		if !st.Applied {
+			pending = append(pending, st.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Describe formats a migration as its file name prefix, e.g. 0001_init.
+func (mig Migration) Describe() string {
+	return fmt.Sprintf("%04d_%s", mig.Version, mig.Name)
+}
@@ -0,0 +1,15 @@
+DROP TABLE order_items;
+DROP TABLE order_details;
+DROP TABLE orders;
+DROP TYPE ORDER_TYPE;
+DROP TYPE DELIVERY_STATUS;
+DROP TABLE products;
+DROP TABLE tags;
+DROP TYPE CATEGORY_TYPE;
+DROP TYPE PROD_INTERACTION_TYPE;
+DROP TABLE messages;
+DROP TABLE chats;
+DROP TYPE CHAT_STATUS;
+DROP TABLE users;
+DROP TYPE USER_ROLE;
+DROP FUNCTION update_updated_at_column();
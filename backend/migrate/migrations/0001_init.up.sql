@@ -1,3 +1,6 @@
+-- The schema of sql/schema.sql from before there were migrations, which `migrate baseline`
+-- adopts. Tags and products are created before the order items that reference them.
+
 CREATE TYPE USER_ROLE AS ENUM ('user','support','admin');
 
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -56,6 +59,42 @@ CREATE TABLE messages
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+CREATE TYPE CATEGORY_TYPE AS ENUM ('plant', 'tool', 'seed','soil');
+
+CREATE TABLE tags
+(
+    id         UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    name       VARCHAR(50) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_tags_updated_at
+    BEFORE UPDATE
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE products
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    img         VARCHAR(255)   NOT NULL,
+    name        VARCHAR(255)   NOT NULL,
+    price       DECIMAL(10, 2) NOT NULL,
+    discount    DECIMAL(5, 2) CHECK (discount >= 0 AND discount <= 100),
+    description TEXT,
+    type        UUID           NOT NULL REFERENCES tags (id),
+    category    UUID           NOT NULL REFERENCES tags (id),
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_products_updated_at
+    BEFORE UPDATE
+    ON products
+    FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
 CREATE TYPE ORDER_TYPE AS ENUM ('pending','completed','returned');
 
 CREATE TABLE orders
@@ -120,42 +159,6 @@ CREATE TYPE DELIVERY_STATUS AS ENUM ('shipped','in transit','delivered','returne
 --     FOR EACH ROW
 -- EXECUTE FUNCTION update_updated_at_column();
 
-CREATE TABLE products
-(
-    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
-    img         VARCHAR(255)   NOT NULL,
-    name        VARCHAR(255)   NOT NULL,
-    price       DECIMAL(10, 2) NOT NULL,
-    discount    DECIMAL(5, 2) CHECK (discount >= 0 AND discount <= 100),
-    description TEXT,
-    type        UUID           NOT NULL REFERENCES tags (id),
-    category    UUID           NOT NULL REFERENCES tags (id),
-    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-);
-
-CREATE TRIGGER update_products_updated_at
-    BEFORE UPDATE
-    ON products
-    FOR EACH ROW
-EXECUTE FUNCTION update_updated_at_column();
-
-CREATE TYPE CATEGORY_TYPE AS ENUM ('plant', 'tool', 'seed','soil');
-
-CREATE TABLE tags
-(
-    id         UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
-    name       VARCHAR(50) UNIQUE NOT NULL,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-);
-
-CREATE TRIGGER update_tags_updated_at
-    BEFORE UPDATE
-    ON tags
-    FOR EACH ROW
-EXECUTE FUNCTION update_updated_at_column();
-
 CREATE TYPE PROD_INTERACTION_TYPE AS ENUM ('review','question');
 
 -- CREATE TABLE product_interactions
@@ -194,4 +197,4 @@ CREATE INDEX idx_products_description_ru ON products USING gin (to_tsvector('rus
 CREATE INDEX idx_chat_status ON chats (status);
 CREATE INDEX idx_messages_chat_id ON messages (chat_id);
 CREATE INDEX idx_orders_user_id ON orders (user_id);
--- CREATE INDEX idx_product_interactions_product_id ON product_interactions (product_id);
\ No newline at end of file
+-- CREATE INDEX idx_product_interactions_product_id ON product_interactions (product_id);
@@ -0,0 +1,31 @@
+-- Orders in a status the original schema doesn't have fall back to the closest one it has.
+DROP TABLE favorites;
+DROP TABLE product_interactions;
+DROP TABLE stock_movements;
+DROP TYPE STOCK_MOVEMENT_TYPE;
+ALTER TABLE products
+    DROP COLUMN stock;
+DROP TABLE delivery_events;
+DROP TABLE deliveries;
+DROP TABLE order_return_items;
+ALTER TABLE order_details
+    DROP COLUMN refund_amount;
+DROP TABLE order_status_history;
+
+ALTER TYPE ORDER_TYPE RENAME TO ORDER_TYPE_OLD;
+CREATE TYPE ORDER_TYPE AS ENUM ('pending','completed','returned');
+ALTER TABLE orders
+    ALTER COLUMN status TYPE ORDER_TYPE USING (CASE status::text
+        WHEN 'paid' THEN 'pending'
+        WHEN 'shipped' THEN 'pending'
+        WHEN 'cancelled' THEN 'pending'
+        WHEN 'return_requested' THEN 'completed'
+        ELSE status::text END)::ORDER_TYPE;
+DROP TYPE ORDER_TYPE_OLD;
+
+DROP TABLE canned_responses;
+DROP TABLE chat_reads;
+ALTER TABLE chats
+    DROP COLUMN assigned_to;
+DROP TABLE api_tokens;
+DROP TYPE API_TOKEN_KIND;
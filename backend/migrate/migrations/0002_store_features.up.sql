@@ -0,0 +1,165 @@
+-- Everything the shop added to the original schema before there were migrations: API tokens,
+-- support chat assignment, order workflow, returns, deliveries, stock and product interactions.
+
+CREATE TYPE API_TOKEN_KIND AS ENUM ('login','key');
+CREATE TABLE api_tokens
+(
+    id           UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    user_id      UUID           NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind         API_TOKEN_KIND NOT NULL  DEFAULT 'login',
+    name         VARCHAR(100),
+    prefix       VARCHAR(16),
+    token_hash   BYTEA UNIQUE   NOT NULL,
+    scopes       TEXT[],
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((kind = 'key') = (name IS NOT NULL AND scopes IS NOT NULL))
+);
+
+ALTER TABLE chats
+    ADD COLUMN assigned_to UUID REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE TABLE chat_reads
+(
+    chat_id      UUID NOT NULL REFERENCES chats (id) ON DELETE CASCADE,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    last_read_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE TABLE canned_responses
+(
+    id         UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    title      VARCHAR(100) NOT NULL,
+    content    TEXT         NOT NULL,
+    created_by UUID         REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TYPE ORDER_TYPE ADD VALUE 'paid' BEFORE 'completed';
+ALTER TYPE ORDER_TYPE ADD VALUE 'shipped' BEFORE 'completed';
+ALTER TYPE ORDER_TYPE ADD VALUE 'return_requested' BEFORE 'returned';
+ALTER TYPE ORDER_TYPE ADD VALUE 'cancelled' AFTER 'returned';
+
+CREATE TABLE order_status_history
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    order_id    UUID       NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status ORDER_TYPE,
+    to_status   ORDER_TYPE NOT NULL,
+    changed_by  UUID       REFERENCES users (id) ON DELETE SET NULL,
+    note        TEXT,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE order_details
+    ADD COLUMN refund_amount DECIMAL(10, 2);
+
+CREATE TABLE order_return_items
+(
+    id            UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    order_id      UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items (id) ON DELETE CASCADE,
+    quantity      INT  NOT NULL CHECK (quantity > 0),
+    reason        TEXT NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE deliveries
+(
+    id                 UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    order_id           UUID            NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    status             DELIVERY_STATUS NOT NULL,
+    tracking_number    VARCHAR(100),
+    estimated_delivery TIMESTAMP WITH TIME ZONE,
+    delivered_at       TIMESTAMP WITH TIME ZONE,
+    created_at         TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at         TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_deliveries_updated_at
+    BEFORE UPDATE
+    ON deliveries
+    FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE delivery_events
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    delivery_id UUID            NOT NULL REFERENCES deliveries (id) ON DELETE CASCADE,
+    status      DELIVERY_STATUS NOT NULL,
+    note        TEXT,
+    created_by  UUID            REFERENCES users (id) ON DELETE SET NULL,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE products
+    ADD COLUMN stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0);
+
+CREATE TYPE STOCK_MOVEMENT_TYPE AS ENUM ('receipt','sale','return','cancellation','adjustment');
+
+CREATE TABLE stock_movements
+(
+    id         UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    product_id UUID                NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity   INT                 NOT NULL,
+    reason     STOCK_MOVEMENT_TYPE NOT NULL,
+    order_id   UUID                REFERENCES orders (id) ON DELETE SET NULL,
+    note       TEXT,
+    created_by UUID                REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Stock wasn't tracked before, every product could be bought. Existing products get an
+-- opening stock, booked as a receipt so admins see where it came from and can correct it.
+UPDATE products
+SET stock = 100;
+
+INSERT INTO stock_movements (product_id, quantity, reason, note)
+SELECT id, 100, 'receipt', 'opening stock when stock tracking started, check it against the warehouse'
+FROM products;
+
+CREATE TABLE product_interactions
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    product_id  UUID                  NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    user_id     UUID                  REFERENCES users (id) ON DELETE SET NULL,
+    type        PROD_INTERACTION_TYPE NOT NULL,
+    content     TEXT                  NOT NULL,
+    rating      SMALLINT CHECK (rating BETWEEN 1 AND 5),
+    is_answered BOOLEAN                  DEFAULT FALSE,
+    response    TEXT,
+    answered_by UUID                  REFERENCES users (id) ON DELETE SET NULL,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((type = 'review') = (rating IS NOT NULL))
+);
+
+CREATE TRIGGER update_prod_interactions_updated_at
+    BEFORE UPDATE
+    ON product_interactions
+    FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE favorites
+(
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, product_id)
+);
+
+CREATE INDEX idx_reviews_content_en ON product_interactions USING gin (to_tsvector('english', content));
+CREATE INDEX idx_reviews_content_ru ON product_interactions USING gin (to_tsvector('russian', content));
+CREATE INDEX idx_product_interactions_compound ON product_interactions (product_id, user_id);
+CREATE UNIQUE INDEX idx_product_interactions_one_review ON product_interactions (product_id, user_id) WHERE type = 'review';
+CREATE INDEX idx_api_tokens_user_id ON api_tokens (user_id);
+CREATE INDEX idx_chats_assigned_to ON chats (assigned_to);
+CREATE INDEX idx_order_status_history_order_id ON order_status_history (order_id);
+CREATE INDEX idx_order_return_items_order_id ON order_return_items (order_id);
+CREATE INDEX idx_deliveries_order_id ON deliveries (order_id);
+CREATE INDEX idx_delivery_events_delivery_id ON delivery_events (delivery_id);
+CREATE INDEX idx_stock_movements_product_id ON stock_movements (product_id);
+CREATE INDEX idx_favorites_product_id ON favorites (product_id);
+CREATE INDEX idx_product_interactions_product_id ON product_interactions (product_id);
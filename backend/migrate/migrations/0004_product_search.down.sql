@@ -0,0 +1,11 @@
+DROP INDEX idx_products_search_vector;
+CREATE INDEX idx_products_description_en ON products USING gin (to_tsvector('english', description));
+CREATE INDEX idx_products_description_ru ON products USING gin (to_tsvector('russian', description));
+
+DROP TRIGGER update_tags_products_search_vector ON tags;
+DROP FUNCTION update_tag_products_search_vector();
+DROP TRIGGER update_products_search_vector ON products;
+DROP FUNCTION update_product_search_vector();
+DROP FUNCTION product_search_vector(TEXT, TEXT, UUID, UUID);
+ALTER TABLE products
+    DROP COLUMN search_vector;
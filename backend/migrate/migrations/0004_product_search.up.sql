@@ -0,0 +1,66 @@
+-- One weighted vector per product covers its name (A), description (B) and the names
+-- of its type and category (C). The names are indexed unstemmed too, for prefix matching.
+ALTER TABLE products
+    ADD COLUMN search_vector TSVECTOR NOT NULL DEFAULT ''::tsvector;
+
+CREATE OR REPLACE FUNCTION product_search_vector(product_name TEXT, product_description TEXT, type_id UUID, category_id UUID)
+    RETURNS TSVECTOR
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT setweight(to_tsvector('simple', product_name), 'A') ||
+       setweight(to_tsvector('russian', product_name), 'A') ||
+       setweight(to_tsvector('english', product_name), 'A') ||
+       setweight(to_tsvector('russian', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('english', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('simple', COALESCE((SELECT string_agg(T.name, ' ')
+                                                 FROM tags T
+                                                 WHERE T.id IN (type_id, category_id)), '')), 'C');
+$$;
+
+CREATE OR REPLACE FUNCTION update_product_search_vector()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    NEW.search_vector = product_search_vector(NEW.name, NEW.description, NEW.type, NEW.category);
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER update_products_search_vector
+    BEFORE INSERT OR UPDATE OF name, description, type, category
+    ON products
+    FOR EACH ROW
+EXECUTE FUNCTION update_product_search_vector();
+
+-- Renaming a tag changes the vectors of its products.
+CREATE OR REPLACE FUNCTION update_tag_products_search_vector()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    UPDATE products
+    SET search_vector = product_search_vector(name, description, type, category)
+    WHERE type = NEW.id
+       OR category = NEW.id;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER update_tags_products_search_vector
+    AFTER UPDATE OF name
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION update_tag_products_search_vector();
+
+UPDATE products
+SET search_vector = product_search_vector(name, description, type, category);
+
+-- The vector covers the descriptions, the indexes on them alone would only slow down writes.
+DROP INDEX idx_products_description_en;
+DROP INDEX idx_products_description_ru;
+CREATE INDEX idx_products_search_vector ON products USING gin (search_vector);
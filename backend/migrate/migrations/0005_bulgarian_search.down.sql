@@ -0,0 +1,27 @@
+-- The search vector goes back to the russian and english lexemes alone.
+CREATE OR REPLACE FUNCTION product_search_vector(product_name TEXT, product_description TEXT, type_id UUID, category_id UUID)
+    RETURNS TSVECTOR
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT setweight(to_tsvector('simple', product_name), 'A') ||
+       setweight(to_tsvector('russian', product_name), 'A') ||
+       setweight(to_tsvector('english', product_name), 'A') ||
+       setweight(to_tsvector('russian', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('english', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('simple', COALESCE((SELECT string_agg(T.name, ' ')
+                                                 FROM tags T
+                                                 WHERE T.id IN (type_id, category_id)), '')), 'C');
+$$;
+
+UPDATE products
+SET search_vector = product_search_vector(name, description, type, category);
+
+DROP FUNCTION fts_headline_query(REGCONFIG, TEXT);
+DROP FUNCTION fts_websearch(REGCONFIG, TEXT);
+DROP FUNCTION bulgarian_normalize(TEXT);
+DROP FUNCTION bulgarian_stem(TEXT);
+DROP TABLE fts_bulgarian_dictionary;
+DROP TABLE fts_bulgarian_stopwords;
+DROP TEXT SEARCH CONFIGURATION bulgarian;
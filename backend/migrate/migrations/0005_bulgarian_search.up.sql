@@ -0,0 +1,139 @@
+-- PostgreSQL has no Bulgarian stemmer, and dictionary files would have to be installed on
+-- the database server. The stopwords and the dictionary live in tables instead and text is
+-- normalized by bulgarian_normalize before the bulgarian configuration, a copy of simple,
+-- turns it into lexemes.
+CREATE TEXT SEARCH CONFIGURATION bulgarian (COPY = simple);
+
+CREATE TABLE fts_bulgarian_stopwords
+(
+    word TEXT PRIMARY KEY
+);
+
+INSERT INTO fts_bulgarian_stopwords (word)
+VALUES ('а'), ('аз'), ('ако'), ('ала'), ('бе'), ('без'), ('би'), ('бил'), ('била'), ('били'), ('било'),
+       ('в'), ('вас'), ('ваш'), ('ваша'), ('вече'), ('ви'), ('вие'), ('все'), ('във'), ('върху'),
+       ('да'), ('до'), ('докато'), ('дори'), ('е'), ('едва'), ('един'), ('една'), ('едно'), ('ето'),
+       ('за'), ('защо'), ('защото'), ('и'), ('из'), ('или'), ('им'), ('има'), ('като'), ('кой'),
+       ('коя'), ('което'), ('които'), ('кога'), ('когато'), ('към'), ('ли'), ('ме'), ('между'),
+       ('ми'), ('много'), ('мой'), ('може'), ('му'), ('на'), ('над'), ('не'), ('него'), ('нея'),
+       ('ни'), ('но'), ('някой'), ('някои'), ('няма'), ('о'), ('от'), ('около'), ('още'), ('по'),
+       ('под'), ('при'), ('пред'), ('преди'), ('с'), ('са'), ('си'), ('след'), ('сме'), ('със'),
+       ('сте'), ('съм'), ('също'), ('та'), ('така'), ('те'), ('тези'), ('ти'), ('то'),
+       ('този'), ('това'), ('тогава'), ('той'), ('тя'), ('тук'), ('у'), ('че'), ('чрез'), ('ще'),
+       ('я');
+
+-- Forms the suffix rules of bulgarian_stem get wrong, mapped to the stem of their word.
+CREATE TABLE fts_bulgarian_dictionary
+(
+    word TEXT PRIMARY KEY,
+    stem TEXT NOT NULL
+);
+
+INSERT INTO fts_bulgarian_dictionary (word, stem)
+VALUES ('семе', 'семе'), ('семена', 'семе'), ('семената', 'семе'), ('семето', 'семе'),
+       ('пиперка', 'пипер'), ('пиперки', 'пипер'), ('пиперките', 'пипер'), ('пиперката', 'пипер'),
+       ('дървета', 'дърв'), ('дърветата', 'дърв'),
+       ('чесън', 'чесън'), ('чесънът', 'чесън'), ('чесъна', 'чесън'),
+       ('торът', 'тор'),
+       ('растение', 'растен'), ('растението', 'растен');
+
+-- bulgarian_stem strips the definite article and then the inflection ending of a lower
+-- case word, keeping stems long enough to stay distinct.
+CREATE OR REPLACE FUNCTION bulgarian_stem(word TEXT)
+    RETURNS TEXT
+    LANGUAGE plpgsql
+    IMMUTABLE
+AS
+$$
+DECLARE
+    stem   TEXT := word;
+    ending TEXT;
+BEGIN
+    FOREACH ending IN ARRAY ARRAY ['ите', 'ът', 'ят', 'та', 'то', 'те']
+        LOOP
+            IF char_length(stem) - char_length(ending) >= 5 AND right(stem, char_length(ending)) = ending THEN
+                stem := left(stem, -char_length(ending));
+                EXIT;
+            END IF;
+        END LOOP;
+    FOREACH ending IN ARRAY ARRAY ['ове', 'еве', 'ища', 'ии', 'ия', 'а', 'я', 'о', 'е', 'и']
+        LOOP
+            IF char_length(stem) - char_length(ending) >= 3 AND right(stem, char_length(ending)) = ending THEN
+                stem := left(stem, -char_length(ending));
+                EXIT;
+            END IF;
+        END LOOP;
+    RETURN stem;
+END;
+$$;
+
+-- bulgarian_normalize replaces every word of a text with its stem and drops the stopwords,
+-- leaving everything else alone, so it also normalizes web search queries: quotes, the
+-- minus sign and "or" keep working.
+CREATE OR REPLACE FUNCTION bulgarian_normalize(input TEXT)
+    RETURNS TEXT
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT COALESCE(string_agg(CASE
+                               WHEN T.token !~ '^[0-9A-Za-zА-Яа-яЍѝ]+$' OR T.token = 'or' THEN T.token
+                               WHEN EXISTS (SELECT FROM fts_bulgarian_stopwords S WHERE S.word = T.token) THEN ' '
+                               ELSE COALESCE((SELECT D.stem FROM fts_bulgarian_dictionary D WHERE D.word = T.token),
+                                             bulgarian_stem(T.token))
+                               END, '' ORDER BY T.n), '')
+FROM (SELECT M[1] AS token, n
+      FROM regexp_matches(lower(input), '[0-9A-Za-zА-Яа-яЍѝ]+|[^0-9A-Za-zА-Яа-яЍѝ]+', 'g') WITH ORDINALITY AS R(M, n)) T;
+$$;
+
+-- fts_websearch parses a web search query with a configuration, normalizing it first for bulgarian.
+CREATE OR REPLACE FUNCTION fts_websearch(config REGCONFIG, query TEXT)
+    RETURNS TSQUERY
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT CASE
+           WHEN config = 'bulgarian'::regconfig THEN websearch_to_tsquery(config, bulgarian_normalize(query))
+           ELSE websearch_to_tsquery(config, query)
+           END;
+$$;
+
+-- fts_headline_query is the query ts_headline marks matches of with a configuration. The
+-- bulgarian configuration doesn't stem the text of the headline, so its stems match as prefixes.
+CREATE OR REPLACE FUNCTION fts_headline_query(config REGCONFIG, query TEXT)
+    RETURNS TSQUERY
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT CASE
+           WHEN config = 'bulgarian'::regconfig THEN
+               COALESCE((SELECT to_tsquery('simple', string_agg(W || ':*', ' | '))
+                         FROM regexp_split_to_table(bulgarian_normalize(query), '[^0-9A-Za-zА-Яа-яЍѝ]+') W
+                         WHERE W <> ''
+                           AND W <> 'or'), ''::tsquery)
+           ELSE websearch_to_tsquery(config, query)
+           END;
+$$;
+
+CREATE OR REPLACE FUNCTION product_search_vector(product_name TEXT, product_description TEXT, type_id UUID, category_id UUID)
+    RETURNS TSVECTOR
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT setweight(to_tsvector('simple', product_name), 'A') ||
+       setweight(to_tsvector('bulgarian', bulgarian_normalize(product_name)), 'A') ||
+       setweight(to_tsvector('russian', product_name), 'A') ||
+       setweight(to_tsvector('english', product_name), 'A') ||
+       setweight(to_tsvector('bulgarian', bulgarian_normalize(COALESCE(product_description, ''))), 'B') ||
+       setweight(to_tsvector('russian', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('english', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('simple', COALESCE((SELECT string_agg(T.name, ' ')
+                                                 FROM tags T
+                                                 WHERE T.id IN (type_id, category_id)), '')), 'C');
+$$;
+
+UPDATE products
+SET search_vector = product_search_vector(name, description, type, category);
@@ -0,0 +1,3 @@
+DROP INDEX idx_order_items_product_id;
+DROP INDEX idx_products_category;
+DROP INDEX idx_products_type;
@@ -0,0 +1,4 @@
+-- Catalog browsing filters by type and category and sorts by the units sold.
+CREATE INDEX idx_products_type ON products (type);
+CREATE INDEX idx_products_category ON products (category);
+CREATE INDEX idx_order_items_product_id ON order_items (product_id);
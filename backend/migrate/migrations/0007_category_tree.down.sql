@@ -0,0 +1,113 @@
+-- Categories go back to flat tags named after them: the root of the category of a product
+-- becomes its type again.
+DROP TRIGGER update_categories_products_search_vector ON categories;
+DROP FUNCTION update_category_products_search_vector();
+DROP TRIGGER update_products_search_vector ON products;
+DROP FUNCTION product_search_vector(TEXT, TEXT, UUID);
+
+CREATE TYPE CATEGORY_TYPE AS ENUM ('plant', 'tool', 'seed','soil');
+
+CREATE TABLE tags
+(
+    id         UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    name       VARCHAR(50) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_tags_updated_at
+    BEFORE UPDATE
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO tags (name)
+SELECT DISTINCT left(name, 50)
+FROM categories;
+
+ALTER TABLE products
+    ADD COLUMN type UUID REFERENCES tags (id);
+
+UPDATE products P
+SET type = T.id
+FROM categories C
+         JOIN categories R ON R.id = C.path[1]
+         JOIN tags T ON T.name = left(R.name, 50)
+WHERE C.id = P.category;
+
+ALTER TABLE products
+    DROP CONSTRAINT products_category_fkey;
+
+UPDATE products P
+SET category = T.id
+FROM categories C
+         JOIN tags T ON T.name = left(C.name, 50)
+WHERE C.id = P.category;
+
+ALTER TABLE products
+    ALTER COLUMN type SET NOT NULL,
+    ADD CONSTRAINT products_category_fkey FOREIGN KEY (category) REFERENCES tags (id);
+
+CREATE INDEX idx_products_type ON products (type);
+
+DROP TABLE categories;
+DROP FUNCTION update_category_descendant_paths();
+DROP FUNCTION set_category_path();
+
+CREATE OR REPLACE FUNCTION product_search_vector(product_name TEXT, product_description TEXT, type_id UUID, category_id UUID)
+    RETURNS TSVECTOR
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT setweight(to_tsvector('simple', product_name), 'A') ||
+       setweight(to_tsvector('bulgarian', bulgarian_normalize(product_name)), 'A') ||
+       setweight(to_tsvector('russian', product_name), 'A') ||
+       setweight(to_tsvector('english', product_name), 'A') ||
+       setweight(to_tsvector('bulgarian', bulgarian_normalize(COALESCE(product_description, ''))), 'B') ||
+       setweight(to_tsvector('russian', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('english', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('simple', COALESCE((SELECT string_agg(T.name, ' ')
+                                                 FROM tags T
+                                                 WHERE T.id IN (type_id, category_id)), '')), 'C');
+$$;
+
+CREATE OR REPLACE FUNCTION update_product_search_vector()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    NEW.search_vector = product_search_vector(NEW.name, NEW.description, NEW.type, NEW.category);
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER update_products_search_vector
+    BEFORE INSERT OR UPDATE OF name, description, type, category
+    ON products
+    FOR EACH ROW
+EXECUTE FUNCTION update_product_search_vector();
+
+CREATE OR REPLACE FUNCTION update_tag_products_search_vector()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    UPDATE products
+    SET search_vector = product_search_vector(name, description, type, category)
+    WHERE type = NEW.id
+       OR category = NEW.id;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER update_tags_products_search_vector
+    AFTER UPDATE OF name
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION update_tag_products_search_vector();
+
+UPDATE products
+SET search_vector = product_search_vector(name, description, type, category);
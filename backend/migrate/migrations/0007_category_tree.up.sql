@@ -0,0 +1,211 @@
+-- Categories form a tree that replaces the flat tags. A product belongs to one category,
+-- its type is the root of that category. path lists the IDs from the root down to the
+-- category itself, so breadcrumbs and subtrees don't need recursive queries.
+CREATE TABLE categories
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    parent_id   UUID REFERENCES categories (id),
+    slug        VARCHAR(60) UNIQUE NOT NULL CHECK (slug ~ '^[a-zа-я0-9]+(-[a-zа-я0-9]+)*$'),
+    name        VARCHAR(100)       NOT NULL,
+    description TEXT               NOT NULL DEFAULT '',
+    img         VARCHAR(255),
+    position    INT                NOT NULL DEFAULT 0,
+    path        UUID[]             NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+CREATE INDEX idx_categories_path ON categories USING gin (path);
+
+CREATE TRIGGER update_categories_updated_at
+    BEFORE UPDATE
+    ON categories
+    FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- set_category_path derives the path of a category from its parent and refuses to move
+-- a category under itself or one of its descendants.
+CREATE OR REPLACE FUNCTION set_category_path()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+DECLARE
+    parent_path UUID[];
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        NEW.path = ARRAY [NEW.id];
+        RETURN NEW;
+    END IF;
+    SELECT path INTO parent_path FROM categories WHERE id = NEW.parent_id;
+    IF NEW.id = ANY (parent_path) THEN
+        RAISE EXCEPTION 'category % can''t be moved under its own subcategory', NEW.slug
+            USING ERRCODE = 'check_violation';
+    END IF;
+    NEW.path = parent_path || NEW.id;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER set_categories_path
+    BEFORE INSERT OR UPDATE OF parent_id
+    ON categories
+    FOR EACH ROW
+EXECUTE FUNCTION set_category_path();
+
+-- Moving a category moves its whole subtree.
+CREATE OR REPLACE FUNCTION update_category_descendant_paths()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    UPDATE categories
+    SET path = NEW.path || path[array_position(path, NEW.id) + 1:]
+    WHERE NEW.id = ANY (path)
+      AND id <> NEW.id;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER update_categories_descendant_paths
+    AFTER UPDATE OF parent_id
+    ON categories
+    FOR EACH ROW
+    WHEN (OLD.parent_id IS DISTINCT FROM NEW.parent_id)
+EXECUTE FUNCTION update_category_descendant_paths();
+
+-- Every tag used as a product type becomes a root category keeping its ID, every other
+-- tag used as a product category a child of the type of its products. A tag under several
+-- types, or that is a type itself, is slugged after the type too. Unused tags are dropped.
+CREATE TEMPORARY TABLE migrated_categories ON COMMIT DROP AS
+WITH pairs AS (SELECT DISTINCT type AS type_id, category AS tag_id
+               FROM products),
+     slugged AS (SELECT R.type_id                                                  AS id,
+                        NULL::uuid                                                 AS parent_id,
+                        R.type_id                                                  AS tag_id,
+                        T.name,
+                        trim(BOTH '-' FROM regexp_replace(lower(T.name), '[^a-zа-я0-9]+', '-', 'g')) AS slug
+                 FROM (SELECT DISTINCT type_id FROM pairs) R
+                          JOIN tags T ON T.id = R.type_id
+                 UNION ALL
+                 SELECT gen_random_uuid(),
+                        P.type_id,
+                        P.tag_id,
+                        T.name,
+                        trim(BOTH '-' FROM regexp_replace(lower(CASE
+                                                                    WHEN COUNT(*) OVER (PARTITION BY P.tag_id) > 1 OR
+                                                                         P.tag_id IN (SELECT type_id FROM pairs)
+                                                                        THEN TYP.name || '-' || T.name
+                                                                    ELSE T.name END), '[^a-zа-я0-9]+', '-', 'g'))
+                 FROM pairs P
+                          JOIN tags T ON T.id = P.tag_id
+                          JOIN tags TYP ON TYP.id = P.type_id
+                 WHERE P.tag_id <> P.type_id)
+SELECT id,
+       parent_id,
+       tag_id,
+       left(name, 100)                                                                 AS name,
+       trim(BOTH '-' FROM left(CASE
+                                   WHEN slug = '' THEN 'category'
+                                   ELSE slug END, 50)) ||
+       CASE
+           WHEN ROW_NUMBER() OVER (PARTITION BY slug ORDER BY parent_id NULLS FIRST, name, id) = 1 THEN ''
+           ELSE '-' || ROW_NUMBER() OVER (PARTITION BY slug ORDER BY parent_id NULLS FIRST, name, id) END AS slug,
+       ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY name)                        AS position
+FROM slugged;
+
+INSERT INTO categories (id, parent_id, slug, name, position)
+SELECT id, parent_id, slug, name, position
+FROM migrated_categories
+WHERE parent_id IS NULL;
+
+INSERT INTO categories (id, parent_id, slug, name, position)
+SELECT id, parent_id, slug, name, position
+FROM migrated_categories
+WHERE parent_id IS NOT NULL;
+
+-- The search vector covered the tags, it's rebuilt over the category path below.
+DROP TRIGGER update_products_search_vector ON products;
+DROP TRIGGER update_tags_products_search_vector ON tags;
+DROP FUNCTION update_tag_products_search_vector();
+DROP FUNCTION product_search_vector(TEXT, TEXT, UUID, UUID);
+
+ALTER TABLE products
+    DROP CONSTRAINT products_category_fkey;
+
+UPDATE products P
+SET category = M.id
+FROM migrated_categories M
+WHERE M.parent_id = P.type
+  AND M.tag_id = P.category;
+
+ALTER TABLE products
+    ADD CONSTRAINT products_category_fkey FOREIGN KEY (category) REFERENCES categories (id);
+
+ALTER TABLE products
+    DROP COLUMN type;
+
+DROP TABLE tags;
+DROP TYPE CATEGORY_TYPE;
+
+CREATE OR REPLACE FUNCTION product_search_vector(product_name TEXT, product_description TEXT, category_id UUID)
+    RETURNS TSVECTOR
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT setweight(to_tsvector('simple', product_name), 'A') ||
+       setweight(to_tsvector('bulgarian', bulgarian_normalize(product_name)), 'A') ||
+       setweight(to_tsvector('russian', product_name), 'A') ||
+       setweight(to_tsvector('english', product_name), 'A') ||
+       setweight(to_tsvector('bulgarian', bulgarian_normalize(COALESCE(product_description, ''))), 'B') ||
+       setweight(to_tsvector('russian', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('english', COALESCE(product_description, '')), 'B') ||
+       setweight(to_tsvector('simple', COALESCE((SELECT string_agg(A.name, ' ')
+                                                 FROM categories C
+                                                          JOIN categories A ON A.id = ANY (C.path)
+                                                 WHERE C.id = category_id), '')), 'C');
+$$;
+
+CREATE OR REPLACE FUNCTION update_product_search_vector()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    NEW.search_vector = product_search_vector(NEW.name, NEW.description, NEW.category);
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER update_products_search_vector
+    BEFORE INSERT OR UPDATE OF name, description, category
+    ON products
+    FOR EACH ROW
+EXECUTE FUNCTION update_product_search_vector();
+
+-- Renaming or moving a category changes the vectors of the products in its subtree.
+CREATE OR REPLACE FUNCTION update_category_products_search_vector()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+BEGIN
+    UPDATE products
+    SET search_vector = product_search_vector(name, description, category)
+    WHERE category IN (SELECT id FROM categories WHERE NEW.id = ANY (path));
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER update_categories_products_search_vector
+    AFTER UPDATE OF name, path
+    ON categories
+    FOR EACH ROW
+    WHEN (OLD.name IS DISTINCT FROM NEW.name OR OLD.path IS DISTINCT FROM NEW.path)
+EXECUTE FUNCTION update_category_products_search_vector();
+
+UPDATE products
+SET search_vector = product_search_vector(name, description, category);
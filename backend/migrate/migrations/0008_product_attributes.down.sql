@@ -0,0 +1,4 @@
+DROP FUNCTION product_matches_attributes(UUID, UUID[], TEXT[], NUMERIC[], NUMERIC[], UUID);
+DROP TABLE product_attribute_values;
+DROP TABLE attributes;
+DROP TYPE ATTRIBUTE_KIND;
@@ -0,0 +1,72 @@
+-- Attributes are the typed specs of products, like sowing month or soil pH. An attribute is
+-- defined on a category and applies to the products of its whole subtree. Slugs are unique
+-- across categories, so catalog filters can name attributes without their category.
+CREATE TYPE ATTRIBUTE_KIND AS ENUM ('text', 'number', 'enum', 'range');
+
+CREATE TABLE attributes
+(
+    id          UUID PRIMARY KEY         DEFAULT gen_random_uuid(),
+    category_id UUID               NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    slug        VARCHAR(60) UNIQUE NOT NULL CHECK (slug ~ '^[a-zа-я0-9]+(-[a-zа-я0-9]+)*$'),
+    name        VARCHAR(100)       NOT NULL,
+    kind        ATTRIBUTE_KIND     NOT NULL,
+    unit        VARCHAR(20)        NOT NULL DEFAULT '',
+    -- options are the allowed values of an enum attribute.
+    options     TEXT[]             NOT NULL DEFAULT '{}',
+    position    INT                NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (kind = 'enum' OR cardinality(options) = 0)
+);
+
+CREATE INDEX idx_attributes_category_id ON attributes (category_id);
+
+CREATE TRIGGER update_attributes_updated_at
+    BEFORE UPDATE
+    ON attributes
+    FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- A text or enum value is in text_value. A number is stored as a range of one value, so
+-- number and range filters are the same overlap test.
+CREATE TABLE product_attribute_values
+(
+    product_id   UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    attribute_id UUID NOT NULL REFERENCES attributes (id) ON DELETE CASCADE,
+    text_value   TEXT,
+    min_value    NUMERIC,
+    max_value    NUMERIC,
+    PRIMARY KEY (product_id, attribute_id),
+    CHECK ((text_value IS NOT NULL AND min_value IS NULL AND max_value IS NULL) OR
+           (text_value IS NULL AND min_value IS NOT NULL AND max_value IS NOT NULL AND min_value <= max_value))
+);
+
+CREATE INDEX idx_product_attribute_values_attribute_id ON product_attribute_values (attribute_id, text_value);
+
+-- product_matches_attributes tells whether a product passes the attribute filters, given as
+-- parallel arrays. An empty text value or a NULL bound doesn't filter. Text attributes match
+-- a part of the value case-insensitively, enum attributes the whole value and numbers or
+-- ranges when they overlap the bounds. The filter of the skipped attribute is ignored, for
+-- the facet counts of that attribute.
+CREATE OR REPLACE FUNCTION product_matches_attributes(product UUID, attribute_ids UUID[], text_values TEXT[],
+                                                      min_values NUMERIC[], max_values NUMERIC[],
+                                                      skipped UUID DEFAULT NULL)
+    RETURNS BOOLEAN
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT NOT EXISTS (SELECT
+                   FROM unnest(attribute_ids, text_values, min_values, max_values) F(attribute_id, text_value, min_value, max_value)
+                            JOIN attributes A ON A.id = F.attribute_id
+                   WHERE F.attribute_id IS DISTINCT FROM skipped
+                     AND NOT EXISTS (SELECT
+                                     FROM product_attribute_values V
+                                     WHERE V.product_id = product
+                                       AND V.attribute_id = F.attribute_id
+                                       AND (COALESCE(F.text_value, '') = '' OR CASE A.kind
+                                                                                   WHEN 'text' THEN strpos(lower(V.text_value), lower(F.text_value)) > 0
+                                                                                   ELSE V.text_value = F.text_value END)
+                                       AND (F.min_value IS NULL OR V.max_value >= F.min_value)
+                                       AND (F.max_value IS NULL OR V.min_value <= F.max_value)));
+$$;
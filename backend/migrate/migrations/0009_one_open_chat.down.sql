@@ -0,0 +1 @@
+DROP INDEX idx_chats_one_open;
@@ -0,0 +1,12 @@
+-- A customer has at most one open chat. Duplicates from concurrent requests are closed,
+-- keeping the one that was active last.
+UPDATE chats C
+SET status = 'closed'
+WHERE C.status = 'open'
+  AND EXISTS (SELECT 1
+              FROM chats O
+              WHERE O.created_by = C.created_by
+                AND O.status = 'open'
+                AND (O.updated_at, O.id) > (C.updated_at, C.id));
+
+CREATE UNIQUE INDEX idx_chats_one_open ON chats (created_by) WHERE status = 'open';
@@ -0,0 +1,5 @@
+ALTER TABLE order_status_history
+    DROP COLUMN seq;
+
+ALTER TABLE messages
+    DROP COLUMN seq;
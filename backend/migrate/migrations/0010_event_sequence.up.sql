@@ -0,0 +1,36 @@
+-- Messages and order status changes get a sequence number in the order they are stored.
+-- Event streams resume after it, timestamps and random IDs don't order rows written in
+-- the same transaction. Existing rows are numbered by creation time.
+ALTER TABLE messages
+    ADD COLUMN seq BIGINT;
+
+UPDATE messages M
+SET seq = N.seq
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS seq FROM messages) N
+WHERE N.id = M.id;
+
+CREATE SEQUENCE messages_seq_seq OWNED BY messages.seq;
+SELECT setval('messages_seq_seq', COALESCE((SELECT MAX(seq) FROM messages), 0) + 1, false);
+
+ALTER TABLE messages
+    ALTER COLUMN seq SET DEFAULT nextval('messages_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_messages_chat_id_seq ON messages (chat_id, seq);
+
+ALTER TABLE order_status_history
+    ADD COLUMN seq BIGINT;
+
+UPDATE order_status_history H
+SET seq = N.seq
+FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS seq FROM order_status_history) N
+WHERE N.id = H.id;
+
+CREATE SEQUENCE order_status_history_seq_seq OWNED BY order_status_history.seq;
+SELECT setval('order_status_history_seq_seq', COALESCE((SELECT MAX(seq) FROM order_status_history), 0) + 1, false);
+
+ALTER TABLE order_status_history
+    ALTER COLUMN seq SET DEFAULT nextval('order_status_history_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_order_status_history_seq ON order_status_history (seq);
@@ -0,0 +1,103 @@
+package orderstatus
+
+import (
+	"context"
+	"fmt"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// deliveryTransitions lists the statuses a shipment may advance to from each status.
+var deliveryTransitions = map[db.DeliveryStatus][]db.DeliveryStatus{
+	db.DeliveryStatusShipped:   {db.DeliveryStatusIntransit, db.DeliveryStatusDelivered, db.DeliveryStatusReturned},
+	db.DeliveryStatusIntransit: {db.DeliveryStatusDelivered, db.DeliveryStatusReturned},
+	db.DeliveryStatusDelivered: {db.DeliveryStatusReturned},
+	db.DeliveryStatusReturned:  {},
+}
+
+// deliveryOrderStatus is the order status a delivery status moves its order to.
+// A shipment sent back to the store leaves the order as it is, the return is reviewed separately.
+var deliveryOrderStatus = map[db.DeliveryStatus]db.OrderType{
+	db.DeliveryStatusShipped:   db.OrderTypeShipped,
+	db.DeliveryStatusDelivered: db.OrderTypeCompleted,
+}
+
+// CanAdvanceDelivery reports whether a shipment may move from one status to another.
+func CanAdvanceDelivery(from db.DeliveryStatus, to db.DeliveryStatus) bool {
+	for _, next := range deliveryTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextDeliveryStatuses returns the statuses a shipment in status from may advance to.
+func NextDeliveryStatuses(from db.DeliveryStatus) []db.DeliveryStatus {
+	return deliveryTransitions[from]
+}
+
+// Ship creates the shipment of a paid order, moves the order to shipped and records
+// the first tracking event. q should be bound to a transaction.
+func Ship(ctx context.Context, q db.Querier, orderID pgtype.UUID, actorID pgtype.UUID, role db.UserRole, trackingNumber string, estimatedDelivery pgtype.Timestamptz) (db.Delivery, error) {
+	_, err := Transition(ctx, q, orderID, actorID, role, deliveryOrderStatus[db.DeliveryStatusShipped], "shipment created")
+	if err != nil {
+		return db.Delivery{}, err
+	}
+
+	delivery, err := q.CreateDelivery(ctx, db.CreateDeliveryParams{OrderID: orderID,
+		TrackingNumber:    pgtype.Text{String: trackingNumber, Valid: trackingNumber != ""},
+		EstimatedDelivery: estimatedDelivery})
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	err = q.CreateDeliveryEvent(ctx, db.CreateDeliveryEventParams{DeliveryID: delivery.ID,
+		Status:    delivery.Status,
+		CreatedBy: actorID})
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	return delivery, nil
+}
+
+// AdvanceDelivery moves a shipment to a new status, records the tracking event and,
+// where the delivery status implies it, moves the order along. q should be bound to a transaction.
+func AdvanceDelivery(ctx context.Context, q db.Querier, deliveryID pgtype.UUID, actorID pgtype.UUID, role db.UserRole, to db.DeliveryStatus, note string) (db.Delivery, error) {
+	delivery, err := q.GetDeliveryByIdForUpdate(ctx, deliveryID)
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	if !CanAdvanceDelivery(delivery.Status, to) {
+		return db.Delivery{}, fmt.Errorf("%w: delivery %s -> %s", ErrInvalidTransition, delivery.Status, to)
+	}
+
+	delivery, err = q.UpdateDeliveryStatus(ctx, db.UpdateDeliveryStatusParams{ID: deliveryID, Status: to})
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	err = q.CreateDeliveryEvent(ctx, db.CreateDeliveryEventParams{DeliveryID: deliveryID,
+		Status:    to,
+		Note:      pgtype.Text{String: note, Valid: note != ""},
+		CreatedBy: actorID})
+	if err != nil {
+		return db.Delivery{}, err
+	}
+
+	orderStatus, ok := deliveryOrderStatus[to]
+	if !ok {
+		return delivery, nil
+	}
+	order, err := q.GetOrderByIdForUpdate(ctx, delivery.OrderID)
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	if !CanTransition(order.Status, orderStatus) {
+		return delivery, nil
+	}
+	_, err = Transition(ctx, q, order.ID, actorID, role, orderStatus, fmt.Sprintf("delivery %s", to))
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	return delivery, nil
+}
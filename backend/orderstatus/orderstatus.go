@@ -0,0 +1,135 @@
+package orderstatus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidTransition is returned when an order can't move from its current status to the requested one.
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// ErrForbiddenTransition is returned when the actor's role isn't allowed to make a valid transition.
+var ErrForbiddenTransition = errors.New("order status transition not allowed for role")
+
+// Channel is the PostgreSQL notification channel every recorded status change is published on.
+const Channel = "order_events"
+
+// Notification is the payload published on Channel. Listeners load the history entry by ID.
+type Notification struct {
+	UserID    string `json:"user_id"`
+	HistoryID string `json:"history_id"`
+}
+
+// transitions lists the statuses an order may move to from each status.
+// A rejected return request moves the order back to completed.
+var transitions = map[db.OrderType][]db.OrderType{
+	db.OrderTypePending:         {db.OrderTypePaid, db.OrderTypeCancelled},
+	db.OrderTypePaid:            {db.OrderTypeShipped, db.OrderTypeCancelled},
+	db.OrderTypeShipped:         {db.OrderTypeCompleted},
+	db.OrderTypeCompleted:       {db.OrderTypeReturnRequested},
+	db.OrderTypeReturnRequested: {db.OrderTypeReturned, db.OrderTypeCompleted},
+	db.OrderTypeReturned:        {},
+	db.OrderTypeCancelled:       {},
+}
+
+// customerTransitions are the only transitions a customer may make on their own order.
+var customerTransitions = map[db.OrderType]db.OrderType{
+	db.OrderTypePending:   db.OrderTypeCancelled,
+	db.OrderTypeCompleted: db.OrderTypeReturnRequested,
+}
+
+// CanTransition reports whether the lifecycle allows moving from one status to another.
+func CanTransition(from db.OrderType, to db.OrderType) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedFor reports whether a user with role may move an order from one status to another.
+// Admins and support may make every valid transition, customers only cancel or request a return.
+func AllowedFor(role db.UserRole, from db.OrderType, to db.OrderType) bool {
+	if !CanTransition(from, to) {
+		return false
+	}
+	if role == db.UserRoleAdmin || role == db.UserRoleSupport {
+		return true
+	}
+	next, ok := customerTransitions[from]
+	return ok && next == to
+}
+
+// NextFor returns the statuses a user with role may move an order in status from to.
+func NextFor(role db.UserRole, from db.OrderType) []db.OrderType {
+	var next []db.OrderType
+	for _, to := range transitions[from] {
+		if AllowedFor(role, from, to) {
+			next = append(next, to)
+		}
+	}
+	return next
+}
+
+// RecordCreated writes the first history entry of a newly created, pending order.
+func RecordCreated(ctx context.Context, q db.Querier, orderID pgtype.UUID, actorID pgtype.UUID) error {
+	entry, err := q.CreateOrderStatusHistory(ctx, db.CreateOrderStatusHistoryParams{OrderID: orderID,
+		ToStatus:  db.OrderTypePending,
+		ChangedBy: actorID})
+	if err != nil {
+		return err
+	}
+	return notify(ctx, q, actorID, entry)
+}
+
+// notify publishes a history entry to the owner of the order. Inside a transaction
+// PostgreSQL only delivers the notification on commit.
+func notify(ctx context.Context, q db.Querier, ownerID pgtype.UUID, entry db.OrderStatusHistory) error {
+	payload, err := json.Marshal(Notification{UserID: ownerID.String(), HistoryID: entry.ID.String()})
+	if err != nil {
+		return err
+	}
+	return q.NotifyChannel(ctx, db.NotifyChannelParams{Channel: Channel, Payload: string(payload)})
+}
+
+// Transition moves an order to a new status and records who did it.
+// q should be bound to a transaction: the order row is locked until it ends,
+// so concurrent transitions of the same order are serialized.
+func Transition(ctx context.Context, q db.Querier, orderID pgtype.UUID, actorID pgtype.UUID, role db.UserRole, to db.OrderType, note string) (db.Order, error) {
+	order, err := q.GetOrderByIdForUpdate(ctx, orderID)
+	if err != nil {
+		return db.Order{}, err
+	}
+	if !CanTransition(order.Status, to) {
+		return db.Order{}, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, order.Status, to)
+	}
+	if !AllowedFor(role, order.Status, to) {
+		return db.Order{}, fmt.Errorf("%w %s: %s -> %s", ErrForbiddenTransition, role, order.Status, to)
+	}
+
+	err = q.UpdateOrderStatus(ctx, db.UpdateOrderStatusParams{ID: orderID, Status: to})
+	if err != nil {
+		return db.Order{}, err
+	}
+	entry, err := q.CreateOrderStatusHistory(ctx, db.CreateOrderStatusHistoryParams{OrderID: orderID,
+		FromStatus: db.NullOrderType{OrderType: order.Status, Valid: true},
+		ToStatus:   to,
+		ChangedBy:  actorID,
+		Note:       pgtype.Text{String: note, Valid: note != ""}})
+	if err != nil {
+		return db.Order{}, err
+	}
+	err = notify(ctx, q, order.UserID, entry)
+	if err != nil {
+		return db.Order{}, err
+	}
+
+	order.Status = to
+	return order, nil
+}
@@ -35,7 +35,7 @@ type PGSession struct {
 }
 
 // NewPGStore creates a new PGStore instance with a pgxpool connection.
-// It also creates the necessary schema in the database.
+// The http_sessions table is created by the migrations in backend/migrate.
 func NewPGStore(dbURL string, keyPairs ...[]byte) (*PGStore, error) {
 	ctx := context.Background()
 	pool, err := pgxpool.New(ctx, dbURL)
@@ -45,7 +45,7 @@ func NewPGStore(dbURL string, keyPairs ...[]byte) (*PGStore, error) {
 	return NewPGStoreFromPool(pool, keyPairs...)
 }
 
-// NewPGStoreFromPool creates a PGStore using an existing pgxpool.Pool and creates the schema.
+// NewPGStoreFromPool creates a PGStore using an existing pgxpool.Pool.
 func NewPGStoreFromPool(pool *pgxpool.Pool, keyPairs ...[]byte) (*PGStore, error) {
 	store := &PGStore{
 		Codecs: securecookie.CodecsFromPairs(keyPairs...),
@@ -58,11 +58,6 @@ func NewPGStoreFromPool(pool *pgxpool.Pool, keyPairs ...[]byte) (*PGStore, error
 		Pool: pool,
 	}
 
-	// Create table if it doesn't exist.
-	if err := store.createSessionsTable(); err != nil {
-		return nil, err
-	}
-
 	return store, nil
 }
 
@@ -226,41 +221,6 @@ func (store *PGStore) destroy(session *sessions.Session) error {
 	return err
 }
 
-// createSessionsTable creates the required table and indexes if they do not exist.
-// The schema uses TEXT columns for the key and data.
-func (store *PGStore) createSessionsTable() error {
-	stmt := `
-DO $$
-BEGIN
-    CREATE TABLE IF NOT EXISTS http_sessions (
-        id BIGSERIAL PRIMARY KEY,
-        key TEXT,
-        data TEXT,
-        created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
-        modified_on TIMESTAMPTZ,
-        expires_on TIMESTAMPTZ
-    );
-    CREATE INDEX IF NOT EXISTS http_sessions_expiry_idx ON http_sessions (expires_on);
-    CREATE INDEX IF NOT EXISTS http_sessions_key_idx ON http_sessions (key);
-EXCEPTION WHEN insufficient_privilege THEN
-    IF NOT EXISTS (
-        SELECT FROM pg_catalog.pg_tables 
-        WHERE schemaname = current_schema() AND tablename = 'http_sessions'
-    ) THEN
-        RAISE;
-    END IF;
-WHEN others THEN RAISE;
-END;
-$$;
-`
-	ctx := context.Background()
-	_, err := store.Pool.Exec(ctx, stmt)
-	if err != nil {
-		return fmt.Errorf("unable to create http_sessions table: %w", err)
-	}
-	return nil
-}
-
 // insert writes a new session record to the database.
 func (store *PGStore) insert(s *PGSession) error {
 	ctx := context.Background()
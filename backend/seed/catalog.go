@@ -0,0 +1,187 @@
+package seed
+
+// demoCategory is a category of the demo tree. Parent is the slug of its parent, empty
+// for the roots, which are the product types.
+type demoCategory struct {
+	Slug        string
+	Parent      string
+	Name        string
+	Description string
+}
+
+// categories lists every parent before its subcategories.
+var categories = []demoCategory{
+	{Slug: "seeds", Name: "Семена и разсад",
+		Description: "Всичко за началото на сезона: семена за директна сеитба и закален разсад."},
+	{Slug: "seed", Parent: "seeds", Name: "Семена",
+		Description: "Зеленчукови и подправъчни семена от традиционни и нови сортове."},
+	{Slug: "plant", Parent: "seeds", Name: "Разсад и растения",
+		Description: "Разсад, билки и трайни насаждения в саксия, готови за засаждане."},
+	{Slug: "equipment", Name: "Оборудване",
+		Description: "Инструменти и принадлежности за градината и двора."},
+	{Slug: "tool", Parent: "equipment", Name: "Градински инструменти",
+		Description: "Лопати, ножици, лейки и маркучи за ежедневната работа в градината."},
+	{Slug: "soil", Name: "Почви и субстрати",
+		Description: "Почвени смеси, торф, перлит и органични подобрители."},
+}
+
+// demoAttribute is an attribute of the demo products of Category and its subcategories.
+// Options lists the values of an enum attribute, one per line.
+type demoAttribute struct {
+	Slug     string
+	Category string
+	Name     string
+	Kind     string
+	Unit     string
+	Options  string
+}
+
+var attributes = []demoAttribute{
+	{Slug: "sowing-month", Category: "seeds", Name: "Месец на засаждане", Kind: "enum",
+		Options: "Февруари\nМарт\nАприл\nМай\nСептември"},
+	{Slug: "sun-exposure", Category: "seeds", Name: "Изложение", Kind: "enum",
+		Options: "Слънце\nПолусянка\nСянка"},
+	{Slug: "germination-days", Category: "seed", Name: "Покълване", Kind: "range", Unit: "дни"},
+	{Slug: "frost-hardiness", Category: "plant", Name: "Студоустойчивост до", Kind: "number", Unit: "°C"},
+	{Slug: "pot-volume", Category: "plant", Name: "Обем на саксията", Kind: "number", Unit: "л"},
+	{Slug: "material", Category: "tool", Name: "Материал", Kind: "text"},
+	{Slug: "soil-ph", Category: "soil", Name: "Киселинност", Kind: "range", Unit: "pH"},
+	{Slug: "volume", Category: "soil", Name: "Обем", Kind: "number", Unit: "л"},
+}
+
+// demoProduct is a product of the demo catalog, Category is the slug of its category.
+// Attributes are its spec values by attribute slug, ranges written as "min..max".
+type demoProduct struct {
+	Name        string
+	Price       string
+	Description string
+	Category    string
+	Attributes  map[string]string
+}
+
+var catalog = []demoProduct{
+	{Name: "Домати Розов великан", Price: "2.40", Category: "seed",
+		Description: "Едроплоден индетерминантен домат с розови месести плодове до 600 г. Подходящ за открити площи и оранжерии.",
+		Attributes:  map[string]string{"sowing-month": "Март", "sun-exposure": "Слънце", "germination-days": "6..10"}},
+	{Name: "Домати Чери Сладко", Price: "2.80", Category: "seed",
+		Description: "Ранен чери домат със сладки червени плодове на гроздове. Отглежда се и в саксия на балкон.",
+		Attributes:  map[string]string{"sowing-month": "Март", "sun-exposure": "Слънце", "germination-days": "5..8"}},
+	{Name: "Краставици Еко", Price: "2.20", Category: "seed",
+		Description: "Корнишон с хрупкави плодове без горчивина, подходящ за прясна консумация и консервиране.",
+		Attributes:  map[string]string{"sowing-month": "Май", "sun-exposure": "Слънце", "germination-days": "4..7"}},
+	{Name: "Пипер Капия", Price: "2.60", Category: "seed",
+		Description: "Традиционен български сорт с дълги червени плодове за печене и лютеница.",
+		Attributes:  map[string]string{"sowing-month": "Февруари", "sun-exposure": "Слънце", "germination-days": "8..14"}},
+	{Name: "Моркови Нантска", Price: "1.90", Category: "seed",
+		Description: "Средноранен морков с цилиндричен корен, сладък вкус и добра съхраняемост.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Слънце", "germination-days": "10..20"}},
+	{Name: "Салата Маруля", Price: "1.80", Category: "seed",
+		Description: "Издръжлива зелена салата за ранна пролетна и есенна сеитба.",
+		Attributes:  map[string]string{"sowing-month": "Март", "sun-exposure": "Полусянка", "germination-days": "4..8"}},
+	{Name: "Босилек Геновезе", Price: "2.10", Category: "seed",
+		Description: "Ароматен босилек с едри листа, незаменим за песто и летни салати.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Слънце", "germination-days": "5..10"}},
+	{Name: "Тиквички Черна красавица", Price: "2.50", Category: "seed",
+		Description: "Продуктивен храстовиден сорт с тъмнозелени плодове, готови за бране 50 дни след сеитба.",
+		Attributes:  map[string]string{"sowing-month": "Май", "sun-exposure": "Слънце", "germination-days": "5..8"}},
+	{Name: "Разсад домати Биволско сърце", Price: "1.60", Category: "plant",
+		Description: "Закален разсад в кубче, готов за засаждане на открито след средата на април.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Слънце", "frost-hardiness": "2"}},
+	{Name: "Разсад пипер Шипка", Price: "1.50", Category: "plant",
+		Description: "Разсад от ранен сладък пипер с конусовидни жълти плодове.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Слънце", "frost-hardiness": "5"}},
+	{Name: "Лавандула в саксия", Price: "7.90", Category: "plant",
+		Description: "Многогодишна ароматна лавандула в саксия 14 см, устойчива на суша и слани.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Слънце", "frost-hardiness": "-20", "pot-volume": "1.5"}},
+	{Name: "Мента", Price: "4.50", Category: "plant",
+		Description: "Освежаваща градинска мента за чай и коктейли, расте бързо на полусянка.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Полусянка", "frost-hardiness": "-25", "pot-volume": "1"}},
+	{Name: "Розмарин", Price: "6.20", Category: "plant",
+		Description: "Вечнозелена подправка в саксия, обича слънце и добре дренирана почва.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Слънце", "frost-hardiness": "-10", "pot-volume": "2"}},
+	{Name: "Ягоди Албион", Price: "3.20", Category: "plant",
+		Description: "Ремонтантен сорт ягоди, който плододава от май до първите есенни студове.",
+		Attributes:  map[string]string{"sowing-month": "Април", "sun-exposure": "Слънце", "frost-hardiness": "-15"}},
+	{Name: "Боровинка Блукроп", Price: "14.90", Category: "plant",
+		Description: "Двегодишен храст от висока боровинка за кисела почва, до 5 кг плод от храст.",
+		Attributes:  map[string]string{"sowing-month": "Септември", "sun-exposure": "Слънце", "frost-hardiness": "-30", "pot-volume": "3"}},
+	{Name: "Градинска лопата", Price: "24.90", Category: "tool",
+		Description: "Лопата от закалена стомана с ясенова дръжка за прекопаване и засаждане.",
+		Attributes:  map[string]string{"material": "стомана, ясен"}},
+	{Name: "Лейка 10 л", Price: "12.50", Category: "tool",
+		Description: "Пластмасова лейка с подвижен накрайник за фино поливане на разсад.",
+		Attributes:  map[string]string{"material": "пластмаса"}},
+	{Name: "Лозарска ножица", Price: "18.90", Category: "tool",
+		Description: "Ножица с разминаващи се остриета за резитба на лози, овощни дървета и храсти.",
+		Attributes:  map[string]string{"material": "стомана"}},
+	{Name: "Градински ръкавици", Price: "6.90", Category: "tool",
+		Description: "Дишащи ръкавици с латексово покритие на дланта, размер M.",
+		Attributes:  map[string]string{"material": "полиестер, латекс"}},
+	{Name: "Маркуч 20 м", Price: "32.00", Category: "tool",
+		Description: "Четирислоен маркуч 1/2 цол, устойчив на пречупване и UV лъчи.",
+		Attributes:  map[string]string{"material": "PVC"}},
+	{Name: "Гребло", Price: "15.40", Category: "tool",
+		Description: "Метално гребло с 14 зъба за подравняване на лехи и събиране на листа.",
+		Attributes:  map[string]string{"material": "стомана, дърво"}},
+	{Name: "Пръскачка 5 л", Price: "27.50", Category: "tool",
+		Description: "Помпена пръскачка с регулируема дюза за растителнозащитни препарати.",
+		Attributes:  map[string]string{"material": "пластмаса"}},
+	{Name: "Универсална почвена смес 50 л", Price: "9.90", Category: "soil",
+		Description: "Готова смес от торф, перлит и тор за саксийни и градински растения.",
+		Attributes:  map[string]string{"soil-ph": "5.5..6.5", "volume": "50"}},
+	{Name: "Субстрат за разсад 20 л", Price: "6.40", Category: "soil",
+		Description: "Фин субстрат с ниско съдържание на соли за покълване на семена и пикиране.",
+		Attributes:  map[string]string{"soil-ph": "5.5..6", "volume": "20"}},
+	{Name: "Торф 70 л", Price: "11.80", Category: "soil",
+		Description: "Кисел торф за боровинки, рододендрони и подобряване на тежки почви.",
+		Attributes:  map[string]string{"soil-ph": "3.5..4.5", "volume": "70"}},
+	{Name: "Перлит 5 л", Price: "5.20", Category: "soil",
+		Description: "Вулканичен перлит за аериране и дренаж на почвени смеси.",
+		Attributes:  map[string]string{"soil-ph": "7..7.5", "volume": "5"}},
+	{Name: "Биохумус 10 л", Price: "8.60", Category: "soil",
+		Description: "Органичен тор от калифорнийски червеи, подобрява структурата и плодородието на почвата.",
+		Attributes:  map[string]string{"soil-ph": "6.8..7.2", "volume": "10"}},
+}
+
+// categoryColors are the background and accent colors of the generated product images.
+var categoryColors = map[string][2]string{
+	"seed":  {"#f3eed9", "#c9a227"},
+	"plant": {"#e3f1de", "#4f8a3c"},
+	"tool":  {"#e6ebef", "#5b6c7a"},
+	"soil":  {"#efe3d6", "#7a4e2d"},
+}
+
+var firstNames = []string{"Иван", "Мария", "Георги", "Елена", "Димитър", "Анна", "Петър", "Николета",
+	"Стоян", "Виктория", "Христо", "Десислава", "Тодор", "Радослава", "Калин", "Цветелина"}
+
+var lastNames = []string{"Петров", "Иванов", "Георгиев", "Димитров", "Николов", "Стоянов", "Тодоров",
+	"Христов", "Маринов", "Колев", "Попов", "Ангелов"}
+
+var cities = []string{"София", "Пловдив", "Варна", "Бургас", "Русе", "Стара Загора", "Плевен", "Велико Търново"}
+
+var streets = []string{"ул. Витоша", "бул. България", "ул. Шипка", "ул. Раковски", "бул. Христо Ботев", "ул. Иван Вазов"}
+
+var reviewTexts = map[int][]string{
+	5: {"Отлично качество, ще поръчам отново.", "Всичко покълна, много съм доволна.", "Бърза доставка и точно както е описано."},
+	4: {"Добър продукт, опаковката можеше да е по-здрава.", "Много добре, малко по-скъпо от очакваното."},
+	3: {"Става, но очаквах повече.", "Половината семена покълнаха."},
+	2: {"Пристигна с повреда, но поддръжката помогна."},
+}
+
+var questionTexts = []string{"Подходящо ли е за отглеждане на балкон?", "Колко време се съхранява?",
+	"Може ли да се засади наесен?", "Има ли нужда от специален тор?"}
+
+var answerTexts = []string{"Да, при поне 6 часа слънце на ден.", "При сухо и хладно място до две години.",
+	"Препоръчваме засаждане през пролетта.", "Достатъчна е универсална почвена смес."}
+
+var chatOpeners = []string{"Здравейте, кога ще пристигне поръчката ми?", "Може ли да сменя адреса за доставка?",
+	"Имате ли семена от черни домати?", "Получих счупена лейка, какво да направя?"}
+
+var chatReplies = []string{"Здравейте! Проверявам веднага.", "Благодарим за търпението, уредихме го.",
+	"Ще получите отговор по имейл до края на деня."}
+
+var cannedResponses = []struct{ Title, Content string }{
+	{"Поздрав", "Здравейте! Благодарим, че се свързахте с нас. С какво можем да помогнем?"},
+	{"Статус на доставка", "Поръчката ви е изпратена, номерът за проследяване е на страницата на поръчката."},
+	{"Връщане", "Можете да заявите връщане от страницата на завършената поръчка до 14 дни след получаването ѝ."},
+}
@@ -0,0 +1,471 @@
+// Package seed fills a database with demo accounts, a catalog with images, historical
+// orders and support chats. It goes through the services, so the seeded data obeys the
+// same rules as data entered in the shop: stock movements, status history and so on.
+//
+// Running it again only adds what is missing, and the same seed on an empty database
+// always produces the same shop.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"time"
+
+	"agro.store/backend/db"
+	"agro.store/backend/inventory"
+	"agro.store/backend/server"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Password is the password of every seeded account.
+const Password = "agrostore123"
+
+// Options sizes the seeded data.
+type Options struct {
+	// Seed drives every random choice.
+	Seed uint64
+	// Customers is the number of customer accounts besides user@agro.store.
+	Customers int
+	// Orders is the number of orders spread over the customers.
+	Orders int
+	// Chats is the number of support chats.
+	Chats int
+	// UploadDir is where the product images are written.
+	UploadDir string
+}
+
+// DefaultOptions returns the size of a demo shop.
+func DefaultOptions() Options {
+	return Options{Seed: 1, Customers: 20, Orders: 60, Chats: 8, UploadDir: "./upload"}
+}
+
+type seeder struct {
+	q        db.Querier
+	services server.Services
+	opts     Options
+	rng      *rand.Rand
+
+	admin     db.GetUserByIdRow
+	support   db.GetUserByIdRow
+	customers []db.GetUserByIdRow
+	products  []db.GetProductByIdRow
+}
+
+// Run seeds the database behind q and services.
+func Run(ctx context.Context, q db.Querier, services server.Services, opts Options) error {
+	s := &seeder{q: q,
+		services: services,
+		opts:     opts,
+		rng:      rand.New(rand.NewPCG(opts.Seed, opts.Seed))}
+
+	steps := []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		{"users", s.seedUsers},
+		{"categories", s.seedCategories},
+		{"attributes", s.seedAttributes},
+		{"catalog", s.seedCatalog},
+		{"orders", s.seedOrders},
+		{"questions", s.seedQuestions},
+		{"chats", s.seedChats},
+	}
+	for _, step := range steps {
+		err := step.run(ctx)
+		if err != nil {
+			return fmt.Errorf("seed %s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+// user returns the account with email, registering it with role when it doesn't exist.
+func (s *seeder) user(ctx context.Context, email string, fname string, lname string, role db.UserRole) (db.GetUserByIdRow, error) {
+	existing, err := s.q.GetUserByEmail(ctx, email)
+	if err == nil {
+		return s.services.Users.User(ctx, existing.ID)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return db.GetUserByIdRow{}, err
+	}
+
+	id, err := s.services.Users.Register(ctx, server.UserRegister{Email: email,
+		Password:  Password,
+		FirstName: fname,
+		LastName:  lname})
+	if err != nil {
+		return db.GetUserByIdRow{}, fmt.Errorf("register %s: %w", email, err)
+	}
+	if role != db.UserRoleUser {
+		_, err = s.q.UpdateUserRole(ctx, db.UpdateUserRoleParams{ID: id, Role: role})
+		if err != nil {
+			return db.GetUserByIdRow{}, fmt.Errorf("make %s %s: %w", email, role, err)
+		}
+	}
+	log.Printf("seed: created %s %s", role, email)
+	return s.services.Users.User(ctx, id)
+}
+
+func (s *seeder) seedUsers(ctx context.Context) error {
+	var err error
+	s.admin, err = s.user(ctx, "admin@agro.store", "Админ", "Агростор", db.UserRoleAdmin)
+	if err != nil {
+		return err
+	}
+	s.support, err = s.user(ctx, "support@agro.store", "Поддръжка", "Агростор", db.UserRoleSupport)
+	if err != nil {
+		return err
+	}
+	customer, err := s.user(ctx, "user@agro.store", "Иван", "Петров", db.UserRoleUser)
+	if err != nil {
+		return err
+	}
+	s.customers = append(s.customers, customer)
+
+	for i := 1; i <= s.opts.Customers; i++ {
+		fname := firstNames[s.rng.IntN(len(firstNames))]
+		lname := lastNames[s.rng.IntN(len(lastNames))]
+		customer, err := s.user(ctx, fmt.Sprintf("customer%03d@agro.store", i), fname, lname, db.UserRoleUser)
+		if err != nil {
+			return err
+		}
+		s.customers = append(s.customers, customer)
+	}
+	return nil
+}
+
+func (s *seeder) seedCategories(ctx context.Context) error {
+	for _, c := range categories {
+		_, err := s.services.Catalog.Category(ctx, c.Slug)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, server.ErrUnknownCategory) {
+			return err
+		}
+		_, err = s.services.Catalog.CreateCategory(ctx, server.CategoryCreateEdit{Name: c.Name,
+			Slug:        c.Slug,
+			Parent:      c.Parent,
+			Description: c.Description}, "")
+		if err != nil {
+			return fmt.Errorf("create category %s: %w", c.Slug, err)
+		}
+	}
+	return nil
+}
+
+func (s *seeder) seedAttributes(ctx context.Context) error {
+	for _, a := range attributes {
+		_, err := s.services.Catalog.Attribute(ctx, a.Slug)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, server.ErrUnknownAttribute) {
+			return err
+		}
+		category, err := s.services.Catalog.Category(ctx, a.Category)
+		if err != nil {
+			return err
+		}
+		_, err = s.services.Catalog.CreateAttribute(ctx, category, server.AttributeCreateEdit{Name: a.Name,
+			Slug:    a.Slug,
+			Kind:    a.Kind,
+			Unit:    a.Unit,
+			Options: a.Options})
+		if err != nil {
+			return fmt.Errorf("create attribute %s: %w", a.Slug, err)
+		}
+	}
+	return nil
+}
+
+func (s *seeder) seedCatalog(ctx context.Context) error {
+	err := os.MkdirAll(s.opts.UploadDir, 0o755)
+	if err != nil {
+		return err
+	}
+	for i, p := range catalog {
+		stock := 40 + s.rng.IntN(160)
+
+		existing, err := s.services.Catalog.Products(ctx, p.Name, "")
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			product, err := s.services.Catalog.Product(ctx, existing[0].ID)
+			if err != nil {
+				return err
+			}
+			s.products = append(s.products, product)
+			continue
+		}
+
+		img := fmt.Sprintf("seed-%02d.svg", i+1)
+		err = os.WriteFile(filepath.Join(s.opts.UploadDir, img), productImage(p), 0o644)
+		if err != nil {
+			return fmt.Errorf("write image of %s: %w", p.Name, err)
+		}
+		id, err := s.services.Catalog.CreateProduct(ctx, server.ProductCreateEdit{Name: p.Name,
+			Price:       p.Price,
+			Description: p.Description,
+			Category:    p.Category,
+			Attributes:  p.Attributes}, img)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", p.Name, err)
+		}
+		err = s.services.Catalog.AdjustStock(ctx, id, s.admin.ID, server.StockAdjust{Change: stock,
+			Reason: string(db.StockMovementTypeReceipt),
+			Note:   "начална наличност"})
+		if err != nil {
+			return fmt.Errorf("stock %s: %w", p.Name, err)
+		}
+		product, err := s.services.Catalog.Product(ctx, id)
+		if err != nil {
+			return err
+		}
+		s.products = append(s.products, product)
+	}
+	log.Printf("seed: catalog has %d demo products", len(s.products))
+	return nil
+}
+
+// productImage draws a placeholder picture in the colors of the product's category.
+func productImage(p demoProduct) []byte {
+	colors := categoryColors[p.Category]
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="600" height="600" viewBox="0 0 600 600">
+  <rect width="600" height="600" fill="%s"/>
+  <circle cx="300" cy="250" r="150" fill="%s" opacity="0.85"/>
+  <path d="M300 330 C 230 260, 240 180, 300 140 C 360 180, 370 260, 300 330 Z" fill="#ffffff" opacity="0.7"/>
+  <text x="300" y="500" font-family="sans-serif" font-size="34" text-anchor="middle" fill="#243024">%s</text>
+</svg>
+`, colors[0], colors[1], html.EscapeString(p.Name)))
+}
+
+// seedOrders places orders only while no seeded customer has one, so a second run
+// doesn't double the history.
+func (s *seeder) seedOrders(ctx context.Context) error {
+	for _, c := range s.customers {
+		orders, err := s.services.Orders.OrdersByUser(ctx, c.ID)
+		if err != nil {
+			return err
+		}
+		if len(orders) > 0 {
+			log.Printf("seed: orders exist already, skipping them")
+			return nil
+		}
+	}
+
+	for i := 0; i < s.opts.Orders; i++ {
+		customer := s.customers[s.rng.IntN(len(s.customers))]
+		err := s.placeOrder(ctx, customer)
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("seed: placed %d orders", s.opts.Orders)
+	return nil
+}
+
+func (s *seeder) placeOrder(ctx context.Context, customer db.GetUserByIdRow) error {
+	lines := 1 + s.rng.IntN(4)
+	var shoppingList []server.CartItem
+	picked := map[int]bool{}
+	for len(shoppingList) < lines {
+		i := s.rng.IntN(len(s.products))
+		if picked[i] {
+			continue
+		}
+		picked[i] = true
+		shoppingList = append(shoppingList, server.CartItem{ID: s.products[i].ID.String(), Quantity: 1 + s.rng.IntN(3)})
+	}
+	err := s.restock(ctx, shoppingList)
+	if err != nil {
+		return err
+	}
+	address := fmt.Sprintf("%s, %s %d", cities[s.rng.IntN(len(cities))], streets[s.rng.IntN(len(streets))], 1+s.rng.IntN(120))
+	phone := fmt.Sprintf("+359 88%07d", s.rng.IntN(10000000))
+
+	orderID, err := s.services.Orders.Create(ctx, customer.ID, server.OrderCreate{Address: address, PhoneNumber: phone}, shoppingList)
+	if err != nil {
+		return fmt.Errorf("create order: %w", err)
+	}
+	daysAgo := 1 + s.rng.IntN(180)
+
+	// Most orders of the past are completed, the recent ones are still on their way.
+	outcome := s.rng.IntN(100)
+	switch {
+	case outcome < 10:
+		err = s.services.Orders.ChangeStatus(ctx, orderID, s.admin, db.OrderTypeCancelled, "клиентът се отказа")
+	case outcome < 20:
+		// left pending
+	case outcome < 35:
+		err = s.services.Orders.ChangeStatus(ctx, orderID, s.admin, db.OrderTypePaid, "")
+	default:
+		err = s.deliver(ctx, orderID, customer, shoppingList, outcome >= 50)
+	}
+	if err != nil {
+		return fmt.Errorf("advance order %s: %w", orderID.String(), err)
+	}
+
+	return s.q.BackdateOrder(ctx, db.BackdateOrderParams{ID: orderID,
+		Age: pgtype.Interval{Days: int32(daysAgo), Microseconds: s.rng.Int64N(int64(24 * time.Hour / time.Microsecond)), Valid: true}})
+}
+
+// restock books a receipt for every line of the shopping list the stock can't cover, so a
+// long history of orders doesn't run out of the demo stock.
+func (s *seeder) restock(ctx context.Context, shoppingList []server.CartItem) error {
+	for _, item := range shoppingList {
+		productID, err := server.StrToUUID(item.ID)
+		if err != nil {
+			return err
+		}
+		_, err = s.services.Cart.CheckStock(ctx, productID, item.Quantity)
+		if !errors.Is(err, inventory.ErrOutOfStock) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err = s.services.Catalog.AdjustStock(ctx, productID, s.admin.ID, server.StockAdjust{Change: 40 + s.rng.IntN(160),
+			Reason: string(db.StockMovementTypeReceipt),
+			Note:   "нова доставка"})
+		if err != nil {
+			return fmt.Errorf("restock %s: %w", item.ID, err)
+		}
+	}
+	return nil
+}
+
+// deliver pays and ships an order and, when delivered, lets the customer review what they bought.
+func (s *seeder) deliver(ctx context.Context, orderID pgtype.UUID, customer db.GetUserByIdRow, shoppingList []server.CartItem, delivered bool) error {
+	err := s.services.Orders.ChangeStatus(ctx, orderID, s.admin, db.OrderTypePaid, "")
+	if err != nil {
+		return err
+	}
+	delivery, err := s.services.Orders.Ship(ctx, orderID, s.admin, server.DeliveryCreate{TrackingNumber: fmt.Sprintf("BG%09d", s.rng.IntN(1000000000))})
+	if err != nil {
+		return err
+	}
+	err = s.services.Orders.AdvanceDelivery(ctx, delivery.ID, s.admin, db.DeliveryStatusIntransit, "")
+	if err != nil || !delivered {
+		return err
+	}
+	err = s.services.Orders.AdvanceDelivery(ctx, delivery.ID, s.admin, db.DeliveryStatusDelivered, "")
+	if err != nil {
+		return err
+	}
+
+	for _, item := range shoppingList {
+		if s.rng.IntN(100) >= 40 {
+			continue
+		}
+		productID, err := server.StrToUUID(item.ID)
+		if err != nil {
+			return err
+		}
+		rating := 2 + s.rng.IntN(4)
+		texts := reviewTexts[rating]
+		err = s.services.Catalog.AddReview(ctx, customer, productID, server.ReviewCreate{Rating: rating,
+			Content: texts[s.rng.IntN(len(texts))]})
+		if err != nil && !errors.Is(err, server.ErrCannotReview) {
+			return fmt.Errorf("review: %w", err)
+		}
+	}
+	return nil
+}
+
+// seedQuestions asks and answers a question on each of the first products. Every question
+// belongs to the same product on every run, so products asked about already are skipped.
+func (s *seeder) seedQuestions(ctx context.Context) error {
+	for i, q := range questionTexts {
+		product := s.products[i%len(s.products)]
+		customer := s.customers[i%len(s.customers)]
+		_, questions, err := s.services.Catalog.Interactions(ctx, product.ID)
+		if err != nil {
+			return err
+		}
+		if len(questions) > 0 {
+			continue
+		}
+		err = s.services.Catalog.AskQuestion(ctx, customer.ID, product.ID, server.QuestionCreate{Content: q})
+		if err != nil {
+			return err
+		}
+		_, questions, err = s.services.Catalog.Interactions(ctx, product.ID)
+		if err != nil || len(questions) == 0 {
+			return err
+		}
+		err = s.services.Catalog.AnswerQuestion(ctx, s.support.ID, product.ID, questions[0].ID, server.QuestionAnswer{Response: answerTexts[i]})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedChats opens support chats for customers without one and adds the canned responses
+// when there are none.
+func (s *seeder) seedChats(ctx context.Context) error {
+	canned, err := s.services.Chat.CannedResponses(ctx)
+	if err != nil {
+		return err
+	}
+	if len(canned) == 0 {
+		for _, r := range cannedResponses {
+			err = s.services.Chat.AddCannedResponse(ctx, s.support.ID, server.CannedResponseCreate{Title: r.Title, Content: r.Content})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	opened := 0
+	for i := 0; i < s.opts.Chats && i < len(s.customers); i++ {
+		customer := s.customers[i]
+		chats, err := s.q.CountChatsByCreator(ctx, customer.ID)
+		if err != nil {
+			return err
+		}
+		if chats > 0 {
+			continue
+		}
+
+		chatID, err := s.services.Chat.Open(ctx, customer.ID)
+		if err != nil {
+			return err
+		}
+		_, err = s.services.Chat.Post(ctx, chatID, customer.ID, chatOpeners[s.rng.IntN(len(chatOpeners))])
+		if err != nil {
+			return err
+		}
+		opened++
+
+		// A third stays in the queue for the support console demo.
+		if s.rng.IntN(3) == 0 {
+			continue
+		}
+		err = s.services.Chat.Claim(ctx, chatID, s.support.ID)
+		if err != nil {
+			return err
+		}
+		_, err = s.services.Chat.Post(ctx, chatID, s.support.ID, chatReplies[s.rng.IntN(len(chatReplies))])
+		if err != nil {
+			return err
+		}
+		if s.rng.IntN(2) == 0 {
+			err = s.services.Chat.Close(ctx, chatID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	log.Printf("seed: opened %d support chats", opened)
+	return nil
+}
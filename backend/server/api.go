@@ -0,0 +1,944 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"agro.store/backend/db"
+	"agro.store/backend/inventory"
+	"agro.store/backend/orderstatus"
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// APIResponse is the envelope of every successful API response. Meta is only set for lists.
+type APIResponse struct {
+	Data any       `json:"data"`
+	Meta *ListMeta `json:"meta,omitempty"`
+}
+
+// ListMeta describes the page of a paginated list. Lists paged with a cursor, like the
+// products, have no page number and set Next to the after= of the following page instead,
+// leaving it empty on the last one.
+type ListMeta struct {
+	Page    int    `json:"page,omitempty"`
+	PerPage int    `json:"per_page"`
+	Total   int    `json:"total"`
+	Next    string `json:"next,omitempty"`
+}
+
+// APIErrorResponse is the envelope of every failed API response.
+type APIErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError is a machine readable code, a human readable message and,
+// for validation errors, the fields that failed.
+type APIError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError names a request field and the validation rule it broke.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+}
+
+type ProductResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Price       float64   `json:"price"`
+	Discount    float64   `json:"discount"`
+	FinalPrice  float64   `json:"final_price"`
+	Description string    `json:"description"`
+	ImageURL    string    `json:"image_url"`
+	Stock       int32     `json:"stock"`
+	Type        string    `json:"type"`
+	Category    string    `json:"category"`
+	AvgRating   float64   `json:"avg_rating"`
+	ReviewCount int32     `json:"review_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Attributes are only listed for a single product.
+	Attributes []AttributeValueResponse `json:"attributes,omitempty"`
+}
+
+// AttributeValueResponse is a spec value of a product. Text and enum attributes have Text,
+// numbers and ranges Min and Max, which are equal for a number.
+type AttributeValueResponse struct {
+	Slug string   `json:"slug"`
+	Name string   `json:"name"`
+	Kind string   `json:"kind"`
+	Unit string   `json:"unit,omitempty"`
+	Text string   `json:"text,omitempty"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+}
+
+// CategoryResponse is a node of the category tree. ParentID is empty for the roots, whose
+// slugs are the product types.
+type CategoryResponse struct {
+	ID           string `json:"id"`
+	ParentID     string `json:"parent_id,omitempty"`
+	Slug         string `json:"slug"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	ImageURL     string `json:"image_url,omitempty"`
+	Position     int32  `json:"position"`
+	ProductCount int32  `json:"product_count"`
+}
+
+type UserResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Role      string `json:"role"`
+}
+
+type CartLine struct {
+	Product  ProductResponse `json:"product"`
+	Quantity int             `json:"quantity"`
+	Total    float64         `json:"total"`
+	InStock  bool            `json:"in_stock"`
+}
+
+type CartQuoteResponse struct {
+	Lines []CartLine `json:"lines"`
+	Total float64    `json:"total"`
+}
+
+type OrderItemResponse struct {
+	ID              string  `json:"id"`
+	ProductID       string  `json:"product_id"`
+	ProductName     string  `json:"product_name"`
+	Quantity        int32   `json:"quantity"`
+	PriceAtPurchase float64 `json:"price_at_purchase"`
+}
+
+type OrderResponse struct {
+	ID           string              `json:"id"`
+	UserID       string              `json:"user_id"`
+	Status       string              `json:"status"`
+	NextStatuses []string            `json:"next_statuses"`
+	Address      string              `json:"address,omitempty"`
+	PhoneNumber  string              `json:"phone_number,omitempty"`
+	Items        []OrderItemResponse `json:"items,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+type ChatResponse struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	CreatedBy  string    `json:"created_by"`
+	AssignedTo string    `json:"assigned_to,omitempty"`
+	Unread     int32     `json:"unread"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// numericFloat converts a NUMERIC column to a float, treating NULL as 0.
+func numericFloat(n pgtype.Numeric) float64 {
+	f, _ := n.Float64Value()
+	return f.Float64
+}
+
+func productResponse(p db.ListAllProductsRow) ProductResponse {
+	price := numericFloat(p.Price)
+	discount := numericFloat(p.Discount)
+	return ProductResponse{ID: p.ID.String(),
+		Name:        p.Name,
+		Price:       price,
+		Discount:    discount,
+		FinalPrice:  price * (100 - discount) / 100,
+		Description: p.Description.String,
+		ImageURL:    fmt.Sprintf("/upload/%s", p.Img),
+		Stock:       p.Stock,
+		Type:        p.Type,
+		Category:    p.Category,
+		AvgRating:   p.AvgRating,
+		ReviewCount: p.ReviewCount,
+		CreatedAt:   p.CreatedAt.Time,
+		UpdatedAt:   p.UpdatedAt.Time}
+}
+
+// productByIdResponse converts a single product, which is loaded without its rating.
+func productByIdResponse(p db.GetProductByIdRow) ProductResponse {
+	return productResponse(db.ListAllProductsRow{ID: p.ID,
+		Name:        p.Name,
+		Price:       p.Price,
+		Discount:    p.Discount,
+		Description: p.Description,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Img:         p.Img,
+		Stock:       p.Stock,
+		Type:        p.Type,
+		Category:    p.Category})
+}
+
+func attributeValueResponse(v db.ListProductAttributeValuesRow) AttributeValueResponse {
+	resp := AttributeValueResponse{Slug: v.Slug,
+		Name: v.Name,
+		Kind: string(v.Kind),
+		Unit: v.Unit,
+		Text: v.TextValue.String}
+	if v.MinValue.Valid && v.MaxValue.Valid {
+		low, high := numericFloat(v.MinValue), numericFloat(v.MaxValue)
+		resp.Min, resp.Max = &low, &high
+	}
+	return resp
+}
+
+func userResponse(u db.GetUserByIdRow) UserResponse {
+	return UserResponse{ID: u.ID.String(), Email: u.Email, FirstName: u.Fname, LastName: u.Lname, Role: string(u.Role)}
+}
+
+func orderResponse(o db.Order, viewer db.GetUserByIdRow) OrderResponse {
+	next := []string{}
+	for _, status := range statusFormOptions(viewer.Role, o.Status) {
+		next = append(next, string(status))
+	}
+	return OrderResponse{ID: o.ID.String(),
+		UserID:       o.UserID.String(),
+		Status:       string(o.Status),
+		NextStatuses: next,
+		CreatedAt:    o.CreatedAt.Time,
+		UpdatedAt:    o.UpdatedAt.Time}
+}
+
+func chatResponse(chat db.Chat) ChatResponse {
+	resp := ChatResponse{ID: chat.ID.String(),
+		Status:    string(chat.Status),
+		CreatedBy: chat.CreatedBy.String(),
+		CreatedAt: chat.CreatedAt.Time}
+	if chat.AssignedTo.Valid {
+		resp.AssignedTo = chat.AssignedTo.String()
+	}
+	return resp
+}
+
+// apiError aborts the request with an error envelope.
+func apiError(c *gin.Context, status int, code string, message string) {
+	c.AbortWithStatusJSON(status, APIErrorResponse{Error: APIError{Code: code, Message: message}})
+}
+
+// apiBind decodes the request body into obj and validates it. On failure the error
+// response is already written and false is returned.
+func (s *Server) apiBind(c *gin.Context, obj any) bool {
+	err := c.ShouldBind(obj)
+	if err != nil {
+		apiError(c, http.StatusBadRequest, "bad_request", err.Error())
+		return false
+	}
+	err = s.validate.Struct(obj)
+	if err == nil {
+		return true
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		apiError(c, http.StatusBadRequest, "bad_request", err.Error())
+		return false
+	}
+	var fields []FieldError
+	for _, err := range validationErrors {
+		fields = append(fields, FieldError{Field: err.Field(), Rule: err.Tag()})
+	}
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, APIErrorResponse{Error: APIError{Code: "validation_failed",
+		Message: "some fields are invalid",
+		Fields:  fields}})
+	return false
+}
+
+// apiParamUUID parses a UUID path parameter, answering 404 when it isn't one.
+func apiParamUUID(c *gin.Context, name string) (pgtype.UUID, bool) {
+	id, err := StrToUUID(c.Param(name))
+	if err != nil {
+		apiError(c, http.StatusNotFound, "not_found", fmt.Sprintf("%s is not a valid id", name))
+		return pgtype.UUID{}, false
+	}
+	return id, true
+}
+
+// apiLoadError answers a failed lookup with 404 for missing rows and 500 for everything else.
+func apiLoadError(c *gin.Context, what string, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		apiError(c, http.StatusNotFound, "not_found", fmt.Sprintf("%s not found", what))
+		return
+	}
+	slog.Warn(fmt.Sprintf("Can't load %s in %s : %v", what, c.FullPath(), err))
+	apiError(c, http.StatusInternalServerError, "internal", fmt.Sprintf("can't load %s", what))
+}
+
+// perPage reads the page size requested with ?per_page=.
+func perPage(c *gin.Context) int {
+	perPage, err := strconv.Atoi(c.Query("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	return min(perPage, maxPerPage)
+}
+
+// pageQuery reads the page requested with ?page=&per_page= for lists paged in SQL.
+// The caller sets the Total of the returned meta.
+func pageQuery(c *gin.Context) (Page, *ListMeta) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size := perPage(c)
+	return Page{Limit: int32(size), Offset: int32((page - 1) * size)}, &ListMeta{Page: page, PerPage: size}
+}
+
+// paginate cuts the page requested with ?page=&per_page= out of a list that is small
+// enough to load whole, like the category tree.
+func paginate[T any](c *gin.Context, items []T) ([]T, *ListMeta) {
+	page, meta := pageQuery(c)
+	start := min(int(page.Offset), len(items))
+	end := min(start+int(page.Limit), len(items))
+	meta.Total = len(items)
+	return items[start:end], meta
+}
+
+// apiCartItems converts the cart of an API client to the shopping list the checkout works with.
+func apiCartItems(items []APICartItem) []CartItem {
+	shoppingList := make([]CartItem, 0, len(items))
+	for _, item := range items {
+		shoppingList = append(shoppingList, CartItem{ID: item.ProductID, Quantity: item.Quantity})
+	}
+	return shoppingList
+}
+
+// apiOrder loads the order in the path, answering 404 unless the user owns it or is staff.
+func (s *Server) apiOrder(c *gin.Context, user db.GetUserByIdRow) (db.Order, bool) {
+	orderID, ok := apiParamUUID(c, "id")
+	if !ok {
+		return db.Order{}, false
+	}
+	order, err := s.Orders.Order(c, orderID)
+	if err != nil {
+		apiLoadError(c, "order", err)
+		return db.Order{}, false
+	}
+	if order.UserID != user.ID && user.Role != db.UserRoleAdmin && user.Role != db.UserRoleSupport {
+		apiError(c, http.StatusNotFound, "not_found", "order not found")
+		return db.Order{}, false
+	}
+	return order, true
+}
+
+// apiChat loads the chat in the path, answering 404 unless the user started it or is staff.
+func (s *Server) apiChat(c *gin.Context, user db.GetUserByIdRow) (db.Chat, bool) {
+	chatID, ok := apiParamUUID(c, "id")
+	if !ok {
+		return db.Chat{}, false
+	}
+	chat, err := s.Chat.Chat(c, chatID)
+	if err != nil {
+		apiLoadError(c, "chat", err)
+		return db.Chat{}, false
+	}
+	if chat.CreatedBy != user.ID && user.Role != db.UserRoleAdmin && user.Role != db.UserRoleSupport {
+		apiError(c, http.StatusNotFound, "not_found", "chat not found")
+		return db.Chat{}, false
+	}
+	return chat, true
+}
+
+// registerAPI adds the versioned JSON API under /api/v1. It shares the services
+// with the HTML handlers and authenticates with bearer tokens instead of the session cookie.
+func (s *Server) registerAPI(router *gin.Engine) {
+	// GET /api/openapi.json serves the contract of the API and GET /api/docs a viewer for it.
+	spec, docs := openAPISpec(), apiDocsOperations()
+	router.GET("/api/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+	router.GET("/api/docs", func(c *gin.Context) {
+		err := views.APIDocsPage(docs).Render(c, c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /api/docs: %v", err)
+		}
+	})
+
+	api := router.Group(apiPrefix)
+	authed := api.Group("", s.apiAuthMiddleware())
+	staff := authed.Group("", apiRoleMiddleware(db.UserRoleSupport, db.UserRoleAdmin))
+	admin := authed.Group("", apiRoleMiddleware(db.UserRoleAdmin))
+
+	// POST /api/v1/auth/token exchanges an email and password for a bearer token.
+	api.POST("/auth/token", func(c *gin.Context) {
+		var loginForm UserLogin
+		if !s.apiBind(c, &loginForm) {
+			return
+		}
+		userID, err := s.Users.Authenticate(c, loginForm)
+		if errors.Is(err, ErrWrongCredentials) {
+			apiError(c, http.StatusUnauthorized, "wrong_credentials", err.Error())
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't authenticate in /api/v1/auth/token : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't log in")
+			return
+		}
+		token, err := s.Users.IssueToken(c, userID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't issue token in /api/v1/auth/token : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't issue token")
+			return
+		}
+		c.JSON(http.StatusCreated, APIResponse{Data: token})
+	})
+
+	// DELETE /api/v1/auth/token revokes the token the request is made with. API keys are
+	// revoked by an admin on the profile page, a script logging out doesn't delete its key.
+	authed.DELETE("/auth/token", func(c *gin.Context) {
+		if c.GetStringSlice("scopes") != nil {
+			apiError(c, http.StatusBadRequest, "bad_request", "API keys can't be revoked with themselves, an admin revokes them on the profile page")
+			return
+		}
+		token, _ := bearerToken(c)
+		err := s.Users.RevokeToken(c, token)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't revoke token in /api/v1/auth/token : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't revoke token")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// POST /api/v1/users registers a customer account.
+	api.POST("/users", func(c *gin.Context) {
+		var registerForm UserRegister
+		if !s.apiBind(c, &registerForm) {
+			return
+		}
+		userID, err := s.Users.Register(c, registerForm)
+		if errors.Is(err, ErrUserExists) {
+			apiError(c, http.StatusConflict, "user_exists", err.Error())
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't register in /api/v1/users : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't register")
+			return
+		}
+		user, err := s.Users.User(c, userID)
+		if err != nil {
+			apiLoadError(c, "user", err)
+			return
+		}
+		c.JSON(http.StatusCreated, APIResponse{Data: userResponse(user)})
+	})
+
+	// GET /api/v1/users lists every user.
+	admin.GET("/users", apiScopeMiddleware(scopeUsersRead), func(c *gin.Context) {
+		page, meta := pageQuery(c)
+		users, total, err := s.Users.UsersPage(c, page)
+		if err != nil {
+			apiLoadError(c, "users", err)
+			return
+		}
+		resp := make([]UserResponse, 0, len(users))
+		for _, u := range users {
+			resp = append(resp, userResponse(db.GetUserByIdRow(u)))
+		}
+		meta.Total = int(total)
+		c.JSON(http.StatusOK, APIResponse{Data: resp, Meta: meta})
+	})
+
+	// GET /api/v1/users/me returns the authenticated user.
+	authed.GET("/users/me", apiScopeMiddleware(scopeUsersRead), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		c.JSON(http.StatusOK, APIResponse{Data: userResponse(user)})
+	})
+
+	// PUT /api/v1/users/me changes the names of the authenticated user.
+	authed.PUT("/users/me", apiScopeMiddleware(scopeUsersWrite), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		var userForm UserEdit
+		if !s.apiBind(c, &userForm) {
+			return
+		}
+		err := s.Users.Update(c, user, user.ID, userForm, "")
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't update user in /api/v1/users/me : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't update user")
+			return
+		}
+		user.Fname, user.Lname = userForm.FirstName, userForm.LastName
+		c.JSON(http.StatusOK, APIResponse{Data: userResponse(user)})
+	})
+
+	// DELETE /api/v1/users/:id deletes a user.
+	admin.DELETE("/users/:id", apiScopeMiddleware(scopeUsersWrite), func(c *gin.Context) {
+		userID, ok := apiParamUUID(c, "id")
+		if !ok {
+			return
+		}
+		err := s.Users.Delete(c, userID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't delete user in /api/v1/users/:id : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't delete user")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// GET /api/v1/products lists the catalog, optionally filtered with ?name= or ?type=.
+	// Pages are keyed like the catalog page, the next one is requested with ?after=<meta.next>.
+	api.GET("/products", func(c *gin.Context) {
+		if name := c.Query("name"); name != "" {
+			products, err := s.Catalog.Products(c, name, "")
+			if err != nil {
+				apiLoadError(c, "products", err)
+				return
+			}
+			resp := make([]ProductResponse, 0, len(products))
+			for _, p := range products {
+				resp = append(resp, productResponse(p))
+			}
+			c.JSON(http.StatusOK, APIResponse{Data: resp, Meta: &ListMeta{PerPage: perPage(c), Total: len(resp)}})
+			return
+		}
+		filter := CatalogFilter{After: c.Query("after"), PageSize: int32(perPage(c))}
+		if productType := c.Query("type"); productType != "" {
+			filter.Types = []string{productType}
+		}
+		page, err := s.Catalog.Browse(c, filter)
+		if errors.Is(err, ErrInvalidCursor) {
+			apiError(c, http.StatusBadRequest, "invalid_cursor", err.Error())
+			return
+		}
+		if err != nil {
+			apiLoadError(c, "products", err)
+			return
+		}
+		resp := make([]ProductResponse, 0, len(page.Products))
+		for _, p := range page.Products {
+			resp = append(resp, productResponse(p))
+		}
+		meta := &ListMeta{PerPage: int(filter.PageSize), Next: page.Next}
+		for _, f := range page.Facets {
+			if f.Facet == "total" {
+				meta.Total = int(f.Count)
+			}
+		}
+		c.JSON(http.StatusOK, APIResponse{Data: resp, Meta: meta})
+	})
+
+	// GET /api/v1/products/:id returns a single product.
+	api.GET("/products/:id", func(c *gin.Context) {
+		productID, ok := apiParamUUID(c, "id")
+		if !ok {
+			return
+		}
+		product, err := s.Catalog.Product(c, productID)
+		if err != nil {
+			apiLoadError(c, "product", err)
+			return
+		}
+		values, err := s.Catalog.ProductAttributes(c, productID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list attributes in /api/v1/products/:id : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't list attributes")
+			return
+		}
+		resp := productByIdResponse(product)
+		resp.Attributes = make([]AttributeValueResponse, 0, len(values))
+		for _, v := range values {
+			resp.Attributes = append(resp.Attributes, attributeValueResponse(v))
+		}
+		c.JSON(http.StatusOK, APIResponse{Data: resp})
+	})
+
+	// POST /api/v1/products adds a product. The body is multipart, with the image as "file"
+	// and the spec values as attr[slug], ranges as attr_min[slug] and attr_max[slug].
+	admin.POST("/products", apiScopeMiddleware(scopeProductsWrite), func(c *gin.Context) {
+		productForm := ProductCreateEdit{Attributes: formAttributes(c)}
+		if !s.apiBind(c, &productForm) {
+			return
+		}
+		file, err := c.FormFile("file")
+		if err != nil {
+			apiError(c, http.StatusBadRequest, "bad_request", "file is required")
+			return
+		}
+		img, err := s.Catalog.SaveImage(file)
+		if errors.Is(err, ErrInvalidImage) {
+			apiError(c, http.StatusUnprocessableEntity, "invalid_image", err.Error())
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't save image in /api/v1/products : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't save image")
+			return
+		}
+		productID, err := s.Catalog.CreateProduct(c, productForm, img)
+		if err != nil {
+			s.Catalog.RemoveImage(img)
+			if errors.Is(err, ErrInvalidPrice) {
+				apiError(c, http.StatusUnprocessableEntity, "invalid_price", err.Error())
+				return
+			}
+			if errors.Is(err, ErrUnknownCategory) {
+				apiError(c, http.StatusUnprocessableEntity, "unknown_category", err.Error())
+				return
+			}
+			if errors.Is(err, ErrInvalidAttributeValue) {
+				apiError(c, http.StatusUnprocessableEntity, "invalid_attribute", err.Error())
+				return
+			}
+			slog.Warn(fmt.Sprintf("Can't create product in /api/v1/products : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't create product")
+			return
+		}
+		product, err := s.Catalog.Product(c, productID)
+		if err != nil {
+			apiLoadError(c, "product", err)
+			return
+		}
+		c.JSON(http.StatusCreated, APIResponse{Data: productByIdResponse(product)})
+	})
+
+	// DELETE /api/v1/products/:id removes a product from the catalog.
+	admin.DELETE("/products/:id", apiScopeMiddleware(scopeProductsWrite), func(c *gin.Context) {
+		productID, ok := apiParamUUID(c, "id")
+		if !ok {
+			return
+		}
+		err := s.Catalog.DeleteProduct(c, productID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't delete product in /api/v1/products/:id : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't delete product")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// POST /api/v1/products/:id/stock books a stock receipt or correction, e.g. from the warehouse scanner.
+	admin.POST("/products/:id/stock", apiScopeMiddleware(scopeProductsWrite), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		productID, ok := apiParamUUID(c, "id")
+		if !ok {
+			return
+		}
+		var stockForm StockAdjust
+		if !s.apiBind(c, &stockForm) {
+			return
+		}
+		err := s.Catalog.AdjustStock(c, productID, user.ID, stockForm)
+		if errors.Is(err, inventory.ErrNegativeStock) {
+			apiError(c, http.StatusConflict, "stock_rejected", "stock can't go below zero")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't adjust stock in /api/v1/products/:id/stock : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't adjust stock")
+			return
+		}
+		product, err := s.Catalog.Product(c, productID)
+		if err != nil {
+			apiLoadError(c, "product", err)
+			return
+		}
+		c.JSON(http.StatusOK, APIResponse{Data: productByIdResponse(product)})
+	})
+
+	// GET /api/v1/categories lists the category tree, every category followed by its subcategories.
+	api.GET("/categories", func(c *gin.Context) {
+		categories, err := s.Catalog.Categories(c)
+		if err != nil {
+			apiLoadError(c, "categories", err)
+			return
+		}
+		resp := make([]CategoryResponse, 0, len(categories))
+		for _, cat := range categories {
+			r := CategoryResponse{ID: cat.ID.String(),
+				Slug:         cat.Slug,
+				Name:         cat.Name,
+				Description:  cat.Description,
+				Position:     cat.Position,
+				ProductCount: cat.ProductCount}
+			if cat.ParentID.Valid {
+				r.ParentID = cat.ParentID.String()
+			}
+			if cat.Img.Valid {
+				r.ImageURL = fmt.Sprintf("/upload/%s", cat.Img.String)
+			}
+			resp = append(resp, r)
+		}
+		page, meta := paginate(c, resp)
+		c.JSON(http.StatusOK, APIResponse{Data: page, Meta: meta})
+	})
+
+	// POST /api/v1/cart/quote prices a cart and checks every line against the stock.
+	// API clients keep their cart themselves and send it with the order.
+	api.POST("/cart/quote", func(c *gin.Context) {
+		var quoteForm CartQuote
+		if !s.apiBind(c, &quoteForm) {
+			return
+		}
+		products, quants := s.Cart.Lines(c, apiCartItems(quoteForm.Items))
+		resp := CartQuoteResponse{Lines: []CartLine{}}
+		for i, p := range products {
+			line := CartLine{Product: productByIdResponse(p), Quantity: quants[i], InStock: p.Stock >= int32(quants[i])}
+			line.Total = line.Product.FinalPrice * float64(quants[i])
+			resp.Lines = append(resp.Lines, line)
+			resp.Total += line.Total
+		}
+		c.JSON(http.StatusOK, APIResponse{Data: resp})
+	})
+
+	// GET /api/v1/orders lists the orders of the authenticated user, or every order for staff with ?all=true.
+	authed.GET("/orders", apiScopeMiddleware(scopeOrdersRead), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		page, meta := pageQuery(c)
+		var orders []db.Order
+		var total int64
+		var err error
+		if c.Query("all") == "true" && (user.Role == db.UserRoleAdmin || user.Role == db.UserRoleSupport) {
+			orders, total, err = s.Orders.OrdersPage(c, page)
+		} else {
+			orders, total, err = s.Orders.OrdersByUserPage(c, user.ID, page)
+		}
+		if err != nil {
+			apiLoadError(c, "orders", err)
+			return
+		}
+		resp := make([]OrderResponse, 0, len(orders))
+		for _, o := range orders {
+			resp = append(resp, orderResponse(o, user))
+		}
+		meta.Total = int(total)
+		c.JSON(http.StatusOK, APIResponse{Data: resp, Meta: meta})
+	})
+
+	// POST /api/v1/orders places an order for the cart sent in the body.
+	authed.POST("/orders", apiScopeMiddleware(scopeOrdersWrite), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		var orderForm APIOrderCreate
+		if !s.apiBind(c, &orderForm) {
+			return
+		}
+		orderID, err := s.Orders.Create(c, user.ID, orderForm.OrderCreate, apiCartItems(orderForm.Items))
+		if errors.Is(err, inventory.ErrOutOfStock) {
+			apiError(c, http.StatusConflict, "out_of_stock", err.Error())
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create order in /api/v1/orders : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't create order")
+			return
+		}
+		order, err := s.Orders.Order(c, orderID)
+		if err != nil {
+			apiLoadError(c, "order", err)
+			return
+		}
+		c.JSON(http.StatusCreated, APIResponse{Data: orderResponse(order, user)})
+	})
+
+	// GET /api/v1/orders/:id returns an order with its delivery details and items.
+	authed.GET("/orders/:id", apiScopeMiddleware(scopeOrdersRead), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		order, ok := s.apiOrder(c, user)
+		if !ok {
+			return
+		}
+		resp := orderResponse(order, user)
+		details, err := s.Orders.Details(c, order.ID)
+		if err == nil {
+			resp.Address, resp.PhoneNumber = details.Address, details.PhoneNumber.String
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			slog.Warn(fmt.Sprintf("Can't get order details in /api/v1/orders/:id : %v", err))
+		}
+		items, products, err := s.Orders.Items(c, order.ID)
+		if err != nil {
+			apiLoadError(c, "order items", err)
+			return
+		}
+		for i, item := range items {
+			resp.Items = append(resp.Items, OrderItemResponse{ID: item.ID.String(),
+				ProductID:       item.ProductID.String(),
+				ProductName:     products[i].Name,
+				Quantity:        item.Quantity,
+				PriceAtPurchase: numericFloat(item.PriceAtPurchase)})
+		}
+		c.JSON(http.StatusOK, APIResponse{Data: resp})
+	})
+
+	// POST /api/v1/orders/:id/status moves an order to another status.
+	authed.POST("/orders/:id/status", apiScopeMiddleware(scopeOrdersWrite), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		order, ok := s.apiOrder(c, user)
+		if !ok {
+			return
+		}
+		var statusForm OrderStatusUpdate
+		if !s.apiBind(c, &statusForm) {
+			return
+		}
+		if returnStatuses[order.Status] || returnStatuses[db.OrderType(statusForm.Status)] {
+			apiError(c, http.StatusConflict, "invalid_transition", "returns are handled through the return request")
+			return
+		}
+		if deliveryStatuses[db.OrderType(statusForm.Status)] {
+			apiError(c, http.StatusConflict, "invalid_transition", "shipping and delivery are handled through the shipment")
+			return
+		}
+		err := s.Orders.ChangeStatus(c, order.ID, user, db.OrderType(statusForm.Status), statusForm.Note)
+		if errors.Is(err, orderstatus.ErrForbiddenTransition) {
+			apiError(c, http.StatusForbidden, "forbidden", err.Error())
+			return
+		}
+		if errors.Is(err, orderstatus.ErrInvalidTransition) {
+			apiError(c, http.StatusConflict, "invalid_transition", err.Error())
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't change order status in /api/v1/orders/:id/status : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't change order status")
+			return
+		}
+		order.Status = db.OrderType(statusForm.Status)
+		c.JSON(http.StatusOK, APIResponse{Data: orderResponse(order, user)})
+	})
+
+	// GET /api/v1/chats lists the open chat of a customer, or the chat queue for staff.
+	authed.GET("/chats", apiScopeMiddleware(scopeChatsRead), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		resp := []ChatResponse{}
+		if user.Role == db.UserRoleAdmin || user.Role == db.UserRoleSupport {
+			queue, err := s.Chat.Queue(c, user.ID)
+			if err != nil {
+				apiLoadError(c, "chats", err)
+				return
+			}
+			for _, q := range queue {
+				chat := chatResponse(db.Chat{ID: q.ID, Status: q.Status, CreatedBy: q.CreatedBy, AssignedTo: q.AssignedTo, CreatedAt: q.CreatedAt})
+				chat.Unread = q.Unread
+				resp = append(resp, chat)
+			}
+		} else {
+			chat, err := s.Chat.ChatByCreator(c, user.ID)
+			if err == nil {
+				resp = append(resp, chatResponse(chat))
+			} else if !errors.Is(err, pgx.ErrNoRows) {
+				apiLoadError(c, "chats", err)
+				return
+			}
+		}
+		page, meta := paginate(c, resp)
+		c.JSON(http.StatusOK, APIResponse{Data: page, Meta: meta})
+	})
+
+	// POST /api/v1/chats returns the open chat of a customer, starting one if there is none.
+	authed.POST("/chats", apiScopeMiddleware(scopeChatsWrite), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		chatID, err := s.Chat.Open(c, user.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't open chat in /api/v1/chats : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't open chat")
+			return
+		}
+		chat, err := s.Chat.Chat(c, chatID)
+		if err != nil {
+			apiLoadError(c, "chat", err)
+			return
+		}
+		c.JSON(http.StatusCreated, APIResponse{Data: chatResponse(chat)})
+	})
+
+	// GET /api/v1/chats/:id/messages lists the messages of a chat, oldest first.
+	authed.GET("/chats/:id/messages", apiScopeMiddleware(scopeChatsRead), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		chat, ok := s.apiChat(c, user)
+		if !ok {
+			return
+		}
+		page, meta := pageQuery(c)
+		messages, total, err := s.Chat.MessagesPage(c, chat.ID, page)
+		if err != nil {
+			apiLoadError(c, "messages", err)
+			return
+		}
+		resp := make([]ChatMessage, 0, len(messages))
+		for _, m := range messages {
+			resp = append(resp, *messageEvent(m).Message)
+		}
+		meta.Total = int(total)
+		c.JSON(http.StatusOK, APIResponse{Data: resp, Meta: meta})
+	})
+
+	// POST /api/v1/chats/:id/messages posts a message and delivers it to everyone in the chat.
+	authed.POST("/chats/:id/messages", apiScopeMiddleware(scopeChatsWrite), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		chat, ok := s.apiChat(c, user)
+		if !ok {
+			return
+		}
+		var messageForm ChatMessageCreate
+		if !s.apiBind(c, &messageForm) {
+			return
+		}
+		msg, err := s.Chat.Post(c, chat.ID, user.ID, messageForm.Content)
+		if errors.Is(err, ErrChatClosed) {
+			apiError(c, http.StatusConflict, "chat_closed", err.Error())
+			return
+		}
+		if errors.Is(err, ErrInvalidMessage) {
+			apiError(c, http.StatusUnprocessableEntity, "invalid_message", err.Error())
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't post message in /api/v1/chats/:id/messages : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't post message")
+			return
+		}
+		s.hub.Publish(c, msg)
+		c.JSON(http.StatusCreated, APIResponse{Data: messageEvent(msg).Message})
+	})
+
+	// POST /api/v1/chats/:id/close closes a resolved chat.
+	staff.POST("/chats/:id/close", apiScopeMiddleware(scopeChatsWrite), func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		chat, ok := s.apiChat(c, user)
+		if !ok {
+			return
+		}
+		err := s.Chat.Close(c, chat.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't close chat in /api/v1/chats/:id/close : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't close chat")
+			return
+		}
+		s.hub.Closed(c, chat.ID.String())
+		chat.Status = db.ChatStatusClosed
+		c.JSON(http.StatusOK, APIResponse{Data: chatResponse(chat)})
+	})
+
+	// GET /api/v1/stats/db reports the use of the database connection pool.
+	admin.GET("/stats/db", apiScopeMiddleware(scopeStatsRead), func(c *gin.Context) {
+		if s.sessions == nil {
+			apiError(c, http.StatusServiceUnavailable, "unavailable", "the server has no database pool")
+			return
+		}
+		c.JSON(http.StatusOK, APIResponse{Data: poolStatsResponse(s.sessions.Pool.Stat())})
+	})
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"agro.store/backend/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// apiKeyPrefix starts every API key, so keys are easy to spot in configs and leaks.
+const apiKeyPrefix = "agro_"
+
+// Scopes an admin can grant to an API key. Tokens from a login carry every scope.
+const (
+	scopeProductsWrite = "products:write"
+	scopeOrdersRead    = "orders:read"
+	scopeOrdersWrite   = "orders:write"
+	scopeUsersRead     = "users:read"
+	scopeUsersWrite    = "users:write"
+	scopeChatsRead     = "chats:read"
+	scopeChatsWrite    = "chats:write"
+	scopeStatsRead     = "stats:read"
+)
+
+// apiScopes lists every scope in the order they are offered when minting a key.
+var apiScopes = []string{scopeProductsWrite, scopeOrdersRead, scopeOrdersWrite, scopeUsersRead, scopeUsersWrite, scopeChatsRead, scopeChatsWrite, scopeStatsRead}
+
+// TokenResponse is the bearer token handed to an API client after it logs in.
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// hashToken is what is stored for a token, so a leaked table doesn't leak usable tokens.
+// Tokens are random, a plain SHA-256 is enough.
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// randomToken returns 32 random bytes encoded for use in a header.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// IssueToken creates a new bearer token for a user.
+func (s *userService) IssueToken(ctx context.Context, userID pgtype.UUID) (TokenResponse, error) {
+	token, err := randomToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	expiresAt := time.Now().Add(s.tokenTTL)
+	_, err = s.q.CreateApiToken(ctx, db.CreateApiTokenParams{UserID: userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true}})
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{Token: token, TokenType: "Bearer", ExpiresAt: expiresAt}, nil
+}
+
+// RevokeToken deletes a bearer token, so it stops working immediately.
+func (s *userService) RevokeToken(ctx context.Context, token string) error {
+	return s.q.DeleteApiToken(ctx, hashToken(token))
+}
+
+// UserByToken returns the user a bearer token or API key acts for and records that the
+// token was used. Scopes are nil for tokens from a login, which carry every scope.
+func (s *userService) UserByToken(ctx context.Context, token string) (db.GetUserByIdRow, []string, error) {
+	u, err := s.q.GetUserByApiToken(ctx, hashToken(token))
+	if err != nil {
+		return db.GetUserByIdRow{}, nil, err
+	}
+	err = s.q.TouchApiToken(ctx, u.TokenID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't record use of token %s : %v", u.TokenID.String(), err))
+	}
+	return db.GetUserByIdRow{ID: u.ID, Email: u.Email, Fname: u.Fname, Lname: u.Lname, Role: u.Role}, u.Scopes, nil
+}
+
+// IssueAPIKey mints a scoped API key acting for the admin who creates it and returns
+// the key. Only its hash is stored, so this is the only time the key can be shown.
+func (s *userService) IssueAPIKey(ctx context.Context, adminID pgtype.UUID, keyForm ApiKeyCreate) (string, error) {
+	secret, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	key := apiKeyPrefix + secret
+	_, err = s.q.CreateApiKey(ctx, db.CreateApiKeyParams{UserID: adminID,
+		Name:      pgtype.Text{String: keyForm.Name, Valid: true},
+		Prefix:    pgtype.Text{String: key[:len(apiKeyPrefix)+6], Valid: true},
+		TokenHash: hashToken(key),
+		Scopes:    keyForm.Scopes,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, keyForm.ExpiresInDays), Valid: true}})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (s *userService) APIKeys(ctx context.Context) ([]db.ListApiKeysRow, error) {
+	return s.q.ListApiKeys(ctx)
+}
+
+// RevokeAPIKey deletes an API key, so it stops working immediately.
+func (s *userService) RevokeAPIKey(ctx context.Context, id pgtype.UUID) error {
+	return s.q.DeleteApiKey(ctx, id)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// apiAuthMiddleware authenticates API requests with a bearer token or API key.
+// The user is stored in the context as "user" and their ID as "userID", like authMiddleware does,
+// and the scopes of an API key as "scopes".
+func (s *Server) apiAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			apiError(c, http.StatusUnauthorized, "unauthorized", "missing bearer token")
+			return
+		}
+		u, scopes, err := s.Users.UserByToken(c, token)
+		if errors.Is(err, pgx.ErrNoRows) {
+			apiError(c, http.StatusUnauthorized, "unauthorized", "token is invalid or expired")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("From apiAuthMiddleware(): can't get user by token : %v", err))
+			apiError(c, http.StatusInternalServerError, "internal", "can't check token")
+			return
+		}
+		c.Set("userID", u.ID.String())
+		c.Set("user", u)
+		c.Set("scopes", scopes)
+		c.Next()
+	}
+}
+
+// apiRoleMiddleware restricts API routes to users with one of roles.
+func apiRoleMiddleware(roles ...db.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		for _, role := range roles {
+			if user.Role == role {
+				c.Next()
+				return
+			}
+		}
+		apiError(c, http.StatusForbidden, "forbidden", "your role can't access this resource")
+	}
+}
+
+// apiScopeMiddleware rejects API keys that weren't granted scope. Login tokens have no scopes
+// stored and may do everything their user may.
+func apiScopeMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes := c.GetStringSlice("scopes")
+		if scopes == nil || slices.Contains(scopes, scope) {
+			c.Next()
+			return
+		}
+		apiError(c, http.StatusForbidden, "insufficient_scope", fmt.Sprintf("the API key needs the %s scope", scope))
+	}
+}
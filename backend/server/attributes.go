@@ -0,0 +1,339 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"agro.store/backend/db"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrUnknownAttribute is returned for an attribute slug that doesn't exist.
+var ErrUnknownAttribute = errors.New("no such attribute")
+
+// ErrAttributeSlugTaken is returned when another attribute already has the slug.
+var ErrAttributeSlugTaken = errors.New("another attribute has this slug")
+
+// ErrAttributeOptions is returned for an enum attribute without options.
+var ErrAttributeOptions = errors.New("an enum attribute needs at least one option")
+
+// ErrInvalidAttributeValue is returned for a value that doesn't fit the kind of its attribute.
+var ErrInvalidAttributeValue = errors.New("invalid attribute value")
+
+func (s *catalogService) Attributes(ctx context.Context) ([]db.ListAttributesRow, error) {
+	return s.q.ListAttributes(ctx)
+}
+
+func (s *catalogService) Attribute(ctx context.Context, slug string) (db.Attribute, error) {
+	attribute, err := s.q.GetAttributeBySlug(ctx, slug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Attribute{}, fmt.Errorf("%w: %s", ErrUnknownAttribute, slug)
+	}
+	return attribute, err
+}
+
+// ProductAttributes returns the spec values of a product, the attributes of the root
+// category first.
+func (s *catalogService) ProductAttributes(ctx context.Context, productID pgtype.UUID) ([]db.ListProductAttributeValuesRow, error) {
+	return s.q.ListProductAttributeValues(ctx, productID)
+}
+
+// attributeOptions splits the options of an enum attribute, one per line, dropping blank
+// and repeated lines. Other kinds have no options.
+func attributeOptions(kind db.AttributeKind, options string) ([]string, error) {
+	if kind != db.AttributeKindEnum {
+		return []string{}, nil
+	}
+	kept := []string{}
+	for _, o := range strings.Split(options, "\n") {
+		o = strings.TrimSpace(o)
+		if o != "" && !slices.Contains(kept, o) {
+			kept = append(kept, o)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, ErrAttributeOptions
+	}
+	return kept, nil
+}
+
+func attributeError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrAttributeSlugTaken
+	}
+	return err
+}
+
+// CreateAttribute defines an attribute for the products of a category and its subcategories.
+func (s *catalogService) CreateAttribute(ctx context.Context, category db.Category, attributeForm AttributeCreateEdit) (db.Attribute, error) {
+	kind := db.AttributeKind(attributeForm.Kind)
+	options, err := attributeOptions(kind, attributeForm.Options)
+	if err != nil {
+		return db.Attribute{}, err
+	}
+	attribute, err := s.q.CreateAttribute(ctx, db.CreateAttributeParams{CategoryID: category.ID,
+		Slug:     attributeForm.Slug,
+		Name:     attributeForm.Name,
+		Kind:     kind,
+		Unit:     attributeForm.Unit,
+		Options:  options,
+		Position: attributeForm.Position})
+	if err != nil {
+		return db.Attribute{}, attributeError(err)
+	}
+	return attribute, nil
+}
+
+// UpdateAttribute changes the name, unit, options and position of an attribute. Products
+// lose the values of enum options that were removed.
+func (s *catalogService) UpdateAttribute(ctx context.Context, attribute db.Attribute, attributeForm AttributeCreateEdit) error {
+	options, err := attributeOptions(attribute.Kind, attributeForm.Options)
+	if err != nil {
+		return err
+	}
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := db.New(tx)
+
+	err = qtx.UpdateAttribute(ctx, db.UpdateAttributeParams{ID: attribute.ID,
+		Name:     attributeForm.Name,
+		Unit:     attributeForm.Unit,
+		Options:  options,
+		Position: attributeForm.Position})
+	if err != nil {
+		return fmt.Errorf("update attribute: %w", err)
+	}
+	err = qtx.DeleteStaleAttributeValues(ctx, attribute.ID)
+	if err != nil {
+		return fmt.Errorf("delete stale values: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteAttribute removes an attribute together with the values products have for it.
+func (s *catalogService) DeleteAttribute(ctx context.Context, attribute db.Attribute) error {
+	return s.q.DeleteAttribute(ctx, attribute.ID)
+}
+
+// attributeNumber parses a number of a spec value, accepting a decimal comma.
+func attributeNumber(raw string) (pgtype.Numeric, float64, error) {
+	raw = strings.Replace(strings.TrimSpace(raw), ",", ".", 1)
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return pgtype.Numeric{}, 0, ErrInvalidAttributeValue
+	}
+	numeric := pgtype.Numeric{}
+	if err := numeric.Scan(raw); err != nil {
+		return pgtype.Numeric{}, 0, ErrInvalidAttributeValue
+	}
+	return numeric, f, nil
+}
+
+// attributeValue checks a spec value against the kind of its attribute. A range missing
+// one of its bounds is the single value it has.
+func attributeValue(attribute db.Attribute, raw string) (db.CreateProductAttributeValueParams, error) {
+	value := db.CreateProductAttributeValueParams{AttributeID: attribute.ID}
+	switch attribute.Kind {
+	case db.AttributeKindText:
+		value.TextValue = pgtype.Text{String: raw, Valid: true}
+	case db.AttributeKindEnum:
+		if !slices.Contains(attribute.Options, raw) {
+			return value, ErrInvalidAttributeValue
+		}
+		value.TextValue = pgtype.Text{String: raw, Valid: true}
+	case db.AttributeKindNumber:
+		n, _, err := attributeNumber(raw)
+		if err != nil {
+			return value, err
+		}
+		value.MinValue, value.MaxValue = n, n
+	case db.AttributeKindRange:
+		low, high, _ := strings.Cut(raw, "..")
+		if strings.TrimSpace(low) == "" {
+			low = high
+		}
+		if strings.TrimSpace(high) == "" {
+			high = low
+		}
+		minValue, minFloat, err := attributeNumber(low)
+		if err != nil {
+			return value, err
+		}
+		maxValue, maxFloat, err := attributeNumber(high)
+		if err != nil {
+			return value, err
+		}
+		if minFloat > maxFloat {
+			return value, ErrInvalidAttributeValue
+		}
+		value.MinValue, value.MaxValue = minValue, maxValue
+	default:
+		return value, ErrInvalidAttributeValue
+	}
+	return value, nil
+}
+
+// attributeValues checks the spec values of a product form against the attributes of its
+// category. Values of attributes the category doesn't have are dropped.
+func attributeValues(ctx context.Context, q db.Querier, categoryID pgtype.UUID, values map[string]string) ([]db.CreateProductAttributeValueParams, error) {
+	attributes, err := q.ListCategoryAttributes(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	var checked []db.CreateProductAttributeValueParams
+	for _, attribute := range attributes {
+		raw := strings.TrimSpace(values[attribute.Slug])
+		if raw == "" {
+			continue
+		}
+		value, err := attributeValue(attribute, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, attribute.Name)
+		}
+		checked = append(checked, value)
+	}
+	return checked, nil
+}
+
+// setProductAttributes replaces the spec values of a product.
+func setProductAttributes(ctx context.Context, q db.Querier, productID pgtype.UUID, values []db.CreateProductAttributeValueParams) error {
+	err := q.DeleteProductAttributeValues(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("delete attribute values: %w", err)
+	}
+	for _, value := range values {
+		value.ProductID = productID
+		err = q.CreateProductAttributeValue(ctx, value)
+		if err != nil {
+			return fmt.Errorf("create attribute value: %w", err)
+		}
+	}
+	return nil
+}
+
+// attributeFilters are the attribute filters of the catalog as the parallel arrays
+// product_matches_attributes takes.
+type attributeFilters struct {
+	ids   []pgtype.UUID
+	texts []string
+	mins  []pgtype.Numeric
+	maxs  []pgtype.Numeric
+}
+
+func optionalNumber(n string) (pgtype.Numeric, error) {
+	if n == "" {
+		return pgtype.Numeric{}, nil
+	}
+	numeric, _, err := attributeNumber(n)
+	return numeric, err
+}
+
+// attributeFilters resolves the attribute slugs of a catalog filter. Filters without a
+// value, like the empty inputs of the filter form, are dropped.
+func (s *catalogService) attributeFilters(ctx context.Context, filter CatalogFilter) (attributeFilters, error) {
+	var slugs []string
+	for _, m := range []map[string]string{filter.Attributes, filter.AttributeMins, filter.AttributeMaxs} {
+		for slug, value := range m {
+			if strings.TrimSpace(value) != "" && !slices.Contains(slugs, slug) {
+				slugs = append(slugs, slug)
+			}
+		}
+	}
+	sort.Strings(slugs)
+
+	var filters attributeFilters
+	for _, slug := range slugs {
+		attribute, err := s.Attribute(ctx, slug)
+		if err != nil {
+			return attributeFilters{}, err
+		}
+		minValue, err := optionalNumber(filter.AttributeMins[slug])
+		if err != nil {
+			return attributeFilters{}, err
+		}
+		maxValue, err := optionalNumber(filter.AttributeMaxs[slug])
+		if err != nil {
+			return attributeFilters{}, err
+		}
+		filters.ids = append(filters.ids, attribute.ID)
+		filters.texts = append(filters.texts, strings.TrimSpace(filter.Attributes[slug]))
+		filters.mins = append(filters.mins, minValue)
+		filters.maxs = append(filters.maxs, maxValue)
+	}
+	return filters, nil
+}
+
+// formAttributes collects the spec values of a product form, joining the bounds of
+// ranges into "min..max".
+func formAttributes(c *gin.Context) map[string]string {
+	values := c.PostFormMap("attr")
+	maxs := c.PostFormMap("attr_max")
+	for slug, low := range c.PostFormMap("attr_min") {
+		high := maxs[slug]
+		if strings.TrimSpace(low) != "" || strings.TrimSpace(high) != "" {
+			values[slug] = low + ".." + high
+		}
+	}
+	return values
+}
+
+// attributeErrMsg is the message the attribute forms show for a failed change.
+func attributeErrMsg(err error) string {
+	switch {
+	case errors.Is(err, ErrAttributeSlugTaken):
+		return "Друга характеристика вече използва този адрес."
+	case errors.Is(err, ErrAttributeOptions):
+		return "Изброената характеристика трябва да има поне една стойност."
+	}
+	return "Характеристиката не можа да бъде запазена."
+}
+
+// categoryAttribute loads the attribute of a /categories/:slug/attributes/:attribute
+// route, which has to be defined on the category itself.
+func (s *Server) categoryAttribute(c *gin.Context, category db.Category) (db.Attribute, error) {
+	attribute, err := s.Catalog.Attribute(c, c.Param("attribute"))
+	if err != nil {
+		return db.Attribute{}, err
+	}
+	if attribute.CategoryID != category.ID {
+		return db.Attribute{}, fmt.Errorf("%w: %s", ErrUnknownAttribute, attribute.Slug)
+	}
+	return attribute, nil
+}
+
+// bindAttributeForm binds and validates an attribute form, skipping the fields in except.
+// The message is for the form when it fails.
+func (s *Server) bindAttributeForm(c *gin.Context, except ...string) (AttributeCreateEdit, string, bool) {
+	var attributeForm AttributeCreateEdit
+	err := c.ShouldBind(&attributeForm)
+	if err != nil {
+		slog.Warn(err.Error())
+		return attributeForm, "wrong fields", false
+	}
+	err = s.validate.StructExcept(attributeForm, except...)
+	if err != nil {
+		formErrMsg := ""
+		for _, err := range err.(validator.ValidationErrors) {
+			curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+			formErrMsg += curr
+			slog.Warn(curr)
+		}
+		return attributeForm, formErrMsg, false
+	}
+	return attributeForm, "", true
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"log"
+
+	"agro.store/backend/db"
+	"agro.store/backend/inventory"
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/sessions"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func init() {
+	// Session values are gob encoded, so the shopping list type must be known to gob.
+	gob.Register([]CartItem{})
+}
+
+// getShoppingList returns the shopping list stored in the session or an empty one.
+func getShoppingList(session *sessions.Session) []CartItem {
+	shoppingList, ok := session.Values["shoppingList"].([]CartItem)
+	if !ok {
+		return []CartItem{}
+	}
+	return shoppingList
+}
+
+// mergeShoppingList sums the quantities of products added to the cart more than once.
+func mergeShoppingList(shoppingList []CartItem) []CartItem {
+	var merged []CartItem
+	positions := make(map[string]int)
+	for _, item := range shoppingList {
+		if i, ok := positions[item.ID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		positions[item.ID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// cartService implements CartService on the database.
+type cartService struct {
+	q db.Querier
+}
+
+func (s *cartService) CheckStock(ctx context.Context, productID pgtype.UUID, quantity int) (db.GetProductByIdRow, error) {
+	product, err := s.q.GetProductById(ctx, productID)
+	if err != nil {
+		return db.GetProductByIdRow{}, err
+	}
+	if product.Stock < int32(quantity) {
+		return db.GetProductByIdRow{}, fmt.Errorf("%w: product %s", inventory.ErrOutOfStock, productID.String())
+	}
+	return product, nil
+}
+
+// Lines leaves out products that no longer exist.
+func (s *cartService) Lines(ctx context.Context, shoppingList []CartItem) ([]db.GetProductByIdRow, []int) {
+	var products []db.GetProductByIdRow
+	var quants []int
+	for _, shopping := range mergeShoppingList(shoppingList) {
+		productId, err := StrToUUID(shopping.ID)
+		if err != nil {
+			continue
+		}
+
+		product, err := s.q.GetProductById(ctx, productId)
+		if err != nil {
+			continue
+		}
+		products = append(products, product)
+		quants = append(quants, shopping.Quantity)
+	}
+	return products, quants
+}
+
+// renderCartPage loads the products in the shopping list and renders the cart.
+func (s *Server) renderCartPage(c *gin.Context, shoppingList []CartItem, errMsg string) {
+	products, quants := s.Cart.Lines(c, shoppingList)
+	err := views.CartPage(products, quants, errMsg).Render(c, c.Writer)
+	if err != nil {
+		log.Fatalf("failed to render in /cart: %v", err)
+	}
+}
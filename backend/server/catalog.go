@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"agro.store/backend/db"
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const catalogPageSize = 24
+
+// ErrInvalidCursor is returned for an after cursor that wasn't produced by Browse.
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// CatalogPage is one page of the filtered catalog with the facet counts of the whole result.
+type CatalogPage struct {
+	Products []db.ListAllProductsRow
+	// Facets count the products per filter value, each applying every filter but its own.
+	// The "total" facet counts the products matching all of them.
+	Facets []db.ListCatalogFacetsRow
+	// Attributes count the products per enum option and bound the numbers of the other
+	// attributes, each applying every filter but the one of its attribute.
+	Attributes []db.ListCatalogAttributeFacetsRow
+	// Next is the cursor of the following page, empty on the last page.
+	Next string
+}
+
+// Browse returns a page of the catalog. Pages are keyed by the sort value and ID of the last
+// product shown, so they stay stable while products are added.
+func (s *catalogService) Browse(ctx context.Context, filter CatalogFilter) (CatalogPage, error) {
+	minPrice, err := optionalPrice(filter.MinPrice)
+	if err != nil {
+		return CatalogPage{}, err
+	}
+	maxPrice, err := optionalPrice(filter.MaxPrice)
+	if err != nil {
+		return CatalogPage{}, err
+	}
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = catalogPageSize
+	}
+	types, categories := nonEmpty(filter.Types), nonEmpty(filter.Categories)
+	attributes, err := s.attributeFilters(ctx, filter)
+	if err != nil {
+		return CatalogPage{}, err
+	}
+
+	params := db.ListCatalogProductsParams{Sort: filter.Sort,
+		Types:          types,
+		Categories:     categories,
+		MinPrice:       minPrice,
+		MaxPrice:       maxPrice,
+		OnDiscount:     filter.OnDiscount,
+		InStock:        filter.InStock,
+		Subtree:        filter.Subtree,
+		AttributeIds:   attributes.ids,
+		AttributeTexts: attributes.texts,
+		AttributeMins:  attributes.mins,
+		AttributeMaxs:  attributes.maxs,
+		PageSize:       pageSize + 1}
+	if filter.After != "" {
+		params.AfterKey, params.AfterID, err = decodeCursor(filter.After)
+		if err != nil {
+			return CatalogPage{}, err
+		}
+	}
+	rows, err := s.q.ListCatalogProducts(ctx, params)
+	if err != nil {
+		return CatalogPage{}, fmt.Errorf("list products: %w", err)
+	}
+	facets, err := s.q.ListCatalogFacets(ctx, db.ListCatalogFacetsParams{Types: types,
+		Categories:     categories,
+		MinPrice:       minPrice,
+		MaxPrice:       maxPrice,
+		OnDiscount:     filter.OnDiscount,
+		InStock:        filter.InStock,
+		Subtree:        filter.Subtree,
+		AttributeIds:   attributes.ids,
+		AttributeTexts: attributes.texts,
+		AttributeMins:  attributes.mins,
+		AttributeMaxs:  attributes.maxs})
+	if err != nil {
+		return CatalogPage{}, fmt.Errorf("count facets: %w", err)
+	}
+	attributeFacets, err := s.q.ListCatalogAttributeFacets(ctx, db.ListCatalogAttributeFacetsParams{Types: types,
+		Categories:     categories,
+		MinPrice:       minPrice,
+		MaxPrice:       maxPrice,
+		OnDiscount:     filter.OnDiscount,
+		InStock:        filter.InStock,
+		Subtree:        filter.Subtree,
+		AttributeIds:   attributes.ids,
+		AttributeTexts: attributes.texts,
+		AttributeMins:  attributes.mins,
+		AttributeMaxs:  attributes.maxs})
+	if err != nil {
+		return CatalogPage{}, fmt.Errorf("count attribute facets: %w", err)
+	}
+
+	page := CatalogPage{Facets: facets, Attributes: attributeFacets}
+	if len(rows) > int(pageSize) {
+		rows = rows[:pageSize]
+		last := rows[len(rows)-1]
+		page.Next = encodeCursor(last.SortKey, last.ID)
+	}
+	page.Products = make([]db.ListAllProductsRow, 0, len(rows))
+	for _, r := range rows {
+		page.Products = append(page.Products, db.ListAllProductsRow{ID: r.ID,
+			Name:        r.Name,
+			Price:       r.Price,
+			Discount:    r.Discount,
+			Description: r.Description,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+			Img:         r.Img,
+			Stock:       r.Stock,
+			Type:        r.Type,
+			Category:    r.Category,
+			AvgRating:   r.AvgRating,
+			ReviewCount: r.ReviewCount})
+	}
+	return page, nil
+}
+
+func optionalPrice(price string) (pgtype.Numeric, error) {
+	if price == "" {
+		return pgtype.Numeric{}, nil
+	}
+	numeric := pgtype.Numeric{}
+	if err := numeric.Scan(price); err != nil || strings.HasPrefix(price, "-") {
+		return pgtype.Numeric{}, ErrInvalidPrice
+	}
+	return numeric, nil
+}
+
+// nonEmpty drops the empty values an "any" option of a filter submits, nil means no filter.
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func encodeCursor(sortKey float64, id pgtype.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(sortKey, 'g', -1, 64) + "_" + id.String()))
+}
+
+func decodeCursor(cursor string) (pgtype.Float8, pgtype.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pgtype.Float8{}, pgtype.UUID{}, ErrInvalidCursor
+	}
+	rawKey, rawID, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return pgtype.Float8{}, pgtype.UUID{}, ErrInvalidCursor
+	}
+	key, err := strconv.ParseFloat(rawKey, 64)
+	if err != nil {
+		return pgtype.Float8{}, pgtype.UUID{}, ErrInvalidCursor
+	}
+	id, err := StrToUUID(rawID)
+	if err != nil {
+		return pgtype.Float8{}, pgtype.UUID{}, ErrInvalidCursor
+	}
+	return pgtype.Float8{Float64: key, Valid: true}, id, nil
+}
+
+// catalogPage loads the catalog page at path for the filters in the query string, limited
+// to the subtree of a category when subtree is valid. Wrong filters and stale cursors
+// redirect to the page without them and report false.
+func (s *Server) catalogPage(c *gin.Context, path string, subtree pgtype.UUID) (views.CatalogPageData, bool) {
+	var filter CatalogFilter
+	err := c.ShouldBindQuery(&filter)
+	filter.Attributes = c.QueryMap("attr")
+	filter.AttributeMins = c.QueryMap("attr_min")
+	filter.AttributeMaxs = c.QueryMap("attr_max")
+	if err == nil {
+		err = s.validate.Struct(filter)
+	}
+	if err != nil {
+		slog.Warn(fmt.Sprintf("wrong filters in %s : %v", path, err))
+		c.Redirect(http.StatusFound, path)
+		return views.CatalogPageData{}, false
+	}
+	filter.Subtree = subtree
+
+	query := c.Request.URL.Query()
+	page, err := s.Catalog.Browse(c, filter)
+	if errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrInvalidPrice) ||
+		errors.Is(err, ErrUnknownAttribute) || errors.Is(err, ErrInvalidAttributeValue) {
+		slog.Warn(fmt.Sprintf("wrong filters in %s : %v", path, err))
+		query.Del("after")
+		if errors.Is(err, ErrInvalidPrice) {
+			query.Del("min_price")
+			query.Del("max_price")
+		}
+		if errors.Is(err, ErrUnknownAttribute) || errors.Is(err, ErrInvalidAttributeValue) {
+			for key := range query {
+				if strings.HasPrefix(key, "attr") {
+					query.Del(key)
+				}
+			}
+		}
+		c.Redirect(http.StatusFound, path+"?"+query.Encode())
+		return views.CatalogPageData{}, false
+	}
+	if err != nil {
+		slog.Warn(fmt.Sprintf("failed to list products in %s: %v", path, err))
+		page = CatalogPage{Products: []db.ListAllProductsRow{}}
+	}
+
+	categories, err := s.Catalog.Categories(c)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("failed to list categories in %s: %v", path, err))
+		categories = []db.ListCategoriesRow{}
+	}
+	favorites := map[string]bool{}
+	if user, ok := s.sessionUser(c); ok {
+		favorites, err = s.Catalog.FavoriteIDs(c, user.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("failed to list favorites in %s: %v", path, err))
+			favorites = map[string]bool{}
+		}
+	}
+
+	data := views.CatalogPageData{Path: path,
+		Products:   page.Products,
+		Favorites:  favorites,
+		Facets:     page.Facets,
+		Attributes: page.Attributes,
+		Categories: categories,
+		Query:      query}
+	if page.Next != "" {
+		next := c.Request.URL.Query()
+		next.Set("after", page.Next)
+		data.NextURL = path + "?" + next.Encode()
+	}
+	return data, true
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"agro.store/backend/db"
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrUnknownCategory is returned for a category slug that doesn't exist.
+var ErrUnknownCategory = errors.New("no such category")
+
+// ErrCategorySlugTaken is returned when another category already has the slug.
+var ErrCategorySlugTaken = errors.New("another category has this slug")
+
+// ErrCategoryCycle is returned when a category would be moved under itself or its subcategories.
+var ErrCategoryCycle = errors.New("a category can't be moved under itself or its subcategories")
+
+// ErrCategoryInUse is returned when deleting a category that still has subcategories or products.
+var ErrCategoryInUse = errors.New("category still has subcategories or products")
+
+// Categories returns the category tree in display order: every category is followed by its
+// subcategories, siblings ordered by position and name. Product counts include the subtree.
+func (s *catalogService) Categories(ctx context.Context) ([]db.ListCategoriesRow, error) {
+	categories, err := s.q.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	children := map[pgtype.UUID][]db.ListCategoriesRow{}
+	for _, c := range categories {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+	tree := make([]db.ListCategoriesRow, 0, len(categories))
+	var walk func(parent pgtype.UUID)
+	walk = func(parent pgtype.UUID) {
+		for _, c := range children[parent] {
+			tree = append(tree, c)
+			walk(c.ID)
+		}
+	}
+	walk(pgtype.UUID{})
+	return tree, nil
+}
+
+// Breadcrumbs returns the category with slug preceded by its ancestors, root first.
+func (s *catalogService) Breadcrumbs(ctx context.Context, slug string) ([]db.Category, error) {
+	category, err := s.Category(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.q.ListCategoryAncestors(ctx, category.ID)
+}
+
+func (s *catalogService) Category(ctx context.Context, slug string) (db.Category, error) {
+	category, err := s.q.GetCategoryBySlug(ctx, slug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Category{}, fmt.Errorf("%w: %s", ErrUnknownCategory, slug)
+	}
+	return category, err
+}
+
+// parentID resolves the parent slug of a category form, empty for a root category.
+func (s *catalogService) parentID(ctx context.Context, slug string) (pgtype.UUID, error) {
+	if slug == "" {
+		return pgtype.UUID{}, nil
+	}
+	parent, err := s.Category(ctx, slug)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	return parent.ID, nil
+}
+
+// categoryError translates the constraint violations of a category change.
+func categoryError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case "23505":
+		return ErrCategorySlugTaken
+	case "23514":
+		// set_category_path raises it without a constraint.
+		if pgErr.ConstraintName == "" {
+			return ErrCategoryCycle
+		}
+	case "23503":
+		return ErrCategoryInUse
+	}
+	return err
+}
+
+// CreateCategory adds a category with an already saved image, img is empty for none.
+func (s *catalogService) CreateCategory(ctx context.Context, categoryForm CategoryCreateEdit, img string) (db.Category, error) {
+	parentID, err := s.parentID(ctx, categoryForm.Parent)
+	if err != nil {
+		return db.Category{}, err
+	}
+	category, err := s.q.CreateCategory(ctx, db.CreateCategoryParams{ParentID: parentID,
+		Slug:        categoryForm.Slug,
+		Name:        categoryForm.Name,
+		Description: categoryForm.Description,
+		Img:         pgtype.Text{String: img, Valid: img != ""},
+		Position:    categoryForm.Position})
+	if err != nil {
+		return db.Category{}, categoryError(err)
+	}
+	return category, nil
+}
+
+// UpdateCategory changes a category, moving its subtree along when the parent changes.
+// An empty img keeps the current image.
+func (s *catalogService) UpdateCategory(ctx context.Context, category db.Category, categoryForm CategoryCreateEdit, img string) error {
+	parentID, err := s.parentID(ctx, categoryForm.Parent)
+	if err != nil {
+		return err
+	}
+	imgText := category.Img
+	if img != "" {
+		imgText = pgtype.Text{String: img, Valid: true}
+	}
+	err = s.q.UpdateCategory(ctx, db.UpdateCategoryParams{ID: category.ID,
+		ParentID:    parentID,
+		Slug:        categoryForm.Slug,
+		Name:        categoryForm.Name,
+		Description: categoryForm.Description,
+		Img:         imgText,
+		Position:    categoryForm.Position})
+	if err != nil {
+		return categoryError(err)
+	}
+	return nil
+}
+
+// DeleteCategory removes an empty category. Categories with subcategories or products
+// have to be emptied first.
+func (s *catalogService) DeleteCategory(ctx context.Context, slug string) error {
+	category, err := s.Category(ctx, slug)
+	if err != nil {
+		return err
+	}
+	err = s.q.DeleteCategory(ctx, category.ID)
+	if err != nil {
+		return categoryError(err)
+	}
+	if category.Img.Valid {
+		s.RemoveImage(category.Img.String)
+	}
+	return nil
+}
+
+// categoryErrMsg is the message the category forms show for a failed change.
+func categoryErrMsg(err error) string {
+	switch {
+	case errors.Is(err, ErrUnknownCategory):
+		return "Родителската категория не съществува."
+	case errors.Is(err, ErrCategorySlugTaken):
+		return "Друга категория вече използва този адрес."
+	case errors.Is(err, ErrCategoryCycle):
+		return "Категорията не може да бъде преместена в своя подкатегория."
+	case errors.Is(err, ErrInvalidImage):
+		return "File must be an image"
+	}
+	return "Категорията не можа да бъде запазена."
+}
+
+// renderCategoriesPage renders the category tree admins manage.
+func (s *Server) renderCategoriesPage(c *gin.Context, errMsg string) {
+	categories, err := s.Catalog.Categories(c)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list categories in /categories : %v", err))
+		categories = []db.ListCategoriesRow{}
+	}
+	err = views.CategoriesPage(categories, errMsg).Render(c, c.Writer)
+	if err != nil {
+		log.Fatalf("failed to render in /categories: %v", err)
+	}
+}
+
+// renderEditCategoryPage renders the edit form of a category with the values it has now.
+func (s *Server) renderEditCategoryPage(c *gin.Context, category db.Category, errMsg string) {
+	categories, err := s.Catalog.Categories(c)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list categories in /categories/:slug/edit : %v", err))
+		categories = []db.ListCategoriesRow{}
+	}
+	attributes, err := s.Catalog.Attributes(c)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list attributes in /categories/:slug/edit : %v", err))
+		attributes = []db.ListAttributesRow{}
+	}
+	parent := ""
+	for _, c := range categories {
+		if c.ID == category.ParentID {
+			parent = c.Slug
+		}
+	}
+	err = views.EditCategoryPage(category, parent, categories, attributes, errMsg).Render(c, c.Writer)
+	if err != nil {
+		log.Fatalf("failed to render in /categories/:slug/edit: %v", err)
+	}
+}
+
+// bindCategoryForm binds and validates a category form together with its optional image,
+// which it saves. The message is for the form when it fails.
+func (s *Server) bindCategoryForm(c *gin.Context) (CategoryCreateEdit, string, string, bool) {
+	var categoryForm CategoryCreateEdit
+	err := c.ShouldBind(&categoryForm)
+	if err != nil {
+		slog.Warn(err.Error())
+		return categoryForm, "", "wrong fields", false
+	}
+	err = s.validate.Struct(categoryForm)
+	if err != nil {
+		formErrMsg := ""
+		for _, err := range err.(validator.ValidationErrors) {
+			curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+			formErrMsg += curr
+			slog.Warn(curr)
+		}
+		return categoryForm, "", formErrMsg, false
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return categoryForm, "", "", true
+	}
+	img, err := s.Catalog.SaveImage(file)
+	if err != nil {
+		slog.Warn(err.Error())
+		return categoryForm, "", categoryErrMsg(err), false
+	}
+	return categoryForm, img, "", true
+}
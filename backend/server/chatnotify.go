@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"agro.store/backend/db"
+	"agro.store/backend/orderstatus"
+)
+
+// chatChannel is the PostgreSQL notification channel chat events are published on.
+const chatChannel = "chat_events"
+
+// listenRetry is how long Listen waits before reconnecting after losing its connection.
+const listenRetry = 5 * time.Second
+
+// chatNotification is the payload of a chat event notification. Only IDs are sent because
+// notification payloads are limited to 8000 bytes, listeners load the message themselves.
+type chatNotification struct {
+	Type      string `json:"type"`
+	ChatID    string `json:"chat_id"`
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// Publish announces a persisted message to every instance of the app, this one included.
+// If the notification can't be sent, the message still reaches the clients of this instance.
+func (h *Hub) Publish(ctx context.Context, msg db.Message) {
+	err := h.notify(ctx, chatNotification{Type: "message", ChatID: msg.ChatID.String(), MessageID: msg.ID.String()})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't notify chat %s, delivering locally : %v", msg.ChatID.String(), err))
+		h.deliverMessage(msg)
+	}
+}
+
+// Closed tells every instance of the app that support closed a chat.
+func (h *Hub) Closed(ctx context.Context, chatID string) {
+	err := h.notify(ctx, chatNotification{Type: "closed", ChatID: chatID})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't notify chat %s, delivering locally : %v", chatID, err))
+		h.deliver(chatRoom(chatID), ChatEvent{Type: "closed"})
+	}
+}
+
+func (h *Hub) notify(ctx context.Context, n chatNotification) error {
+	if h.pool == nil {
+		return fmt.Errorf("no pool to notify through")
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return h.queries.NotifyChannel(ctx, db.NotifyChannelParams{Channel: chatChannel, Payload: string(payload)})
+}
+
+// Listen delivers the chat events and order status changes published by every instance
+// to the clients of this one until ctx is done. The LISTEN connection is taken out of the pool for good and is
+// re-established after a failure. Events published while it is down are not replayed,
+// clients see them when they reload the chat history.
+func (h *Hub) Listen(ctx context.Context) {
+	if h.pool == nil {
+		return
+	}
+	for {
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn(fmt.Sprintf("chat listener stopped, retrying in %v : %v", listenRetry, err))
+		select {
+		case <-time.After(listenRetry):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	pooled, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	for _, channel := range []string{chatChannel, orderstatus.Channel} {
+		_, err = conn.Exec(ctx, "LISTEN "+channel)
+		if err != nil {
+			return err
+		}
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		if notification.Channel == orderstatus.Channel {
+			h.handleOrderNotification(ctx, notification.Payload)
+		} else {
+			h.handleNotification(ctx, notification.Payload)
+		}
+	}
+}
+
+func (h *Hub) handleOrderNotification(ctx context.Context, payload string) {
+	var n orderstatus.Notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		slog.Warn(fmt.Sprintf("Invalid order notification %q : %v", payload, err))
+		return
+	}
+	historyID, err := StrToUUID(n.HistoryID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Invalid history id in order notification %q : %v", payload, err))
+		return
+	}
+	entry, err := h.queries.GetOrderStatusHistoryById(ctx, historyID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't load notified order status %s : %v", n.HistoryID, err))
+		return
+	}
+	h.deliver(ordersRoom(n.UserID), orderEvent(entry))
+}
+
+func (h *Hub) handleNotification(ctx context.Context, payload string) {
+	var n chatNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		slog.Warn(fmt.Sprintf("Invalid chat notification %q : %v", payload, err))
+		return
+	}
+	switch n.Type {
+	case "message":
+		messageID, err := StrToUUID(n.MessageID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Invalid message id in chat notification %q : %v", payload, err))
+			return
+		}
+		msg, err := h.queries.GetMessageById(ctx, messageID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't load notified message %s : %v", n.MessageID, err))
+			return
+		}
+		h.deliverMessage(msg)
+	case "closed":
+		h.deliver(chatRoom(n.ChatID), ChatEvent{Type: "closed"})
+	}
+}
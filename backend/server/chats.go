@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrChatClosed is returned when a message is posted to a chat support already closed.
+var ErrChatClosed = errors.New("chat is closed")
+
+// ErrInvalidMessage is returned for empty messages and messages over maxMessageLength.
+var ErrInvalidMessage = errors.New("message must be between 1 and 2000 characters")
+
+const maxMessageLength = 2000
+
+// chatService implements ChatService on the database.
+type chatService struct {
+	q db.Querier
+}
+
+func (s *chatService) Chat(ctx context.Context, id pgtype.UUID) (db.Chat, error) {
+	return s.q.GetChatById(ctx, id)
+}
+
+func (s *chatService) ChatByCreator(ctx context.Context, userID pgtype.UUID) (db.Chat, error) {
+	return s.q.GetChatByCreator(ctx, userID)
+}
+
+func (s *chatService) Messages(ctx context.Context, chatID pgtype.UUID) ([]db.Message, error) {
+	return s.q.ListAllMessagesByChatId(ctx, chatID)
+}
+
+func (s *chatService) MessagesPage(ctx context.Context, chatID pgtype.UUID, page Page) ([]db.Message, int64, error) {
+	messages, err := s.q.ListMessagesByChatIdPage(ctx, db.ListMessagesByChatIdPageParams{ChatID: chatID,
+		Limit:  page.Limit,
+		Offset: page.Offset})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list messages: %w", err)
+	}
+	total, err := s.q.CountMessagesByChatId(ctx, chatID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count messages: %w", err)
+	}
+	return messages, total, nil
+}
+
+func (s *chatService) MessagesSince(ctx context.Context, chatID pgtype.UUID, afterID pgtype.UUID) ([]db.Message, error) {
+	return s.q.ListMessagesByChatIdAfter(ctx, db.ListMessagesByChatIdAfterParams{ChatID: chatID, AfterID: afterID})
+}
+
+// Post stores a message in an open chat.
+func (s *chatService) Post(ctx context.Context, chatID pgtype.UUID, userID pgtype.UUID, content string) (db.Message, error) {
+	content = strings.TrimSpace(content)
+	if content == "" || utf8.RuneCountInString(content) > maxMessageLength {
+		return db.Message{}, ErrInvalidMessage
+	}
+	chat, err := s.q.GetChatById(ctx, chatID)
+	if err != nil {
+		return db.Message{}, err
+	}
+	if chat.Status != db.ChatStatusOpen {
+		return db.Message{}, ErrChatClosed
+	}
+	return s.q.CreateMessage(ctx, db.CreateMessageParams{ChatID: chatID, UserID: userID, Content: content})
+}
+
+// Open returns the open chat of a customer or starts one. The database allows a single open
+// chat per customer, so a request losing the race to create it returns the winner's chat.
+func (s *chatService) Open(ctx context.Context, userID pgtype.UUID) (pgtype.UUID, error) {
+	chat, err := s.q.GetChatByCreator(ctx, userID)
+	if err == nil {
+		return chat.ID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, err
+	}
+	chatID, err := s.q.CreateChat(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		chat, err = s.q.GetChatByCreator(ctx, userID)
+		return chat.ID, err
+	}
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("create chat: %w", err)
+	}
+	return chatID, nil
+}
+
+func (s *chatService) MarkRead(ctx context.Context, chatID pgtype.UUID, userID pgtype.UUID) error {
+	return s.q.MarkChatRead(ctx, db.MarkChatReadParams{ChatID: chatID, UserID: userID})
+}
+
+func (s *chatService) Close(ctx context.Context, chatID pgtype.UUID) error {
+	return s.q.UpdateChatStatus(ctx, db.UpdateChatStatusParams{ID: chatID, Status: db.ChatStatusClosed})
+}
+
+// ErrChatTaken is returned when an agent claims a chat another agent already claimed.
+var ErrChatTaken = errors.New("chat is already assigned")
+
+// ErrNotAssignee is returned when someone other than the assigned agent or an admin transfers a chat.
+var ErrNotAssignee = errors.New("only the assigned agent or an admin can transfer the chat")
+
+// ErrNotAgent is returned when a chat is transferred to a user without the support or admin role.
+var ErrNotAgent = errors.New("chats can only be assigned to support agents")
+
+// Queue returns the open chats, longest waiting first, with what the agent hasn't read yet.
+func (s *chatService) Queue(ctx context.Context, agentID pgtype.UUID) ([]db.ListChatQueueRow, error) {
+	return s.q.ListChatQueue(ctx, agentID)
+}
+
+// Claim assigns an open, unassigned chat to an agent. The check and the update
+// are a single statement, so two agents can't claim the same chat.
+func (s *chatService) Claim(ctx context.Context, chatID pgtype.UUID, agentID pgtype.UUID) error {
+	claimed, err := s.q.ClaimChat(ctx, db.ClaimChatParams{ID: chatID, AssignedTo: agentID})
+	if err != nil {
+		return err
+	}
+	if claimed == 0 {
+		return ErrChatTaken
+	}
+	return nil
+}
+
+// Transfer hands a chat over to another agent.
+func (s *chatService) Transfer(ctx context.Context, chat db.Chat, actor db.GetUserByIdRow, agentID pgtype.UUID) error {
+	if chat.Status != db.ChatStatusOpen {
+		return ErrChatClosed
+	}
+	if chat.AssignedTo != actor.ID && actor.Role != db.UserRoleAdmin {
+		return ErrNotAssignee
+	}
+	agent, err := s.q.GetUserById(ctx, agentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotAgent
+	}
+	if err != nil {
+		return err
+	}
+	if agent.Role != db.UserRoleSupport && agent.Role != db.UserRoleAdmin {
+		return ErrNotAgent
+	}
+	return s.q.AssignChat(ctx, db.AssignChatParams{ID: chat.ID, AssignedTo: agentID})
+}
+
+func (s *chatService) Agents(ctx context.Context) ([]db.ListSupportAgentsRow, error) {
+	return s.q.ListSupportAgents(ctx)
+}
+
+func (s *chatService) CannedResponses(ctx context.Context) ([]db.CannedResponse, error) {
+	return s.q.ListCannedResponses(ctx)
+}
+
+func (s *chatService) AddCannedResponse(ctx context.Context, authorID pgtype.UUID, cannedForm CannedResponseCreate) error {
+	return s.q.CreateCannedResponse(ctx, db.CreateCannedResponseParams{Title: strings.TrimSpace(cannedForm.Title),
+		Content:   strings.TrimSpace(cannedForm.Content),
+		CreatedBy: authorID})
+}
+
+func (s *chatService) DeleteCannedResponse(ctx context.Context, id pgtype.UUID) error {
+	return s.q.DeleteCannedResponse(ctx, id)
+}
@@ -1,60 +1,381 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"golang.org/x/net/websocket"
 	"io"
 	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/net/websocket"
 )
 
 /**
 HOW TO USE
-server: NewServer()
-http.Handle("/ws", websocket.Handler(server.handleWS))
+hub := NewHub(pool, services.Chat)
+go hub.Run()
+go hub.Listen(ctx)
+defer hub.Shutdown()
+router.GET("/chats/:id/ws", ..., func(c *gin.Context) {
+	hub.Handler(chat, user).ServeHTTP(c.Writer, c.Request)
+})
 */
 
-type Server struct {
-	conns map[*websocket.Conn]string
+const (
+	// writeWait is the time allowed to write a single frame to a client.
+	writeWait = 10 * time.Second
+	// pongWait is the time allowed between two frames from a client, pongs included.
+	pongWait = 60 * time.Second
+	// pingPeriod must be shorter than pongWait so a healthy client always answers in time.
+	pingPeriod = pongWait * 9 / 10
+	// sendQueueSize is how many frames may wait for a client before it is considered too slow and evicted.
+	sendQueueSize = 32
+)
+
+// ChatMessage is a persisted chat message as it is sent to the browser.
+type ChatMessage struct {
+	ID        string `json:"id"`
+	ChatID    string `json:"chat_id"`
+	UserID    string `json:"user_id"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// OrderEvent is a status change of one of the user's orders as it is sent to the browser.
+type OrderEvent struct {
+	ID        string `json:"id"`
+	OrderID   string `json:"order_id"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ChatEvent is a single frame sent over the chat websocket or the event streams.
+// Type is "message", "closed" when support closes the chat, "order" for order status changes,
+// "ping" for keepalives, or "error" for the sender only.
+type ChatEvent struct {
+	Type    string       `json:"type"`
+	Message *ChatMessage `json:"message,omitempty"`
+	Order   *OrderEvent  `json:"order,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// ClientFrame is a single frame received from the browser, either a "message" or a "pong".
+// x/net/websocket answers protocol pings internally and never surfaces pongs,
+// so keepalives are done with application frames instead.
+type ClientFrame struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// chatClient is one connection listening to a room, either a chat websocket or an event stream.
+type chatClient struct {
+	hub  *Hub
+	ws   *websocket.Conn
+	room string
+	chat db.Chat
+	user db.GetUserByIdRow
+	// send queues the events for the client. Only the hub goroutine sends on it, and it
+	// closes it when the client leaves.
+	send chan ChatEvent
+}
+
+// roomEvent is an event for every client of a room.
+type roomEvent struct {
+	room  string
+	event ChatEvent
+}
+
+// clientEvent is an event for a single client, like the error of a message it sent.
+type clientEvent struct {
+	client *chatClient
+	event  ChatEvent
+}
+
+// chatRoom is the room of the participants of a chat.
+func chatRoom(chatID string) string {
+	return "chat:" + chatID
+}
+
+// ordersRoom is the room receiving the order status changes of a user.
+func ordersRoom(userID string) string {
+	return "orders:" + userID
+}
+
+// Hub keeps the clients of every chat, grouped in rooms by chat ID, so a message only
+// reaches the participants of its own chat. Order status changes use one room per user.
+// The rooms are owned by the Run goroutine, everything else talks to it through channels.
+// Events are published through PostgreSQL so every instance of the app delivers them
+// to its own clients, see chatnotify.go.
+type Hub struct {
+	pool       *pgxpool.Pool
+	chats      ChatService
+	queries    *db.Queries
+	rooms      map[string]map[*chatClient]bool
+	register   chan *chatClient
+	unregister chan *chatClient
+	broadcast  chan roomEvent
+	direct     chan clientEvent
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+// NewHub creates a hub that fans events out through pool and stores the messages
+// of websocket clients with chats. With a nil pool events are only delivered to the
+// clients of this process.
+func NewHub(pool *pgxpool.Pool, chats ChatService) *Hub {
+	h := &Hub{
+		pool:       pool,
+		chats:      chats,
+		rooms:      make(map[string]map[*chatClient]bool),
+		register:   make(chan *chatClient),
+		unregister: make(chan *chatClient),
+		broadcast:  make(chan roomEvent, 256),
+		direct:     make(chan clientEvent),
+		done:       make(chan struct{}),
+	}
+	if pool != nil {
+		h.queries = db.New(pool)
+	}
+	return h
+}
+
+// Run serves register, unregister, broadcast and direct requests until Shutdown is called.
+func (h *Hub) Run() {
+	for {
+		select {
+		case c := <-h.register:
+			if h.rooms[c.room] == nil {
+				h.rooms[c.room] = make(map[*chatClient]bool)
+			}
+			h.rooms[c.room][c] = true
+		case c := <-h.unregister:
+			h.remove(c)
+		case e := <-h.broadcast:
+			for c := range h.rooms[e.room] {
+				select {
+				case c.send <- e.event:
+				default:
+					// The client can't keep up, drop it instead of blocking the whole hub.
+					slog.Warn(fmt.Sprintf("evicting slow client %s from %s", c.user.ID.String(), e.room))
+					h.remove(c)
+				}
+			}
+		case d := <-h.direct:
+			if !h.rooms[d.client.room][d.client] {
+				// The client was evicted, its queue is closed already.
+				continue
+			}
+			select {
+			case d.client.send <- d.event:
+			default:
+			}
+		case <-h.done:
+			for _, room := range h.rooms {
+				for c := range room {
+					h.remove(c)
+				}
+			}
+			return
+		}
+	}
+}
+
+// remove drops a client from its room and closes its send queue, which ends its write pump.
+func (h *Hub) remove(c *chatClient) {
+	room, ok := h.rooms[c.room]
+	if !ok || !room[c] {
+		return
+	}
+	delete(room, c)
+	close(c.send)
+	if len(room) == 0 {
+		delete(h.rooms, c.room)
+	}
+}
+
+// Shutdown disconnects every client and stops the hub. It is safe to call more than once.
+func (h *Hub) Shutdown() {
+	h.stopOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+// Subscribe registers a client without a websocket, used by the event streams, in a room.
+// The client's send queue is closed when it is evicted or the hub shuts down.
+func (h *Hub) Subscribe(room string, chat db.Chat, user db.GetUserByIdRow) (*chatClient, bool) {
+	c := &chatClient{hub: h, room: room, chat: chat, user: user, send: make(chan ChatEvent, sendQueueSize)}
+	select {
+	case h.register <- c:
+		return c, true
+	case <-h.done:
+		return nil, false
+	}
 }
 
-func NewServer() *Server {
-	return &Server{
-		conns: make(map[*websocket.Conn]string),
+// Unsubscribe removes a client from its room.
+func (h *Hub) Unsubscribe(c *chatClient) {
+	select {
+	case h.unregister <- c:
+	case <-h.done:
 	}
 }
-func (s *Server) handleWS(ws *websocket.Conn) {
-	ctx := ws.Request().Context()
-	uid, ok := ctx.Value("userID").(string)
-	if !ok {
-		slog.Warn(fmt.Sprintf("No userID found in context: %v", ws.RemoteAddr()))
+
+// Handler returns the websocket handler of a user taking part in a chat. The session
+// cookie goes along with any page opening the socket, so only pages of this site may.
+func (h *Hub) Handler(chat db.Chat, user db.GetUserByIdRow) websocket.Server {
+	return websocket.Server{Handshake: sameOrigin, Handler: func(ws *websocket.Conn) {
+		c, ok := h.Subscribe(chatRoom(chat.ID.String()), chat, user)
+		if !ok {
+			return
+		}
+		c.ws = ws
+		slog.Info(fmt.Sprintf("new incoming connection from client %s to chat %s", user.ID.String(), chat.ID.String()))
+
+		go c.writePump()
+		c.readPump()
+	}}
+}
+
+// sameOrigin rejects a websocket handshake whose Origin isn't the host it was sent to.
+func sameOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.Host != req.Host {
+		return fmt.Errorf("websocket from foreign origin %v", origin)
 	}
-	slog.Info(fmt.Sprintf("new incoming connection from client: %v", ctx.Value("userID")))
-	s.conns[ws] = uid
-	s.readLoop(ws)
+	config.Origin = origin
+	return nil
 }
 
-func (s *Server) readLoop(ws *websocket.Conn) {
-	buf := make([]byte, 1024)
+// messageEvent converts a persisted message to the event sent to the browser.
+func messageEvent(msg db.Message) ChatEvent {
+	return ChatEvent{Type: "message", Message: &ChatMessage{ID: msg.ID.String(),
+		ChatID:    msg.ChatID.String(),
+		UserID:    msg.UserID.String(),
+		Content:   msg.Content,
+		CreatedAt: msg.CreatedAt.Time.Format(time.RFC3339Nano)}}
+}
+
+// orderEvent converts an order status history entry to the event sent to the browser.
+func orderEvent(entry db.OrderStatusHistory) ChatEvent {
+	return ChatEvent{Type: "order", Order: &OrderEvent{ID: entry.ID.String(),
+		OrderID:   entry.OrderID.String(),
+		Status:    string(entry.ToStatus),
+		CreatedAt: entry.CreatedAt.Time.Format(time.RFC3339Nano)}}
+}
+
+// deliverMessage sends a persisted message to the clients of its chat connected to this process.
+func (h *Hub) deliverMessage(msg db.Message) {
+	h.deliver(chatRoom(msg.ChatID.String()), messageEvent(msg))
+}
+
+// deliver sends an event to the clients of a room connected to this process.
+func (h *Hub) deliver(room string, event ChatEvent) {
+	select {
+	case h.broadcast <- roomEvent{room: room, event: event}:
+	case <-h.done:
+	}
+}
+
+// markRead records that the client's user has seen its chat up to now. Clients of
+// other rooms, like the order streams, have no chat and are skipped.
+func (c *chatClient) markRead(ctx context.Context) {
+	if c.hub.chats == nil || !c.chat.ID.Valid {
+		return
+	}
+	if err := c.hub.chats.MarkRead(ctx, c.chat.ID, c.user.ID); err != nil {
+		slog.Warn(fmt.Sprintf("Can't mark chat %s read for %s : %v", c.chat.ID.String(), c.user.ID.String(), err))
+	}
+}
+
+// readPump persists every message the client sends and broadcasts it to the chat.
+// A client that sends nothing, not even a pong, for pongWait is disconnected.
+func (c *chatClient) readPump() {
+	defer func() {
+		c.hub.Unsubscribe(c)
+		c.ws.Close()
+	}()
+
+	ctx := c.ws.Request().Context()
 	for {
-		n, err := ws.Read(buf)
+		c.ws.SetReadDeadline(time.Now().Add(pongWait))
+		var frame ClientFrame
+		err := websocket.JSON.Receive(c.ws, &frame)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				slog.Warn(fmt.Sprintf("read error: %v", err))
+			}
+			return
+		}
+		if frame.Type != "message" {
+			continue
+		}
+
+		msg, err := c.hub.chats.Post(ctx, c.chat.ID, c.user.ID, frame.Content)
 		if err != nil {
-			if err == io.EOF {
-				break
+			if !errors.Is(err, ErrChatClosed) && !errors.Is(err, ErrInvalidMessage) {
+				slog.Warn(fmt.Sprintf("Can't post message to chat %s : %v", c.chat.ID.String(), err))
 			}
-			slog.Warn(fmt.Sprintf("read error: %v", err))
+			c.reply(ChatEvent{Type: "error", Error: err.Error()})
 			continue
 		}
-		msg := buf[:n]
-		s.broadcast(msg)
+		c.markRead(ctx)
+		c.hub.Publish(ctx, msg)
 	}
 }
 
-func (s *Server) broadcast(b []byte) {
-	for ws := range s.conns {
-		go func(ws *websocket.Conn) {
-			if _, err := ws.Write(b); err != nil {
-				fmt.Println("write error:", err)
+// reply queues an event for this client only, dropping it if the queue is full or the
+// client was evicted. It goes through the hub, which owns the queue and closes it.
+func (c *chatClient) reply(event ChatEvent) {
+	select {
+	case c.hub.direct <- clientEvent{client: c, event: event}:
+	case <-c.hub.done:
+	}
+}
+
+// writePump is the only writer of the connection. It sends the queued frames and
+// a ping every pingPeriod, and closes the connection once the hub closes the queue.
+// A message that reached the open page counts as read.
+func (c *chatClient) writePump() {
+	ping, _ := json.Marshal(ChatEvent{Type: "ping"})
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.ws.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-c.send:
+			if !ok {
+				return
 			}
-		}(ws)
+			frame, err := json.Marshal(event)
+			if err != nil {
+				slog.Warn(fmt.Sprintf("marshal error: %v", err))
+				continue
+			}
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := websocket.Message.Send(c.ws, string(frame)); err != nil {
+				slog.Warn(fmt.Sprintf("write error: %v", err))
+				return
+			}
+			if event.Type == "message" {
+				c.markRead(c.ws.Request().Context())
+			}
+		case <-ticker.C:
+			c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := websocket.Message.Send(c.ws, string(ping)); err != nil {
+				return
+			}
+		}
 	}
 }
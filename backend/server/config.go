@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config is everything a Server depends on from its environment. Nothing is read from
+// package state, so servers with different configs can run side by side in one process.
+type Config struct {
+	// Addr is the address the HTTP server listens on.
+	Addr string
+	// DatabaseURL is the PostgreSQL connection string, the sessions are stored there too.
+	DatabaseURL string
+	// SessionName is the name of the session cookie.
+	SessionName string
+	// SessionSecret signs the session cookie.
+	SessionSecret string
+	// PublicDir holds the static assets served under /public.
+	PublicDir string
+	// UploadDir is where product images are saved, they are served under /upload.
+	UploadDir string
+	// APITokenTTL is how long a token issued by POST /api/v1/auth/token stays valid.
+	APITokenTTL time.Duration
+	// DB configures the connection pool shared by the sessions, the services and the hub.
+	DB PoolConfig
+}
+
+// PoolConfig sizes the database connection pool. Zero values keep the pgxpool defaults.
+type PoolConfig struct {
+	MinConns int32
+	MaxConns int32
+	// HealthCheckPeriod is how often idle connections are checked and replaced when broken.
+	HealthCheckPeriod time.Duration
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	// StatementTimeout aborts statements running longer, zero disables it.
+	StatementTimeout time.Duration
+}
+
+// DefaultConfig returns the config of a local development server.
+func DefaultConfig() Config {
+	return Config{Addr: ":8080",
+		SessionName:   DefaultSessionName,
+		SessionSecret: DefaultSecretKey,
+		PublicDir:     "./public",
+		UploadDir:     "./upload",
+		APITokenTTL:   30 * 24 * time.Hour,
+		DB: PoolConfig{MinConns: 2,
+			MaxConns:          10,
+			HealthCheckPeriod: time.Minute,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			StatementTimeout:  30 * time.Second}}
+}
+
+// ConfigFromEnv returns DefaultConfig overridden by the environment: DB_URI, HTTP_ADDR,
+// SESSION_SECRET, UPLOAD_DIR and the pool settings DB_MIN_CONNS, DB_MAX_CONNS,
+// DB_HEALTH_CHECK_PERIOD, DB_MAX_CONN_LIFETIME, DB_MAX_CONN_IDLE_TIME and
+// DB_STATEMENT_TIMEOUT. Durations are written like "30s" or "5m".
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	cfg.DatabaseURL = os.Getenv("DB_URI")
+	if addr := os.Getenv("HTTP_ADDR"); addr != "" {
+		cfg.Addr = addr
+	}
+	if secret := os.Getenv("SESSION_SECRET"); secret != "" {
+		cfg.SessionSecret = secret
+	}
+	if dir := os.Getenv("UPLOAD_DIR"); dir != "" {
+		cfg.UploadDir = dir
+	}
+
+	var err error
+	if cfg.DB.MinConns, err = envInt32("DB_MIN_CONNS", cfg.DB.MinConns); err != nil {
+		return cfg, err
+	}
+	if cfg.DB.MaxConns, err = envInt32("DB_MAX_CONNS", cfg.DB.MaxConns); err != nil {
+		return cfg, err
+	}
+	if cfg.DB.HealthCheckPeriod, err = envDuration("DB_HEALTH_CHECK_PERIOD", cfg.DB.HealthCheckPeriod); err != nil {
+		return cfg, err
+	}
+	if cfg.DB.MaxConnLifetime, err = envDuration("DB_MAX_CONN_LIFETIME", cfg.DB.MaxConnLifetime); err != nil {
+		return cfg, err
+	}
+	if cfg.DB.MaxConnIdleTime, err = envDuration("DB_MAX_CONN_IDLE_TIME", cfg.DB.MaxConnIdleTime); err != nil {
+		return cfg, err
+	}
+	if cfg.DB.StatementTimeout, err = envDuration("DB_STATEMENT_TIMEOUT", cfg.DB.StatementTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.DB.MinConns > cfg.DB.MaxConns {
+		return cfg, fmt.Errorf("DB_MIN_CONNS %d is more than DB_MAX_CONNS %d", cfg.DB.MinConns, cfg.DB.MaxConns)
+	}
+	return cfg, nil
+}
+
+// envInt32 returns the non-negative number in the environment variable key, or def when it's unset.
+func envInt32(key string, def int32) (int32, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number, got %q", key, raw)
+	}
+	return int32(n), nil
+}
+
+// envDuration returns the non-negative duration in the environment variable key, or def when it's unset.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a duration like 30s, got %q", key, raw)
+	}
+	return d, nil
+}
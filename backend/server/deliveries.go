@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"agro.store/backend/db"
+	"agro.store/backend/orderstatus"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *orderService) Delivery(ctx context.Context, orderID pgtype.UUID) (db.Delivery, error) {
+	return s.q.GetDeliveryByOrderId(ctx, orderID)
+}
+
+func (s *orderService) DeliveryEvents(ctx context.Context, deliveryID pgtype.UUID) ([]db.DeliveryEvent, error) {
+	return s.q.ListDeliveryEventsByDeliveryId(ctx, deliveryID)
+}
+
+// Ship creates the shipment of an order in its own transaction.
+func (s *orderService) Ship(ctx context.Context, orderID pgtype.UUID, actor db.GetUserByIdRow, deliveryForm DeliveryCreate) (db.Delivery, error) {
+	estimatedDelivery := pgtype.Timestamptz{}
+	if deliveryForm.EstimatedDelivery != "" {
+		estimated, err := time.Parse(time.DateOnly, deliveryForm.EstimatedDelivery)
+		if err != nil {
+			return db.Delivery{}, err
+		}
+		estimatedDelivery = pgtype.Timestamptz{Time: estimated, Valid: true}
+	}
+
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	delivery, err := orderstatus.Ship(ctx, db.New(tx), orderID, actor.ID, actor.Role, deliveryForm.TrackingNumber, estimatedDelivery)
+	if err != nil {
+		return db.Delivery{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.Delivery{}, err
+	}
+	return delivery, nil
+}
+
+// AdvanceDelivery moves the shipment of an order to a new status in its own transaction.
+func (s *orderService) AdvanceDelivery(ctx context.Context, deliveryID pgtype.UUID, actor db.GetUserByIdRow, to db.DeliveryStatus, note string) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = orderstatus.AdvanceDelivery(ctx, db.New(tx), deliveryID, actor.ID, actor.Role, to, note)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// streamKeepalive is how often an idle event stream gets a comment so proxies don't drop it.
+const streamKeepalive = 30 * time.Second
+
+// eventID is the Server-Sent Events id of an event, used by the browser as Last-Event-ID on reconnect.
+func eventID(event ChatEvent) string {
+	switch {
+	case event.Message != nil:
+		return event.Message.ID
+	case event.Order != nil:
+		return event.Order.ID
+	}
+	return ""
+}
+
+// resumeID is the last event a stream client has seen. A reconnecting browser sends it as
+// Last-Event-ID, a page opening its first stream passes the last one it rendered as ?after=.
+func resumeID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("after")
+}
+
+// writeEvent writes a single Server-Sent Event with the JSON of the message or order it carries.
+func writeEvent(c *gin.Context, event ChatEvent) error {
+	var data any = event
+	switch {
+	case event.Message != nil:
+		data = event.Message
+	case event.Order != nil:
+		data = event.Order
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return sse.Encode(c.Writer, sse.Event{Id: eventID(event), Event: event.Type, Data: string(payload)})
+}
+
+// streamEvents sends the backlog of a subscribed client and then its live events as
+// Server-Sent Events until the browser goes away or the hub drops the client.
+// The client was subscribed before the backlog was loaded, so live events already
+// sent in the backlog are skipped. Chat messages that reached the browser count as read.
+func streamEvents(c *gin.Context, client *chatClient, backlog []ChatEvent) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	sent := make(map[string]bool, len(backlog))
+	read := false
+	for _, event := range backlog {
+		if err := writeEvent(c, event); err != nil {
+			slog.Warn(fmt.Sprintf("Can't write event to %s : %v", client.room, err))
+			return
+		}
+		sent[eventID(event)] = true
+		read = read || event.Type == "message"
+	}
+	c.Writer.Flush()
+	if read {
+		client.markRead(c.Request.Context())
+	}
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-client.send:
+			if !ok {
+				return false
+			}
+			if id := eventID(event); id != "" && sent[id] {
+				return true
+			}
+			if err := writeEvent(c, event); err != nil {
+				slog.Warn(fmt.Sprintf("Can't write event to %s : %v", client.room, err))
+				return false
+			}
+			if event.Type == "message" {
+				client.markRead(c.Request.Context())
+			}
+			return true
+		case <-keepalive.C:
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
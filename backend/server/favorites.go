@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"agro.store/backend/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s *catalogService) FavoriteIDs(ctx context.Context, userID pgtype.UUID) (map[string]bool, error) {
+	ids, err := s.q.ListFavoriteProductIdsByUserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	favorites := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		favorites[id.String()] = true
+	}
+	return favorites, nil
+}
+
+func (s *catalogService) Favorites(ctx context.Context, userID pgtype.UUID) ([]db.ListAllProductsRow, error) {
+	favorites, err := s.q.ListFavoriteProductsByUserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return favoriteProductRows(favorites), nil
+}
+
+// ToggleFavorite saves a product for a user or removes it if it was already saved.
+func (s *catalogService) ToggleFavorite(ctx context.Context, userID pgtype.UUID, productID pgtype.UUID) error {
+	favorites, err := s.FavoriteIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if favorites[productID.String()] {
+		return s.RemoveFavorite(ctx, userID, productID)
+	}
+	return s.q.AddFavorite(ctx, db.AddFavoriteParams{UserID: userID, ProductID: productID})
+}
+
+func (s *catalogService) RemoveFavorite(ctx context.Context, userID pgtype.UUID, productID pgtype.UUID) error {
+	return s.q.DeleteFavorite(ctx, db.DeleteFavoriteParams{UserID: userID, ProductID: productID})
+}
+
+// moveFavoriteToCart puts one unit of a saved product in the session shopping list
+// and removes it from the favorites.
+func (s *Server) moveFavoriteToCart(c *gin.Context, userID pgtype.UUID, productID pgtype.UUID) error {
+	_, err := s.Cart.CheckStock(c, productID, 1)
+	if err != nil {
+		return err
+	}
+
+	session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
+	if err != nil {
+		return err
+	}
+	shoppingList := getShoppingList(session)
+	session.Values["shoppingList"] = append(shoppingList, CartItem{ID: productID.String(), Quantity: 1})
+	if err := s.sessions.Save(c.Request, c.Writer, session); err != nil {
+		return err
+	}
+	return s.Catalog.RemoveFavorite(c, userID, productID)
+}
+
+// localRedirect returns next if it is a path on this site, otherwise fallback. Browsers
+// read a backslash as a slash, so "/\evil.com" would leave the site too.
+func localRedirect(next string, fallback string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.Contains(next, "\\") {
+		return fallback
+	}
+	if strings.IndexFunc(next, unicode.IsControl) >= 0 {
+		return fallback
+	}
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return fallback
+	}
+	return next
+}
+
+// favoriteProductRows converts saved products to the rows the product cards are rendered from.
+func favoriteProductRows(favorites []db.ListFavoriteProductsByUserIdRow) []db.ListAllProductsRow {
+	products := make([]db.ListAllProductsRow, 0, len(favorites))
+	for _, p := range favorites {
+		products = append(products, db.ListAllProductsRow{ID: p.ID,
+			Name:        p.Name,
+			Price:       p.Price,
+			Discount:    p.Discount,
+			Description: p.Description,
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+			Img:         p.Img,
+			Stock:       p.Stock,
+			Type:        p.Type,
+			Category:    p.Category,
+			AvgRating:   p.AvgRating,
+			ReviewCount: p.ReviewCount})
+	}
+	return products
+}
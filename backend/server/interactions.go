@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+
+	"agro.store/backend/db"
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrCannotReview is returned when a user without a completed order of a product,
+// or who already reviewed it, tries to review it.
+var ErrCannotReview = errors.New("only customers with a completed order of this product can review it, once")
+
+// ErrNoSuchQuestion is returned when answering a question that doesn't belong to the product.
+var ErrNoSuchQuestion = errors.New("no such question")
+
+// sessionUser returns the logged in user on routes that don't require authentication.
+// The second result is false for anonymous visitors.
+func (s *Server) sessionUser(c *gin.Context) (db.GetUserByIdRow, bool) {
+	session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
+	if err != nil || session.IsNew {
+		return db.GetUserByIdRow{}, false
+	}
+	rawID, ok := session.Values["userID"].(string)
+	if !ok {
+		return db.GetUserByIdRow{}, false
+	}
+	userID, err := StrToUUID(rawID)
+	if err != nil {
+		return db.GetUserByIdRow{}, false
+	}
+	u, err := s.Users.User(c, userID)
+	if err != nil {
+		return db.GetUserByIdRow{}, false
+	}
+	return u, true
+}
+
+// CanReview reports whether a user may review a product: they must have a completed order
+// containing it and must not have reviewed it yet.
+func (s *catalogService) CanReview(ctx context.Context, user db.GetUserByIdRow, productID pgtype.UUID, reviews []db.ListProductInteractionsByProductIdRow) bool {
+	for _, r := range reviews {
+		if r.UserID == user.ID {
+			return false
+		}
+	}
+	bought, err := s.q.HasCompletedOrderWithProduct(ctx, db.HasCompletedOrderWithProductParams{UserID: user.ID, ProductID: productID})
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't check completed orders of %s : %v", user.ID.String(), err))
+		return false
+	}
+	return bought
+}
+
+// splitInteractions separates the reviews of a product from its questions.
+func splitInteractions(interactions []db.ListProductInteractionsByProductIdRow) ([]db.ListProductInteractionsByProductIdRow, []db.ListProductInteractionsByProductIdRow) {
+	var reviews, questions []db.ListProductInteractionsByProductIdRow
+	for _, i := range interactions {
+		if i.Type == db.ProdInteractionTypeReview {
+			reviews = append(reviews, i)
+		} else {
+			questions = append(questions, i)
+		}
+	}
+	return reviews, questions
+}
+
+func (s *catalogService) Interactions(ctx context.Context, productID pgtype.UUID) ([]db.ListProductInteractionsByProductIdRow, []db.ListProductInteractionsByProductIdRow, error) {
+	interactions, err := s.q.ListProductInteractionsByProductId(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reviews, questions := splitInteractions(interactions)
+	return reviews, questions, nil
+}
+
+// AddReview adds a star rating review by a customer who received the product.
+func (s *catalogService) AddReview(ctx context.Context, user db.GetUserByIdRow, productID pgtype.UUID, reviewForm ReviewCreate) error {
+	reviews, _, err := s.Interactions(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("list interactions: %w", err)
+	}
+	if !s.CanReview(ctx, user, productID, reviews) {
+		return ErrCannotReview
+	}
+	return s.q.CreateProductInteraction(ctx, db.CreateProductInteractionParams{ProductID: productID,
+		UserID:  user.ID,
+		Type:    db.ProdInteractionTypeReview,
+		Content: reviewForm.Content,
+		Rating:  pgtype.Int2{Int16: int16(reviewForm.Rating), Valid: true}})
+}
+
+func (s *catalogService) AskQuestion(ctx context.Context, userID pgtype.UUID, productID pgtype.UUID, questionForm QuestionCreate) error {
+	return s.q.CreateProductInteraction(ctx, db.CreateProductInteractionParams{ProductID: productID,
+		UserID:  userID,
+		Type:    db.ProdInteractionTypeQuestion,
+		Content: questionForm.Content})
+}
+
+func (s *catalogService) AnswerQuestion(ctx context.Context, agentID pgtype.UUID, productID pgtype.UUID, questionID pgtype.UUID, answerForm QuestionAnswer) error {
+	question, err := s.q.GetProductInteractionById(ctx, questionID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoSuchQuestion, err)
+	}
+	if question.ProductID != productID || question.Type != db.ProdInteractionTypeQuestion {
+		return ErrNoSuchQuestion
+	}
+	return s.q.AnswerProductQuestion(ctx, db.AnswerProductQuestionParams{ID: questionID,
+		Response:   pgtype.Text{String: answerForm.Response, Valid: true},
+		AnsweredBy: agentID})
+}
+
+// renderProductPage renders a product with its reviews and questions, together with the
+// forms the viewing user may use.
+func (s *Server) renderProductPage(c *gin.Context, product db.GetProductByIdRow, errMsg string) {
+	reviews, questions, err := s.Catalog.Interactions(c, product.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list interactions of product %s : %v", product.ID.String(), err))
+	}
+
+	data := views.ProductPageData{Product: product,
+		Reviews:   reviews,
+		Questions: questions,
+		ErrMsg:    errMsg}
+	crumbs, err := s.Catalog.Breadcrumbs(c, product.Category)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't get breadcrumbs in /products/:id : %v", err))
+	}
+	data.Breadcrumbs = crumbs
+	attributes, err := s.Catalog.ProductAttributes(c, product.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list attributes of product %s : %v", product.ID.String(), err))
+	}
+	data.Attributes = attributes
+	if viewer, ok := s.sessionUser(c); ok {
+		data.Viewer = viewer
+		data.LoggedIn = true
+		data.CanReview = s.Catalog.CanReview(c, viewer, product.ID, reviews)
+		favorites, err := s.Catalog.FavoriteIDs(c, viewer.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list favorites of %s : %v", viewer.ID.String(), err))
+		}
+		data.Favorite = favorites[product.ID.String()]
+	}
+
+	err = views.ProductPage(data).Render(c, c.Writer)
+	if err != nil {
+		log.Fatalf("failed to render in /products/view: %v", err)
+	}
+}
@@ -7,9 +7,9 @@ import (
 	"net/http"
 )
 
-func notAuthMiddleware() gin.HandlerFunc {
+func (s *Server) notAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err == nil && !session.IsNew {
 			DefaultMiddlewareLog("From notAuthMiddleware()", "Authorized", c, err)
 			c.Redirect(http.StatusFound, "/")
@@ -29,9 +29,9 @@ func notAuthMiddleware() gin.HandlerFunc {
 }
 
 // authMiddleware checks for a valid session stored in our PostgreSQL using the modernized pgstore.
-func authMiddleware() gin.HandlerFunc {
+func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil || session.IsNew {
 			DefaultMiddlewareLog("From authMiddleware()", "Session error", c, err)
 			c.Redirect(http.StatusFound, "/login")
@@ -52,7 +52,7 @@ func authMiddleware() gin.HandlerFunc {
 }
 
 // adminMiddleware is a stub for routes restricted to administrators.
-func adminMiddleware() gin.HandlerFunc {
+func (s *Server) adminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := StrToUUID(c.GetString("userID"))
 		if err != nil {
@@ -62,7 +62,7 @@ func adminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		u, err := dbQueries.GetUserById(c, userID)
+		u, err := s.Users.User(c, userID)
 		if err != nil {
 			DefaultMiddlewareLog("From adminMiddleware()", "Can't query by userID", c, err)
 			c.Redirect(http.StatusFound, "/")
@@ -80,8 +80,38 @@ func adminMiddleware() gin.HandlerFunc {
 	}
 }
 
-// orderOwnerOrAdminMiddleware restricts order routes to the order owner or admins.
-func orderOwnerOrAdminMiddleware() gin.HandlerFunc {
+// supportMiddleware restricts routes to support staff and administrators.
+func (s *Server) supportMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			DefaultMiddlewareLog("From supportMiddleware()", "userID is not UUID", c, err)
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+
+		u, err := s.Users.User(c, userID)
+		if err != nil {
+			DefaultMiddlewareLog("From supportMiddleware()", "Can't query by userID", c, err)
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+
+		if u.Role != "support" && u.Role != "admin" {
+			slog.Info("From supportMiddleware(): user is not support or admin")
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// orderOwnerOrAdminMiddleware restricts order routes to the order owner, admins and support.
+// The order and the requesting user are stored in the context as "order" and "user".
+func (s *Server) orderOwnerOrAdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := StrToUUID(c.GetString("userID"))
 		if err != nil {
@@ -91,7 +121,7 @@ func orderOwnerOrAdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		u, err := dbQueries.GetUserById(c, userID)
+		u, err := s.Users.User(c, userID)
 		if err != nil {
 			DefaultMiddlewareLog("From orderOwnerOrAdminMiddleware()", "Can't get user by userID", c, err)
 			c.Redirect(http.StatusFound, "/")
@@ -107,7 +137,7 @@ func orderOwnerOrAdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		o, err := dbQueries.GetOrderById(c, orderId)
+		o, err := s.Orders.Order(c, orderId)
 		if err != nil {
 			DefaultMiddlewareLog("From orderOwnerOrAdminMiddleware()", "Error querying order by id", c, err)
 			c.Redirect(http.StatusFound, "/")
@@ -115,20 +145,70 @@ func orderOwnerOrAdminMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if u.ID != o.UserID {
+		if u.ID != o.UserID && u.Role != "admin" && u.Role != "support" {
 			slog.Info("From orderOwnerOrAdminMiddleware(): User is not Order User")
 			c.Redirect(http.StatusFound, "/")
 			c.Abort()
 			return
 		}
+		c.Set("order", o)
+		c.Set("user", u)
+		c.Next()
+	}
+}
+
+// chatParticipantMiddleware restricts chat routes to the customer who started the chat, support and admins.
+// The chat and the requesting user are stored in the context as "chat" and "user".
+func (s *Server) chatParticipantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			DefaultMiddlewareLog("From chatParticipantMiddleware()", "userID is not UUID", c, err)
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+
+		u, err := s.Users.User(c, userID)
+		if err != nil {
+			DefaultMiddlewareLog("From chatParticipantMiddleware()", "Can't get user by userID", c, err)
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+
+		chatId, err := StrToUUID(c.Param("id"))
+		if err != nil {
+			DefaultMiddlewareLog("From chatParticipantMiddleware()", "Param id is not UUID", c, err)
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+
+		chat, err := s.Chat.Chat(c, chatId)
+		if err != nil {
+			DefaultMiddlewareLog("From chatParticipantMiddleware()", "Error querying chat by id", c, err)
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+
+		if u.ID != chat.CreatedBy && u.Role != "admin" && u.Role != "support" {
+			slog.Info("From chatParticipantMiddleware(): User is not a chat participant")
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+		c.Set("chat", chat)
+		c.Set("user", u)
 		c.Next()
 	}
 }
 
 // csrfMiddleware is the middleware function for CSRF protection
-func csrfMiddleware() gin.HandlerFunc {
+func (s *Server) csrfMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
 			c.AbortWithStatus(http.StatusInternalServerError)
 			return
@@ -2,8 +2,11 @@ package server
 
 import (
 	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgtype"
 	"log/slog"
+	"reflect"
 	"regexp"
+	"strings"
 )
 
 type UserRegister struct {
@@ -27,11 +30,149 @@ type ProductCreateEdit struct {
 	Name        string `json:"name" form:"name" validate:"required,min=2,max=100"`
 	Price       string `json:"price" form:"price" validate:"required,numeric,gt=0"`
 	Description string `json:"description" form:"description" validate:"required,max=500"`
-	Type        string `json:"type" form:"type" validate:"required,oneof=seeds equipment soil"`
-	Category    string `json:"category" form:"category" validate:"required,min=2,max=50"`
+	// Category is the slug of the category, which decides the type of the product too.
+	Category string `json:"category" form:"category" validate:"required,max=60,slug"`
+	// Attributes are the spec values by attribute slug, a range written as "min..max".
+	// Forms submit them as attr[slug], ranges as attr_min[slug] and attr_max[slug].
+	Attributes map[string]string `json:"attributes" form:"-" validate:"max=50,dive,keys,max=60,slug,endkeys,max=200"`
+}
+
+// CategoryCreateEdit is the admin form of a category. Parent is the slug of the parent
+// category, empty for a root category.
+type CategoryCreateEdit struct {
+	Name        string `form:"name" validate:"required,min=2,max=100"`
+	Slug        string `form:"slug" validate:"required,max=60,slug"`
+	Parent      string `form:"parent" validate:"omitempty,max=60,slug"`
+	Description string `form:"description" validate:"max=2000"`
+	Position    int32  `form:"position" validate:"min=0,max=10000"`
+}
+
+// AttributeCreateEdit is the admin form of a product attribute. Options lists the values
+// of an enum attribute, one per line. The slug and the kind of an attribute can't change.
+type AttributeCreateEdit struct {
+	Name     string `form:"name" validate:"required,min=2,max=100"`
+	Slug     string `form:"slug" validate:"required,max=60,slug"`
+	Kind     string `form:"kind" validate:"required,oneof=text number enum range"`
+	Unit     string `form:"unit" validate:"max=20"`
+	Options  string `form:"options" validate:"max=2000"`
+	Position int32  `form:"position" validate:"min=0,max=10000"`
+}
+
+// CatalogFilter is the query string of the catalog: every filter is optional and they combine.
+type CatalogFilter struct {
+	Types      []string `form:"type" validate:"max=10,dive,max=50"`
+	Categories []string `form:"category" validate:"max=50,dive,max=50"`
+	MinPrice   string   `form:"min_price" validate:"omitempty,numeric"`
+	MaxPrice   string   `form:"max_price" validate:"omitempty,numeric"`
+	OnDiscount bool     `form:"discount"`
+	InStock    bool     `form:"in_stock"`
+	Sort       string   `form:"sort" validate:"omitempty,oneof=newest price_asc price_desc popular rating"`
+	// After is the cursor of the page, from CatalogPage.Next of the previous one.
+	After string `form:"after" validate:"max=200"`
+	// PageSize is the number of products per page, catalogPageSize when zero.
+	PageSize int32 `form:"-"`
+	// Subtree limits the catalog to a category and its subcategories, for its landing page.
+	Subtree pgtype.UUID `form:"-"`
+	// Attributes filter by attribute slug: text attributes by a part of the value, enum
+	// attributes by the whole value. AttributeMins and AttributeMaxs bound number and range
+	// attributes. They come from attr[slug], attr_min[slug] and attr_max[slug].
+	Attributes    map[string]string `form:"-" validate:"max=20,dive,keys,max=60,slug,endkeys,max=100"`
+	AttributeMins map[string]string `form:"-" validate:"max=20,dive,keys,max=60,slug,endkeys,omitempty,numeric"`
+	AttributeMaxs map[string]string `form:"-" validate:"max=20,dive,keys,max=60,slug,endkeys,omitempty,numeric"`
+}
+
+type StockAdjust struct {
+	Change int    `json:"change" form:"change" validate:"required,min=-100000,max=100000"`
+	Reason string `json:"reason" form:"reason" validate:"required,oneof=receipt adjustment"`
+	Note   string `json:"note" form:"note" validate:"max=500"`
+}
+
+type OrderCreate struct {
+	Address     string `json:"address" form:"address" validate:"required,min=5,max=255"`
+	PhoneNumber string `json:"phone_number" form:"phone" validate:"required,phone"`
+}
+
+type OrderStatusUpdate struct {
+	Status string `json:"status" form:"status" validate:"required,oneof=pending paid shipped completed return_requested returned cancelled"`
+	Note   string `json:"note" form:"note" validate:"max=500"`
+}
+
+type ReturnRequest struct {
+	Statement string `json:"return_statement" form:"return_statement" validate:"required,max=1000"`
+}
+
+type DeliveryCreate struct {
+	TrackingNumber    string `json:"tracking_number" form:"tracking_number" validate:"required,max=100"`
+	EstimatedDelivery string `json:"estimated_delivery" form:"estimated_delivery" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type DeliveryStatusUpdate struct {
+	Status string `json:"status" form:"status" validate:"required,oneof='in transit' delivered returned"`
+	Note   string `json:"note" form:"note" validate:"max=500"`
+}
+
+type ReviewCreate struct {
+	Rating  int    `json:"rating" form:"rating" validate:"required,min=1,max=5"`
+	Content string `json:"content" form:"content" validate:"required,max=2000"`
+}
+
+type QuestionCreate struct {
+	Content string `json:"content" form:"content" validate:"required,max=1000"`
+}
+
+type QuestionAnswer struct {
+	Response string `json:"response" form:"response" validate:"required,max=2000"`
+}
+
+type ChatTransfer struct {
+	AgentID string `json:"agent_id" form:"agent_id" validate:"required,uuid"`
+}
+
+type CannedResponseCreate struct {
+	Title   string `json:"title" form:"title" validate:"required,max=100"`
+	Content string `json:"content" form:"content" validate:"required,max=2000"`
+}
+
+type ChatMessageCreate struct {
+	Content string `json:"content" form:"message" validate:"required,max=2000"`
+}
+
+type ApiKeyCreate struct {
+	Name          string   `json:"name" form:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" form:"scopes" validate:"required,min=1,dive,oneof=products:write orders:read orders:write users:read users:write chats:read chats:write stats:read"`
+	ExpiresInDays int      `json:"expires_in_days" form:"expires_in_days" validate:"required,min=1,max=365"`
+}
+
+// APICartItem is a shopping list line sent by API clients, which keep their cart themselves.
+type APICartItem struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=1000"`
+}
+
+type CartQuote struct {
+	Items []APICartItem `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type APIOrderCreate struct {
+	OrderCreate
+	Items []APICartItem `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+// Page selects a part of a long list, Limit items after skipping Offset.
+type Page struct {
+	Limit  int32
+	Offset int32
+}
+
+// CartItem is a single line of the shopping list kept in the session.
+type CartItem struct {
+	ID       string
+	Quantity int
 }
 
 var nameRegex = `^[A-ZА-Я][a-zа-я]{1,49}$`
+var phoneRegex = `^\+?[0-9 ]{6,23}$`
+var slugRegex = `^[a-zа-я0-9]+(-[a-zа-я0-9]+)*$`
 
 func nameValidator(fl validator.FieldLevel) bool {
 	match, err := regexp.MatchString(nameRegex, fl.Field().String())
@@ -41,12 +182,44 @@ func nameValidator(fl validator.FieldLevel) bool {
 	return match
 }
 
+func phoneValidator(fl validator.FieldLevel) bool {
+	match, err := regexp.MatchString(phoneRegex, fl.Field().String())
+	if err != nil {
+		slog.Warn(err.Error())
+	}
+	return match
+}
+
+func slugValidator(fl validator.FieldLevel) bool {
+	match, err := regexp.MatchString(slugRegex, fl.Field().String())
+	if err != nil {
+		slog.Warn(err.Error())
+	}
+	return match
+}
+
 func NewValidator() (*validator.Validate, error) {
 	validate := validator.New()
+	// Field() reports the JSON name of a field so API errors name what the client sent.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
 
 	err := validate.RegisterValidation("name", nameValidator)
 	if err != nil {
 		return nil, err
 	}
+	err = validate.RegisterValidation("phone", phoneValidator)
+	if err != nil {
+		return nil, err
+	}
+	err = validate.RegisterValidation("slug", slugValidator)
+	if err != nil {
+		return nil, err
+	}
 	return validate, nil
 }
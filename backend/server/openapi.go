@@ -0,0 +1,482 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// apiPrefix is the part of the path every documented route starts with.
+const apiPrefix = "/api/v1"
+
+// APIOperation documents a single route of the JSON API.
+type APIOperation struct {
+	Method  string
+	Path    string // gin path, e.g. /api/v1/products/:id
+	Summary string
+	Tag     string
+	// Auth is set for routes that need a bearer token, Roles lists who may call them if not everyone
+	// and Scope is the scope an API key needs for them.
+	Auth  bool
+	Roles string
+	Scope string
+	Query []APIParam
+	// Request is the body type, nil for routes without a body. Multipart bodies carry an image as "file".
+	Request   any
+	Multipart bool
+	// Response is the type of "data" in the envelope, nil for 204 responses. List responses are
+	// paginated, by page number or, for Cursor lists, with the after= cursor of meta.next.
+	Response any
+	List     bool
+	Cursor   bool
+	Status   int
+}
+
+// APIParam is a query string parameter of an operation.
+type APIParam struct {
+	Name        string
+	Description string
+}
+
+// apiOperations is the contract of the JSON API. TestAPISpecCoversRoutes fails, and NewServer refuses
+// to create a server, while a route under apiPrefix has no entry here or an entry has no route.
+var apiOperations = []APIOperation{
+	{Method: http.MethodPost, Path: "/api/v1/auth/token", Summary: "Exchange an email and password for a bearer token", Tag: "auth",
+		Request: UserLogin{}, Response: TokenResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/api/v1/auth/token", Summary: "Revoke the login token of the request, API keys are refused", Tag: "auth",
+		Auth: true, Status: http.StatusNoContent},
+
+	{Method: http.MethodPost, Path: "/api/v1/users", Summary: "Register a customer account", Tag: "users",
+		Request: UserRegister{}, Response: UserResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/users", Summary: "List every user", Tag: "users",
+		Auth: true, Roles: "admin", Scope: scopeUsersRead, Response: UserResponse{}, List: true, Status: http.StatusOK},
+	{Method: http.MethodGet, Path: "/api/v1/users/me", Summary: "Get the authenticated user", Tag: "users",
+		Auth: true, Scope: scopeUsersRead, Response: UserResponse{}, Status: http.StatusOK},
+	{Method: http.MethodPut, Path: "/api/v1/users/me", Summary: "Change the names of the authenticated user", Tag: "users",
+		Auth: true, Scope: scopeUsersWrite, Request: UserEdit{}, Response: UserResponse{}, Status: http.StatusOK},
+	{Method: http.MethodDelete, Path: "/api/v1/users/:id", Summary: "Delete a user", Tag: "users",
+		Auth: true, Roles: "admin", Scope: scopeUsersWrite, Status: http.StatusNoContent},
+
+	{Method: http.MethodGet, Path: "/api/v1/products", Summary: "List the catalog", Tag: "products",
+		Query: []APIParam{{Name: "name", Description: "Only the product with this exact name"},
+			{Name: "type", Description: "Only products of this type"}},
+		Response: ProductResponse{}, List: true, Cursor: true, Status: http.StatusOK},
+	{Method: http.MethodGet, Path: "/api/v1/products/:id", Summary: "Get a product", Tag: "products",
+		Response: ProductResponse{}, Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/api/v1/products", Summary: "Add a product", Tag: "products",
+		Auth: true, Roles: "admin", Scope: scopeProductsWrite, Request: ProductCreateEdit{}, Multipart: true, Response: ProductResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/api/v1/products/:id", Summary: "Remove a product", Tag: "products",
+		Auth: true, Roles: "admin", Scope: scopeProductsWrite, Status: http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/api/v1/products/:id/stock", Summary: "Book a stock receipt or correction", Tag: "products",
+		Auth: true, Roles: "admin", Scope: scopeProductsWrite, Request: StockAdjust{}, Response: ProductResponse{}, Status: http.StatusOK},
+
+	{Method: http.MethodGet, Path: "/api/v1/categories", Summary: "List the category tree", Tag: "categories",
+		Response: CategoryResponse{}, List: true, Status: http.StatusOK},
+
+	{Method: http.MethodPost, Path: "/api/v1/cart/quote", Summary: "Price a cart and check it against the stock", Tag: "cart",
+		Request: CartQuote{}, Response: CartQuoteResponse{}, Status: http.StatusOK},
+
+	{Method: http.MethodGet, Path: "/api/v1/orders", Summary: "List the orders of the authenticated user", Tag: "orders",
+		Auth: true, Scope: scopeOrdersRead, Query: []APIParam{{Name: "all", Description: "Set to true by support and admins to list every order"}},
+		Response: OrderResponse{}, List: true, Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/api/v1/orders", Summary: "Place an order", Tag: "orders",
+		Auth: true, Scope: scopeOrdersWrite, Request: APIOrderCreate{}, Response: OrderResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/orders/:id", Summary: "Get an order with its items", Tag: "orders",
+		Auth: true, Scope: scopeOrdersRead, Response: OrderResponse{}, Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/api/v1/orders/:id/status", Summary: "Move an order to another status", Tag: "orders",
+		Auth: true, Scope: scopeOrdersWrite, Request: OrderStatusUpdate{}, Response: OrderResponse{}, Status: http.StatusOK},
+
+	{Method: http.MethodGet, Path: "/api/v1/chats", Summary: "List the open chat of a customer or the queue for support", Tag: "chats",
+		Auth: true, Scope: scopeChatsRead, Response: ChatResponse{}, List: true, Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/api/v1/chats", Summary: "Open a support chat", Tag: "chats",
+		Auth: true, Scope: scopeChatsWrite, Response: ChatResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/chats/:id/messages", Summary: "List the messages of a chat", Tag: "chats",
+		Auth: true, Scope: scopeChatsRead, Response: ChatMessage{}, List: true, Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/api/v1/chats/:id/messages", Summary: "Post a message", Tag: "chats",
+		Auth: true, Scope: scopeChatsWrite, Request: ChatMessageCreate{}, Response: ChatMessage{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/api/v1/chats/:id/close", Summary: "Close a resolved chat", Tag: "chats",
+		Auth: true, Roles: "support, admin", Scope: scopeChatsWrite, Response: ChatResponse{}, Status: http.StatusOK},
+
+	{Method: http.MethodGet, Path: "/api/v1/stats/db", Summary: "Report the use of the database connection pool", Tag: "stats",
+		Auth: true, Roles: "admin", Scope: scopeStatsRead, Response: PoolStatsResponse{}, Status: http.StatusOK},
+}
+
+// checkAPISpec compares the routes registered under apiPrefix with apiOperations and
+// returns the routes without an entry and the entries without a route.
+func checkAPISpec(routes gin.RoutesInfo) (undocumented []string, stale []string) {
+	documented := make(map[string]bool, len(apiOperations))
+	for _, op := range apiOperations {
+		documented[op.Method+" "+op.Path] = true
+	}
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, apiPrefix) {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(stale)
+	return undocumented, stale
+}
+
+// openAPISpec builds the OpenAPI 3 document of the JSON API from apiOperations.
+// Schemas are derived from the Go types and their json and validate tags.
+func openAPISpec() map[string]any {
+	g := &schemaGenerator{schemas: map[string]any{}}
+	errorResponse := map[string]any{"description": "Error",
+		"content": map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(APIErrorResponse{}))}}}
+
+	paths := map[string]any{}
+	for _, op := range apiOperations {
+		path, params := openAPIPath(op.Path)
+		for _, q := range op.Query {
+			params = append(params, map[string]any{"name": q.Name, "in": "query", "description": q.Description, "schema": map[string]any{"type": "string"}})
+		}
+		if op.List && op.Cursor {
+			params = append(params,
+				map[string]any{"name": "after", "in": "query", "description": "meta.next of the previous page", "schema": map[string]any{"type": "string"}})
+		} else if op.List {
+			params = append(params,
+				map[string]any{"name": "page", "in": "query", "schema": map[string]any{"type": "integer", "minimum": 1, "default": 1}})
+		}
+		if op.List {
+			params = append(params,
+				map[string]any{"name": "per_page", "in": "query", "schema": map[string]any{"type": "integer", "minimum": 1, "maximum": maxPerPage, "default": defaultPerPage}})
+		}
+
+		operation := map[string]any{"summary": op.Summary,
+			"tags":        []string{op.Tag},
+			"operationId": openAPIOperationID(op),
+			"responses": map[string]any{strconv.Itoa(op.Status): g.response(op),
+				"default": errorResponse}}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Auth {
+			operation["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+		}
+		if description := openAPIDescription(op); description != "" {
+			operation["description"] = description
+		}
+		if op.Request != nil {
+			operation["requestBody"] = g.requestBody(op)
+		}
+
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]any{"openapi": "3.0.3",
+		"info": map[string]any{"title": "agro.store API",
+			"version":     "1.0.0",
+			"description": "JSON API of the agro.store shop. Successful responses are wrapped in {\"data\": ...}, lists add \"meta\" with the page, failures are {\"error\": {...}}."},
+		"servers": []any{map[string]any{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]any{"schemas": g.schemas,
+			"securitySchemes": map[string]any{"bearerAuth": map[string]any{"type": "http",
+				"scheme":      "bearer",
+				"description": "A token from POST /api/v1/auth/token or an API key minted by an admin on the profile page."}}}}
+}
+
+// openAPIDescription says who may call an operation, empty when everyone may.
+func openAPIDescription(op APIOperation) string {
+	var description []string
+	if op.Roles != "" {
+		description = append(description, fmt.Sprintf("Only for %s.", op.Roles))
+	}
+	if op.Scope != "" {
+		description = append(description, fmt.Sprintf("API keys need the %s scope.", op.Scope))
+	}
+	return strings.Join(description, " ")
+}
+
+// apiDocsOperations lists apiOperations for the docs page shown without Swagger UI.
+func apiDocsOperations() []views.APIDocsOperation {
+	operations := make([]views.APIDocsOperation, 0, len(apiOperations))
+	for _, op := range apiOperations {
+		path, _ := openAPIPath(op.Path)
+		operations = append(operations, views.APIDocsOperation{Method: op.Method,
+			Path:        path,
+			Summary:     op.Summary,
+			Description: openAPIDescription(op)})
+	}
+	return operations
+}
+
+// openAPIPath converts a gin path to an OpenAPI path and its path parameters.
+func openAPIPath(ginPath string) (string, []any) {
+	var params []any
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, map[string]any{"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string", "format": "uuid"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// openAPIOperationID names an operation after its method and path, e.g. getProductsById.
+func openAPIOperationID(op APIOperation) string {
+	id := strings.ToLower(op.Method)
+	for _, segment := range strings.Split(strings.TrimPrefix(op.Path, apiPrefix), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") {
+			segment = "by_" + segment[1:]
+		}
+		for _, word := range strings.Split(segment, "_") {
+			if word != "" {
+				id += strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+	}
+	return id
+}
+
+// schemaGenerator collects the named schemas referenced by the document.
+type schemaGenerator struct {
+	schemas map[string]any
+}
+
+func (g *schemaGenerator) response(op APIOperation) map[string]any {
+	if op.Response == nil {
+		return map[string]any{"description": http.StatusText(op.Status)}
+	}
+	data := g.schema(reflect.TypeOf(op.Response))
+	properties := map[string]any{"data": data}
+	if op.List {
+		properties["data"] = map[string]any{"type": "array", "items": data}
+		properties["meta"] = g.schema(reflect.TypeOf(ListMeta{}))
+	}
+	envelope := map[string]any{"type": "object", "required": []string{"data"}, "properties": properties}
+	return map[string]any{"description": http.StatusText(op.Status),
+		"content": map[string]any{"application/json": map[string]any{"schema": envelope}}}
+}
+
+func (g *schemaGenerator) requestBody(op APIOperation) map[string]any {
+	body := g.schema(reflect.TypeOf(op.Request))
+	contentType := "application/json"
+	if op.Multipart {
+		contentType = "multipart/form-data"
+		// Multipart forms are bound by their form names and carry the image as a file.
+		body = g.object(reflect.TypeOf(op.Request), "form")
+		body["properties"].(map[string]any)["file"] = map[string]any{"type": "string", "format": "binary"}
+		body["required"] = append(body["required"].([]string), "file")
+	}
+	return map[string]any{"required": true, "content": map[string]any{contentType: map[string]any{"schema": body}}}
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	uuidType        = reflect.TypeOf(pgtype.UUID{})
+	numericType     = reflect.TypeOf(pgtype.Numeric{})
+	textType        = reflect.TypeOf(pgtype.Text{})
+	timestamptzType = reflect.TypeOf(pgtype.Timestamptz{})
+	timestampType   = reflect.TypeOf(pgtype.Timestamp{})
+)
+
+// schema returns the schema of t. Named structs are added to the components and referenced.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType, timestamptzType, timestampType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]any{"type": "string", "format": "uuid"}
+	case numericType:
+		return map[string]any{"type": "number"}
+	case textType:
+		return map[string]any{"type": "string", "nullable": true}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t, "json")
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate.
+			g.schemas[t.Name()] = map[string]any{}
+			g.schemas[t.Name()] = g.object(t, "json")
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// object builds the schema of a struct, naming the properties after the nameTag of each field.
+// Embedded structs are flattened the way encoding/json flattens them.
+func (g *schemaGenerator) object(t reflect.Type, nameTag string) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	g.addFields(t, nameTag, properties, &required)
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, nameTag string, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get(nameTag) == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, nameTag, properties, required)
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get(nameTag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schema(field.Type)
+		fieldRequired := applyValidateTag(property, field.Type, field.Tag.Get("validate"))
+		if fieldRequired || (nameTag == "json" && !strings.Contains(options, "omitempty") && field.Tag.Get("validate") == "") {
+			*required = append(*required, name)
+		}
+		properties[name] = property
+	}
+}
+
+// applyValidateTag adds the constraints of a validate tag to the schema of a field and
+// reports whether the field is required. Rules after "dive" apply to the elements of a slice.
+func applyValidateTag(property map[string]any, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	rules, elemRules, dive := strings.Cut(tag, ",dive")
+	if dive {
+		if items, ok := property["items"].(map[string]any); ok && strings.TrimPrefix(elemRules, ",") != "" {
+			applyValidateTag(items, t.Elem(), strings.TrimPrefix(elemRules, ","))
+		}
+	}
+
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "gt", "gte", "lt", "lte", "len":
+			applyBound(property, t, name, param)
+		case "oneof":
+			property["enum"] = oneofValues(param)
+		case "email":
+			property["format"] = "email"
+		case "uuid":
+			property["format"] = "uuid"
+		case "numeric":
+			property["pattern"] = `^[-+]?[0-9]*\.?[0-9]+$`
+		case "name":
+			property["pattern"] = nameRegex
+		case "phone":
+			property["pattern"] = phoneRegex
+		case "slug":
+			property["pattern"] = slugRegex
+		}
+	}
+	return required
+}
+
+// applyBound maps a size rule to the keyword validator applies it as:
+// the length of strings, the number of items of slices and the value of numbers.
+func applyBound(property map[string]any, t reflect.Type, rule string, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	var minKey, maxKey string
+	switch t.Kind() {
+	case reflect.String:
+		minKey, maxKey = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		minKey, maxKey = "minItems", "maxItems"
+	default:
+		minKey, maxKey = "minimum", "maximum"
+	}
+	isCount := minKey != "minimum"
+	switch rule {
+	case "min", "gte":
+		property[minKey] = n
+	case "max", "lte":
+		property[maxKey] = n
+	case "len":
+		property[minKey], property[maxKey] = n, n
+	case "gt":
+		if isCount {
+			property[minKey] = n + 1
+		} else {
+			property[minKey], property["exclusiveMinimum"] = n, true
+		}
+	case "lt":
+		if isCount {
+			property[maxKey] = n - 1
+		} else {
+			property[maxKey], property["exclusiveMaximum"] = n, true
+		}
+	}
+}
+
+// oneofValues splits the parameter of a oneof rule, where values with spaces are single quoted.
+func oneofValues(param string) []string {
+	var values []string
+	for param != "" {
+		param = strings.TrimLeft(param, " ")
+		if strings.HasPrefix(param, "'") {
+			value, rest, _ := strings.Cut(param[1:], "'")
+			values = append(values, value)
+			param = rest
+			continue
+		}
+		value, rest, _ := strings.Cut(param, " ")
+		if value != "" {
+			values = append(values, value)
+		}
+		param = rest
+	}
+	return values
+}
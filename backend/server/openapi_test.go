@@ -0,0 +1,76 @@
+package server
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAPISpecCoversRoutes fails when a route under apiPrefix is registered without an
+// entry in apiOperations, or an entry is left without a route.
+func TestAPISpecCoversRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{cfg: DefaultConfig(), hub: NewHub(nil, nil), router: gin.New()}
+	s.routes()
+
+	undocumented, stale := checkAPISpec(s.router.Routes())
+	if len(undocumented) > 0 {
+		t.Errorf("routes without an entry in apiOperations: %v", undocumented)
+	}
+	if len(stale) > 0 {
+		t.Errorf("entries of apiOperations without a route: %v", stale)
+	}
+}
+
+// TestNewServerWithoutDatabase checks that a server builds from services alone.
+func TestNewServerWithoutDatabase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, err := NewServer(DefaultConfig(), nil, Services{}, NewHub(nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateTagSchemas(t *testing.T) {
+	g := &schemaGenerator{schemas: map[string]any{}}
+	property := func(v any, name string) map[string]any {
+		t.Helper()
+		schema := g.object(reflect.TypeOf(v), "json")
+		p, ok := schema["properties"].(map[string]any)[name].(map[string]any)
+		if !ok {
+			t.Fatalf("%T has no property %s", v, name)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		property map[string]any
+		key      string
+		want     any
+	}{
+		{"UserRegister.password min", property(UserRegister{}, "password"), "minLength", 8.0},
+		{"UserRegister.password max", property(UserRegister{}, "password"), "maxLength", 32.0},
+		{"ProductCreateEdit.name min", property(ProductCreateEdit{}, "name"), "minLength", 2.0},
+		{"ProductCreateEdit.description max", property(ProductCreateEdit{}, "description"), "maxLength", 500.0},
+		{"ProductCreateEdit.attributes max", property(ProductCreateEdit{}, "attributes"), "maxItems", 50.0},
+		{"StockAdjust.change min", property(StockAdjust{}, "change"), "minimum", -100000.0},
+		{"StockAdjust.change max", property(StockAdjust{}, "change"), "maximum", 100000.0},
+		{"StockAdjust.reason oneof", property(StockAdjust{}, "reason"), "enum", []string{"receipt", "adjustment"}},
+		{"DeliveryStatusUpdate.status oneof", property(DeliveryStatusUpdate{}, "status"), "enum", []string{"in transit", "delivered", "returned"}},
+	}
+	for _, tt := range tests {
+		if got := tt.property[tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %s = %v, want %v", tt.name, tt.key, got, tt.want)
+		}
+	}
+
+	schema := g.object(reflect.TypeOf(UserRegister{}), "json")
+	for _, name := range []string{"email", "password", "first_name", "last_name"} {
+		if !slices.Contains(schema["required"].([]string), name) {
+			t.Errorf("UserRegister.%s is not required", name)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"agro.store/backend/db"
+	"agro.store/backend/inventory"
+	"agro.store/backend/orderstatus"
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// orderService implements OrderService on the database.
+type orderService struct {
+	q    db.Querier
+	conn TxBeginner
+}
+
+func (s *orderService) Order(ctx context.Context, id pgtype.UUID) (db.Order, error) {
+	return s.q.GetOrderById(ctx, id)
+}
+
+func (s *orderService) Orders(ctx context.Context) ([]db.Order, error) {
+	return s.q.ListAllOrders(ctx)
+}
+
+func (s *orderService) OrdersByUser(ctx context.Context, userID pgtype.UUID) ([]db.Order, error) {
+	return s.q.ListAllOrdersByUserId(ctx, userID)
+}
+
+func (s *orderService) OrdersPage(ctx context.Context, page Page) ([]db.Order, int64, error) {
+	orders, err := s.q.ListOrdersPage(ctx, db.ListOrdersPageParams{Limit: page.Limit, Offset: page.Offset})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list orders: %w", err)
+	}
+	total, err := s.q.CountOrders(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+func (s *orderService) OrdersByUserPage(ctx context.Context, userID pgtype.UUID, page Page) ([]db.Order, int64, error) {
+	orders, err := s.q.ListOrdersByUserIdPage(ctx, db.ListOrdersByUserIdPageParams{UserID: userID,
+		Limit:  page.Limit,
+		Offset: page.Offset})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list orders: %w", err)
+	}
+	total, err := s.q.CountOrdersByUserId(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+func (s *orderService) OrdersByStatus(ctx context.Context, status db.OrderType) ([]db.Order, error) {
+	return s.q.ListAllOrdersByStatus(ctx, status)
+}
+
+// Create stores the shopping list as a pending order. The order, its details
+// and every item are written in one transaction, so a failing line leaves no partial order.
+// Item prices are frozen from the current product price minus its discount and the stock
+// of every item is reserved. Products are reserved in ID order so concurrent checkouts
+// lock product rows in the same order and can't deadlock.
+func (s *orderService) Create(ctx context.Context, userID pgtype.UUID, orderForm OrderCreate, shoppingList []CartItem) (pgtype.UUID, error) {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := db.New(tx)
+
+	orderID, err := qtx.CreateOrder(ctx, db.CreateOrderParams{UserID: userID, Status: db.OrderTypePending})
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("create order: %w", err)
+	}
+	err = orderstatus.RecordCreated(ctx, qtx, orderID, userID)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("record order status: %w", err)
+	}
+
+	err = qtx.CreateOrderDetails(ctx, db.CreateOrderDetailsParams{OrderID: orderID,
+		Address:     orderForm.Address,
+		PhoneNumber: pgtype.Text{String: orderForm.PhoneNumber, Valid: orderForm.PhoneNumber != ""}})
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("create order details: %w", err)
+	}
+
+	cart := mergeShoppingList(shoppingList)
+	sort.Slice(cart, func(i, j int) bool { return cart[i].ID < cart[j].ID })
+	for _, item := range cart {
+		productID, err := StrToUUID(item.ID)
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("product id %q is not UUID: %w", item.ID, err)
+		}
+		_, err = qtx.CreateOrderItem(ctx, db.CreateOrderItemParams{OrderID: orderID,
+			Quantity:  int32(item.Quantity),
+			ProductID: productID})
+		if err != nil {
+			return pgtype.UUID{}, fmt.Errorf("create order item for product %s: %w", item.ID, err)
+		}
+		err = inventory.Reserve(ctx, qtx, productID, orderID, userID, int32(item.Quantity))
+		if err != nil {
+			return pgtype.UUID{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pgtype.UUID{}, err
+	}
+	return orderID, nil
+}
+
+func (s *orderService) Details(ctx context.Context, orderID pgtype.UUID) (db.OrderDetail, error) {
+	return s.q.GetOrderDetailsById(ctx, orderID)
+}
+
+func (s *orderService) Items(ctx context.Context, orderID pgtype.UUID) ([]db.OrderItem, []db.GetProductByIdRow, error) {
+	items, err := s.q.ListAllOrderItemsById(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	products := make([]db.GetProductByIdRow, 0, len(items))
+	for _, item := range items {
+		product, err := s.q.GetProductById(ctx, item.ProductID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("product %s of order item %s: %w", item.ProductID.String(), item.ID.String(), err)
+		}
+		products = append(products, product)
+	}
+	return items, products, nil
+}
+
+func (s *orderService) History(ctx context.Context, orderID pgtype.UUID) ([]db.ListOrderStatusHistoryByOrderIdRow, error) {
+	return s.q.ListOrderStatusHistoryByOrderId(ctx, orderID)
+}
+
+func (s *orderService) ChangesSince(ctx context.Context, userID pgtype.UUID, afterID pgtype.UUID) ([]db.OrderStatusHistory, error) {
+	return s.q.ListOrderStatusHistoryByUserIdAfter(ctx, db.ListOrderStatusHistoryByUserIdAfterParams{UserID: userID, AfterID: afterID})
+}
+
+// ChangeStatus moves an order through its lifecycle in its own transaction.
+// Cancelled orders put their reserved stock back.
+func (s *orderService) ChangeStatus(ctx context.Context, orderID pgtype.UUID, actor db.GetUserByIdRow, to db.OrderType, note string) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := db.New(tx)
+
+	_, err = orderstatus.Transition(ctx, qtx, orderID, actor.ID, actor.Role, to, note)
+	if err != nil {
+		return err
+	}
+	if to == db.OrderTypeCancelled {
+		err = inventory.ReleaseOrder(ctx, qtx, orderID, actor.ID)
+		if err != nil {
+			return fmt.Errorf("release stock: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// renderOrderPage renders an order with its items, status history, return request
+// and shipment, together with the actions the viewing user may take.
+func (s *Server) renderOrderPage(c *gin.Context, order db.Order, user db.GetUserByIdRow, errMsg string) {
+	details, err := s.Orders.Details(c, order.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't get order details in /orders/:id : %v", err))
+		c.Redirect(http.StatusFound, "/orders")
+		return
+	}
+	items, products, err := s.Orders.Items(c, order.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't get order items in /orders/:id : %v", err))
+		c.Redirect(http.StatusFound, "/orders")
+		return
+	}
+	history, err := s.Orders.History(c, order.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't get order status history in /orders/:id : %v", err))
+		history = []db.ListOrderStatusHistoryByOrderIdRow{}
+	}
+	returnItems, err := s.Orders.ReturnItems(c, order.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't get order return items in /orders/:id : %v", err))
+		returnItems = []db.ListOrderReturnItemsByOrderIdRow{}
+	}
+
+	data := views.OrderPageData{Order: order,
+		Details:      details,
+		Items:        items,
+		Products:     products,
+		History:      history,
+		ReturnItems:  returnItems,
+		NextStatuses: statusFormOptions(user.Role, order.Status),
+		Viewer:       user,
+		ErrMsg:       errMsg}
+
+	delivery, err := s.Orders.Delivery(c, order.ID)
+	if err == nil {
+		data.Delivery = delivery
+		data.DeliveryEvents, err = s.Orders.DeliveryEvents(c, delivery.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't get delivery events in /orders/:id : %v", err))
+		}
+		data.NextDeliveryStatuses = orderstatus.NextDeliveryStatuses(delivery.Status)
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		slog.Warn(fmt.Sprintf("Can't get delivery in /orders/:id : %v", err))
+	}
+
+	err = views.OrderPage(data).Render(c, c.Writer)
+	if err != nil {
+		log.Fatalf("failed to render in /orders/:id: %v", err)
+	}
+}
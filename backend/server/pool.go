@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"agro.store/backend/migrate"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewPool connects the pool the sessions, the queries of the services and the hub share.
+func NewPool(ctx context.Context, databaseURL string, cfg PoolConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse database url: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = cfg.MinConns
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.StatementTimeout > 0 {
+		// Sent as a startup parameter, so it holds for every statement on every connection.
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create pool: %w", err)
+	}
+	err = pool.Ping(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+	return pool, nil
+}
+
+// checkMigrations fails when the database schema is behind the migrations in the binary.
+func checkMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	migrator, err := migrate.New(conn.Conn())
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("check migrations: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d migrations are pending, starting with %s, run `migrate up` first", len(pending), pending[0].Describe())
+	}
+	return nil
+}
+
+// PoolStatsResponse is a snapshot of the connection pool.
+type PoolStatsResponse struct {
+	MaxConns             int32   `json:"max_conns"`
+	TotalConns           int32   `json:"total_conns"`
+	AcquiredConns        int32   `json:"acquired_conns"`
+	IdleConns            int32   `json:"idle_conns"`
+	ConstructingConns    int32   `json:"constructing_conns"`
+	AcquireCount         int64   `json:"acquire_count"`
+	EmptyAcquireCount    int64   `json:"empty_acquire_count"`
+	CanceledAcquireCount int64   `json:"canceled_acquire_count"`
+	AcquireDurationMs    float64 `json:"acquire_duration_ms"`
+	NewConnsCount        int64   `json:"new_conns_count"`
+	MaxLifetimeDestroys  int64   `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroys      int64   `json:"max_idle_destroy_count"`
+}
+
+func poolStatsResponse(stat *pgxpool.Stat) PoolStatsResponse {
+	return PoolStatsResponse{MaxConns: stat.MaxConns(),
+		TotalConns:           stat.TotalConns(),
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		ConstructingConns:    stat.ConstructingConns(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireDurationMs:    float64(stat.AcquireDuration().Microseconds()) / 1000,
+		NewConnsCount:        stat.NewConnsCount(),
+		MaxLifetimeDestroys:  stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroys:      stat.MaxIdleDestroyCount()}
+}
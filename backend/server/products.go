@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"time"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidImage is returned for product images that aren't svg, jpeg or png files.
+var ErrInvalidImage = errors.New("file must be an image")
+
+// ErrInvalidPrice is returned when the product price isn't a number.
+var ErrInvalidPrice = errors.New("failed to get price")
+
+// catalogService implements CatalogService on the database, with product images on disk.
+type catalogService struct {
+	q         db.Querier
+	conn      TxBeginner
+	uploadDir string
+}
+
+func (s *catalogService) Products(ctx context.Context, name string, productType string) ([]db.ListAllProductsRow, error) {
+	if name != "" {
+		p, err := s.q.GetProductByName(ctx, name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []db.ListAllProductsRow{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []db.ListAllProductsRow{{ID: p.ID,
+			Name:        p.Name,
+			Price:       p.Price,
+			Discount:    p.Discount,
+			Description: p.Description,
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+			Img:         p.Img,
+			Stock:       p.Stock,
+			Type:        p.Type,
+			Category:    p.Category}}, nil
+	}
+	if productType != "" {
+		prods, err := s.q.ListAllProductsByType(ctx, productType)
+		if err != nil {
+			return nil, err
+		}
+		products := make([]db.ListAllProductsRow, 0, len(prods))
+		for _, p := range prods {
+			products = append(products, db.ListAllProductsRow(p))
+		}
+		return products, nil
+	}
+	return s.q.ListAllProducts(ctx)
+}
+
+func (s *catalogService) Product(ctx context.Context, id pgtype.UUID) (db.GetProductByIdRow, error) {
+	return s.q.GetProductById(ctx, id)
+}
+
+func (s *catalogService) SaveImage(file *multipart.FileHeader) (string, error) {
+	ext := filepath.Ext(file.Filename)
+	if ext != ".svg" && ext != ".jpeg" && ext != ".jpg" && ext != ".png" {
+		return "", ErrInvalidImage
+	}
+	newFileName := fmt.Sprintf("IMG-%d%s", time.Now().UnixNano(), ext)
+	err := saveFile(file, filepath.Join(s.uploadDir, newFileName))
+	if err != nil {
+		return "", fmt.Errorf("save file: %w", err)
+	}
+	return newFileName, nil
+}
+
+// saveFile copies an uploaded file to dst, creating its directory if needed.
+func saveFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, src)
+	return err
+}
+
+func (s *catalogService) RemoveImage(img string) {
+	_ = os.Remove(filepath.Join(s.uploadDir, img))
+}
+
+func (s *catalogService) CreateProduct(ctx context.Context, productForm ProductCreateEdit, img string) (pgtype.UUID, error) {
+	priceNumeric := pgtype.Numeric{}
+	err := priceNumeric.Scan(productForm.Price)
+	if err != nil {
+		return pgtype.UUID{}, ErrInvalidPrice
+	}
+	category, err := s.Category(ctx, productForm.Category)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	values, err := attributeValues(ctx, s.q, category.ID, productForm.Attributes)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := db.New(tx)
+
+	id, err := qtx.CreateProduct(ctx, db.CreateProductParams{Name: productForm.Name,
+		Price:       priceNumeric,
+		Description: pgtype.Text{String: productForm.Description, Valid: true},
+		Category:    category.ID,
+		Img:         img,
+	})
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	err = setProductAttributes(ctx, qtx, id, values)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (s *catalogService) UpdateProduct(ctx context.Context, product db.GetProductByIdRow, productForm ProductCreateEdit, img string) error {
+	priceNumeric := pgtype.Numeric{}
+	err := priceNumeric.Scan(productForm.Price)
+	if err != nil {
+		return ErrInvalidPrice
+	}
+	category, err := s.Category(ctx, productForm.Category)
+	if err != nil {
+		return err
+	}
+	values, err := attributeValues(ctx, s.q, category.ID, productForm.Attributes)
+	if err != nil {
+		return err
+	}
+	if img == "" {
+		img = product.Img
+	}
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := db.New(tx)
+
+	err = qtx.UpdateProduct(ctx, db.UpdateProductParams{ID: product.ID,
+		Name:        productForm.Name,
+		Price:       priceNumeric,
+		Discount:    product.Discount,
+		Description: pgtype.Text{String: productForm.Description, Valid: true},
+		Category:    category.ID,
+		Img:         img,
+	})
+	if err != nil {
+		return err
+	}
+	err = setProductAttributes(ctx, qtx, product.ID, values)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *catalogService) DeleteProduct(ctx context.Context, id pgtype.UUID) error {
+	return s.q.DeleteProduct(ctx, id)
+}
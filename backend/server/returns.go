@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"agro.store/backend/db"
+	"agro.store/backend/inventory"
+	"agro.store/backend/orderstatus"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// returnStatuses are reached only through the returns workflow, never through the plain status form,
+// so a return always carries its items and refund amount.
+var returnStatuses = map[db.OrderType]bool{
+	db.OrderTypeReturnRequested: true,
+	db.OrderTypeReturned:        true,
+}
+
+// deliveryStatuses are reached only through the delivery endpoints, so a shipped or completed
+// order always has its shipment.
+var deliveryStatuses = map[db.OrderType]bool{
+	db.OrderTypeShipped:   true,
+	db.OrderTypeCompleted: true,
+}
+
+// ReturnLine is a single order item the customer wants to send back.
+type ReturnLine struct {
+	OrderItemID pgtype.UUID
+	Quantity    int
+	Reason      string
+}
+
+// statusFormOptions returns the statuses offered in the plain status form of an order.
+func statusFormOptions(role db.UserRole, from db.OrderType) []db.OrderType {
+	if returnStatuses[from] {
+		return nil
+	}
+	var options []db.OrderType
+	for _, to := range orderstatus.NextFor(role, from) {
+		if !returnStatuses[to] && !deliveryStatuses[to] {
+			options = append(options, to)
+		}
+	}
+	return options
+}
+
+// parseReturnLines reads the quantity and reason entered for every order item.
+// Items left with an empty or zero quantity are not returned.
+func parseReturnLines(c *gin.Context, items []db.OrderItem) ([]ReturnLine, string) {
+	var lines []ReturnLine
+	for _, item := range items {
+		rawQuantity := c.PostForm(fmt.Sprintf("quantity-%s", item.ID.String()))
+		if rawQuantity == "" {
+			continue
+		}
+		quantity, err := strconv.Atoi(rawQuantity)
+		if err != nil || quantity < 0 || quantity > int(item.Quantity) {
+			return nil, fmt.Sprintf("Quantity must be between 0 and %d", item.Quantity)
+		}
+		if quantity == 0 {
+			continue
+		}
+		reason := c.PostForm(fmt.Sprintf("reason-%s", item.ID.String()))
+		if reason == "" || utf8.RuneCountInString(reason) > 500 {
+			return nil, "Every returned product needs a reason up to 500 characters"
+		}
+		lines = append(lines, ReturnLine{OrderItemID: item.ID, Quantity: quantity, Reason: reason})
+	}
+	if len(lines) == 0 {
+		return nil, "Choose at least one product to return"
+	}
+	return lines, ""
+}
+
+func (s *orderService) ReturnItems(ctx context.Context, orderID pgtype.UUID) ([]db.ListOrderReturnItemsByOrderIdRow, error) {
+	return s.q.ListOrderReturnItemsByOrderId(ctx, orderID)
+}
+
+// RequestReturn moves a completed order to return_requested and stores the returned items.
+// Items of an earlier, rejected request are replaced.
+func (s *orderService) RequestReturn(ctx context.Context, order db.Order, actor db.GetUserByIdRow, statement string, lines []ReturnLine) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	qtx := db.New(tx)
+
+	_, err = orderstatus.Transition(ctx, qtx, order.ID, actor.ID, actor.Role, db.OrderTypeReturnRequested, statement)
+	if err != nil {
+		return err
+	}
+	err = qtx.DeleteOrderReturnItemsByOrderId(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		err = qtx.CreateOrderReturnItem(ctx, db.CreateOrderReturnItemParams{OrderID: order.ID,
+			OrderItemID: line.OrderItemID,
+			Quantity:    int32(line.Quantity),
+			Reason:      line.Reason})
+		if err != nil {
+			return fmt.Errorf("create return item %s: %w", line.OrderItemID.String(), err)
+		}
+	}
+	err = qtx.UpdateOrderReturnStatement(ctx, db.UpdateOrderReturnStatementParams{OrderID: order.ID,
+		ReturnStatement: pgtype.Text{String: statement, Valid: true}})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ApproveReturn moves the order to returned, puts the returned items back in stock and
+// stores the refund computed from the prices the returned items were bought at.
+func (s *orderService) ApproveReturn(ctx context.Context, order db.Order, actor db.GetUserByIdRow) (pgtype.Numeric, error) {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return pgtype.Numeric{}, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := db.New(tx)
+
+	refund, err := qtx.SetOrderRefundAmount(ctx, order.ID)
+	if err != nil {
+		return pgtype.Numeric{}, err
+	}
+	refundValue, _ := refund.Float64Value()
+	_, err = orderstatus.Transition(ctx, qtx, order.ID, actor.ID, actor.Role, db.OrderTypeReturned,
+		fmt.Sprintf("refund %.2f", refundValue.Float64))
+	if err != nil {
+		return pgtype.Numeric{}, err
+	}
+	err = inventory.RestockReturn(ctx, qtx, order.ID, actor.ID)
+	if err != nil {
+		return pgtype.Numeric{}, fmt.Errorf("restock returned items: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return pgtype.Numeric{}, err
+	}
+	return refund, nil
+}
+
+// RejectReturn moves the order back to completed, keeping the request for reference.
+func (s *orderService) RejectReturn(ctx context.Context, order db.Order, actor db.GetUserByIdRow, note string) error {
+	if order.Status != db.OrderTypeReturnRequested {
+		return fmt.Errorf("%w: order %s has no return request", orderstatus.ErrInvalidTransition, order.ID.String())
+	}
+	return s.ChangeStatus(ctx, order.ID, actor, db.OrderTypeCompleted, note)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"agro.store/backend/db"
+)
+
+// searchConfigs maps the languages the catalog can be searched in to their text search
+// configuration. The shop is Bulgarian, so anything else falls back to it.
+var searchConfigs = map[string]string{
+	"bg": "bulgarian",
+	"ru": "russian",
+	"en": "english",
+}
+
+const defaultSearchLanguage = "bg"
+
+const (
+	maxSearchResults  = 50
+	maxSuggestions    = 8
+	maxSearchQueryLen = 200
+)
+
+// Search finds the products matching a query written the way people type in a search box:
+// words, "quoted phrases", or and -excluded words. The words are stemmed by the rules of
+// lang and the best matches come first.
+func (s *catalogService) Search(ctx context.Context, query string, lang string) ([]db.SearchProductsRow, error) {
+	query = trimQuery(query)
+	if query == "" {
+		return []db.SearchProductsRow{}, nil
+	}
+	config, ok := searchConfigs[lang]
+	if !ok {
+		config = searchConfigs[defaultSearchLanguage]
+	}
+	return s.q.SearchProducts(ctx, db.SearchProductsParams{Config: config, Query: query, MaxResults: maxSearchResults})
+}
+
+// searchLanguage picks the language of a search: the lang query parameter, else the first
+// language of Accept-Language the catalog can be searched in.
+func searchLanguage(langParam string, acceptLanguage string) string {
+	if _, ok := searchConfigs[langParam]; ok {
+		return langParam
+	}
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := searchConfigs[primary]; ok {
+			return primary
+		}
+	}
+	return defaultSearchLanguage
+}
+
+// Suggest returns the names of products with words starting with the words typed so far.
+func (s *catalogService) Suggest(ctx context.Context, prefix string) ([]string, error) {
+	tsquery := prefixQuery(trimQuery(prefix))
+	if tsquery == "" {
+		return []string{}, nil
+	}
+	rows, err := s.q.SuggestProducts(ctx, db.SuggestProductsParams{PrefixQuery: tsquery, MaxResults: maxSuggestions})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(rows))
+	for _, r := range rows {
+		names = append(names, r.Name)
+	}
+	return names, nil
+}
+
+func trimQuery(query string) string {
+	query = strings.TrimSpace(query)
+	for utf8.RuneCountInString(query) > maxSearchQueryLen {
+		_, size := utf8.DecodeLastRuneInString(query)
+		query = query[:len(query)-size]
+	}
+	return query
+}
+
+// prefixQuery turns typed text into a to_tsquery expression matching every word as a prefix,
+// e.g. "дом сем" becomes "дом:* & сем:*". Anything but letters and digits separates words,
+// so the text can't inject tsquery operators.
+func prefixQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -2,165 +2,437 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
-	"github.com/jackc/pgx/v5/pgtype"
-	"golang.org/x/crypto/bcrypt"
 	"log"
 	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"agro.store/backend/db"
+	"agro.store/backend/inventory"
+	"agro.store/backend/orderstatus"
 	"agro.store/backend/pgstore"
 	"agro.store/frontend/views"
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/joho/godotenv"
 )
 
-var sessionStore *pgstore.PGStore
-
 var DefaultSessionName = "session-name"
 var DefaultSecretKey = "your-secret-key"
-var dbQueries *db.Queries
-var validate *validator.Validate
 
 func init() {
 	_ = godotenv.Load()
 }
 
-func StartServer() {
-	dbURL := os.Getenv("DB_URI")
-	var err error
-	sessionStore, err = pgstore.NewPGStore(dbURL, []byte(DefaultSecretKey))
+// Server is the shop: the HTML pages and the JSON API, both thin layers over the services.
+type Server struct {
+	Services
+	cfg      Config
+	sessions *pgstore.PGStore
+	validate *validator.Validate
+	hub      *Hub
+	router   *gin.Engine
+	// closers release what New opened, they run in reverse order.
+	closers []func()
+}
+
+// New connects to the database of cfg and creates a server with the services backed by it.
+func New(ctx context.Context, cfg Config) (*Server, error) {
+	pool, err := NewPool(ctx, cfg.DatabaseURL, cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+	err = checkMigrations(ctx, pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	sessions, err := pgstore.NewPGStoreFromPool(pool, []byte(cfg.SessionSecret))
 	if err != nil {
-		log.Fatalf("failed to initialize pgstore: %v", err)
+		pool.Close()
+		return nil, fmt.Errorf("initialize pgstore: %w", err)
 	}
-	defer sessionStore.Close()
 
-	ctx := context.Background()
+	// The sessions, the services and the hub share the pool, closing the store closes it.
+	services := NewServices(cfg, db.New(pool), pool)
+	s, err := NewServer(cfg, sessions, services, NewHub(pool, services.Chat))
+	if err != nil {
+		sessions.Close()
+		return nil, err
+	}
+	s.closers = append(s.closers, sessions.Close)
+	return s, nil
+}
 
-	conn, err := pgx.Connect(ctx, dbURL)
+// NewServer creates a server around services that are already constructed, fakes in tests for example.
+func NewServer(cfg Config, sessions *pgstore.PGStore, services Services, hub *Hub) (*Server, error) {
+	validate, err := NewValidator()
 	if err != nil {
-		log.Fatalf("failed to initialize")
+		return nil, fmt.Errorf("initialize validator: %w", err)
+	}
+	s := &Server{Services: services,
+		cfg:      cfg,
+		sessions: sessions,
+		validate: validate,
+		hub:      hub,
+		router:   gin.Default()}
+	s.routes()
+	undocumented, stale := checkAPISpec(s.router.Routes())
+	if len(undocumented) > 0 || len(stale) > 0 {
+		return nil, fmt.Errorf("API routes and the OpenAPI document differ, undocumented: %v, without route: %v", undocumented, stale)
+	}
+	return s, nil
+}
+
+// Handler returns the router serving every route of the server.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Close releases the connections New opened.
+func (s *Server) Close() {
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		s.closers[i]()
+	}
+}
+
+// Run serves on cfg.Addr until ctx is done and then shuts the server down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	go s.hub.Run()
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	go s.hub.Listen(listenCtx)
+
+	srv := &http.Server{Addr: s.cfg.Addr, Handler: s.router}
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		err = fmt.Errorf("run server: %w", err)
+	case <-ctx.Done():
+		slog.Info("shutting down server")
+	}
+
+	// Websocket connections are hijacked, so the http server doesn't wait for them: the hub closes them.
+	stopListening()
+	s.hub.Shutdown()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+		slog.Warn(fmt.Sprintf("server shutdown: %v", shutdownErr))
 	}
-	defer conn.Close(ctx)
+	return err
+}
 
-	dbQueries = db.New(conn)
+// StartServer runs a server configured from the environment until SIGINT or SIGTERM.
+func StartServer() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	validate, err = NewValidator()
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	s, err := New(ctx, cfg)
+	if err != nil {
+		log.Fatalf("failed to initialize server: %v", err)
+	}
+	err = s.Run(ctx)
+	s.Close()
 	if err != nil {
-		log.Fatalf("failed to initialize validator: %v", err)
+		log.Fatalf("failed to run server: %v", err)
 	}
+}
 
-	router := gin.Default()
-	router.Static("/public", "./public")
-	router.Static("/upload", "./upload")
+// routes registers the HTML pages and the JSON API.
+func (s *Server) routes() {
+	router := s.router
+	router.Static("/public", s.cfg.PublicDir)
+	router.Static("/upload", s.cfg.UploadDir)
 	router.MaxMultipartMemory = 8 << 20
 
 	// --- Route definitions ---
 
+	s.registerAPI(router)
+
 	// GET "/" redirects to /products.
 	router.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusFound, "/products")
 	})
 
-	// GET /products with optional filters: ?tag=...&order=asc|desc|newest
+	// GET /products lists the catalog a page at a time, with optional filters:
+	// ?type=...&category=...&min_price=...&max_price=...&discount=true&in_stock=true
+	// &sort=newest|price_asc|price_desc|popular|rating&after=<cursor>
 	router.GET("/products", func(c *gin.Context) {
-		productName := c.Query("name")
-		productType := c.Query("type")
-		var products []db.ListAllProductsRow
+		data, ok := s.catalogPage(c, "/products", pgtype.UUID{})
+		if !ok {
+			return
+		}
+		err := views.ProductsPage(data).Render(c.Request.Context(), c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /products: %v", err)
+		}
+	})
+
+	// GET /products/search?q=...&lang=bg|ru|en lists the products matching a full-text query.
+	// Without lang the language comes from Accept-Language.
+	router.GET("/products/search", func(c *gin.Context) {
+		query := c.Query("q")
+		results, err := s.Catalog.Search(c, query, searchLanguage(c.Query("lang"), c.GetHeader("Accept-Language")))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("failed to search in /products/search : %v", err))
+			results = []db.SearchProductsRow{}
+		}
 
-		if productName != "" {
-			p, err := dbQueries.GetProductByName(c, productName)
+		favorites := map[string]bool{}
+		if user, ok := s.sessionUser(c); ok {
+			favorites, err = s.Catalog.FavoriteIDs(c, user.ID)
 			if err != nil {
-				slog.Warn(fmt.Sprintf("failed to get product by name: %s", productName))
-				slog.Info(err.Error())
-				c.Redirect(http.StatusFound, "/products")
-				c.Abort()
-				return
+				slog.Warn(fmt.Sprintf("failed to list favorites in /products/search: %v", err))
+				favorites = map[string]bool{}
 			}
-			products = append(products, db.ListAllProductsRow{ID: p.ID,
-				Name:        p.Name,
-				Price:       p.Price,
-				Discount:    p.Discount,
-				Description: p.Description,
-				CreatedAt:   p.CreatedAt,
-				UpdatedAt:   p.UpdatedAt,
-				Type:        p.Type,
-				Category:    p.Category})
-		} else if productType != "" {
-			prods, err := dbQueries.ListAllProductsByType(c, productName)
-			if err != nil {
-				slog.Warn(fmt.Sprintf("failed to list products by type: %s", productType))
-				c.Redirect(http.StatusFound, "/products")
-				c.Abort()
-				return
+		}
+
+		err = views.SearchPage(query, results, favorites).Render(c.Request.Context(), c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /products/search: %v", err)
+		}
+	})
+
+	// GET /products/suggest?q=... returns the product names completing the search box.
+	router.GET("/products/suggest", func(c *gin.Context) {
+		names, err := s.Catalog.Suggest(c, c.Query("q"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("failed to suggest in /products/suggest : %v", err))
+			names = []string{}
+		}
+		c.JSON(http.StatusOK, names)
+	})
+
+	// GET /categories/:slug is the landing page of a category, listing the products of its
+	// whole subtree with the filters of the catalog.
+	router.GET("/categories/:slug", func(c *gin.Context) {
+		slug := c.Param("slug")
+		crumbs, err := s.Catalog.Breadcrumbs(c, slug)
+		if err != nil || len(crumbs) == 0 {
+			slog.Warn(fmt.Sprintf("Can't find category in /categories/:slug : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
+		}
+		category := crumbs[len(crumbs)-1]
+		catalog, ok := s.catalogPage(c, fmt.Sprintf("/categories/%s", slug), category.ID)
+		if !ok {
+			return
+		}
+		var subcategories []db.ListCategoriesRow
+		for _, sub := range catalog.Categories {
+			if sub.ParentID == category.ID {
+				subcategories = append(subcategories, sub)
 			}
-			for _, p := range prods {
-				products = append(products, db.ListAllProductsRow{ID: p.ID,
-					Name:        p.Name,
-					Price:       p.Price,
-					Discount:    p.Discount,
-					Description: p.Description,
-					CreatedAt:   p.CreatedAt,
-					UpdatedAt:   p.UpdatedAt,
-					Type:        p.Type,
-					Category:    p.Category})
-			}
-		} else {
-			products, err = dbQueries.ListAllProducts(c)
-			if err != nil {
-				slog.Info("Failed to list all products in /products")
-				slog.Warn(fmt.Sprintf("failed to list all products: %v", err))
+		}
+		err = views.CategoryPage(views.CategoryPageData{Breadcrumbs: crumbs,
+			Subcategories: subcategories,
+			Catalog:       catalog}).Render(c, c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /categories/:slug: %v", err)
+		}
+	})
+
+	// GET & POST /categories: admins manage the category tree and add categories.
+	router.GET("/categories", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		errMsg := ""
+		if c.Query("error") == "in_use" {
+			errMsg = ErrCategoryInUse.Error()
+		}
+		s.renderCategoriesPage(c, errMsg)
+	})
+
+	router.POST("/categories", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		categoryForm, img, errMsg, ok := s.bindCategoryForm(c)
+		if !ok {
+			s.renderCategoriesPage(c, errMsg)
+			return
+		}
+		_, err := s.Catalog.CreateCategory(c, categoryForm, img)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create category in /categories : %v", err))
+			if img != "" {
+				s.Catalog.RemoveImage(img)
+			}
+			s.renderCategoriesPage(c, categoryErrMsg(err))
+			return
+		}
+		c.Redirect(http.StatusFound, "/categories")
+	})
+
+	// GET & POST /categories/:slug/edit.
+	router.GET("/categories/:slug/edit", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		category, err := s.Catalog.Category(c, c.Param("slug"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't find category in /categories/:slug/edit : %v", err))
+			c.Redirect(http.StatusFound, "/categories")
+			return
+		}
+		s.renderEditCategoryPage(c, category, "")
+	})
+
+	router.POST("/categories/:slug/edit", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		category, err := s.Catalog.Category(c, c.Param("slug"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't find category in /categories/:slug/edit : %v", err))
+			c.Redirect(http.StatusFound, "/categories")
+			return
+		}
+		categoryForm, img, errMsg, ok := s.bindCategoryForm(c)
+		if !ok {
+			s.renderEditCategoryPage(c, category, errMsg)
+			return
+		}
+		err = s.Catalog.UpdateCategory(c, category, categoryForm, img)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't update category in /categories/:slug/edit : %v", err))
+			if img != "" {
+				s.Catalog.RemoveImage(img)
+			}
+			s.renderEditCategoryPage(c, category, categoryErrMsg(err))
+			return
+		}
+		c.Redirect(http.StatusFound, "/categories")
+	})
+
+	// POST /categories/:slug/delete removes a category without subcategories and products.
+	router.POST("/categories/:slug/delete", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		err := s.Catalog.DeleteCategory(c, c.Param("slug"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't delete category in /categories/:slug/delete : %v", err))
+			if errors.Is(err, ErrCategoryInUse) {
+				c.Redirect(http.StatusFound, "/categories?error=in_use")
 				return
 			}
 		}
+		c.Redirect(http.StatusFound, "/categories")
+	})
 
-		err = views.ProductsPage(products).Render(c.Request.Context(), c.Writer)
+	// POST /categories/:slug/attributes defines an attribute for the products of a category
+	// and its subcategories.
+	router.POST("/categories/:slug/attributes", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		category, err := s.Catalog.Category(c, c.Param("slug"))
 		if err != nil {
-			log.Fatalf("failed to render in /products: %v", err)
+			slog.Warn(fmt.Sprintf("Can't find category in /categories/:slug/attributes : %v", err))
+			c.Redirect(http.StatusFound, "/categories")
+			return
+		}
+		attributeForm, errMsg, ok := s.bindAttributeForm(c)
+		if !ok {
+			s.renderEditCategoryPage(c, category, errMsg)
+			return
+		}
+		_, err = s.Catalog.CreateAttribute(c, category, attributeForm)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create attribute in /categories/:slug/attributes : %v", err))
+			s.renderEditCategoryPage(c, category, attributeErrMsg(err))
+			return
 		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/categories/%s/edit", category.Slug))
 	})
 
-	router.POST("/products/search", func(c *gin.Context) {
-		c.Redirect(http.StatusFound, "/products")
+	// POST /categories/:slug/attributes/:attribute/edit changes an attribute, except its slug and kind.
+	router.POST("/categories/:slug/attributes/:attribute/edit", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		category, err := s.Catalog.Category(c, c.Param("slug"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't find category in /categories/:slug/attributes/:attribute/edit : %v", err))
+			c.Redirect(http.StatusFound, "/categories")
+			return
+		}
+		attribute, err := s.categoryAttribute(c, category)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't find attribute in /categories/:slug/attributes/:attribute/edit : %v", err))
+			c.Redirect(http.StatusFound, fmt.Sprintf("/categories/%s/edit", category.Slug))
+			return
+		}
+		attributeForm, errMsg, ok := s.bindAttributeForm(c, "Slug", "Kind")
+		if !ok {
+			s.renderEditCategoryPage(c, category, errMsg)
+			return
+		}
+		err = s.Catalog.UpdateAttribute(c, attribute, attributeForm)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't update attribute in /categories/:slug/attributes/:attribute/edit : %v", err))
+			s.renderEditCategoryPage(c, category, attributeErrMsg(err))
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/categories/%s/edit", category.Slug))
+	})
+
+	// POST /categories/:slug/attributes/:attribute/delete removes an attribute and the values products have for it.
+	router.POST("/categories/:slug/attributes/:attribute/delete", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		category, err := s.Catalog.Category(c, c.Param("slug"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't find category in /categories/:slug/attributes/:attribute/delete : %v", err))
+			c.Redirect(http.StatusFound, "/categories")
+			return
+		}
+		attribute, err := s.categoryAttribute(c, category)
+		if err == nil {
+			err = s.Catalog.DeleteAttribute(c, attribute)
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't delete attribute in /categories/:slug/attributes/:attribute/delete : %v", err))
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/categories/%s/edit", category.Slug))
 	})
 
 	// GET & POST /products/create.
-	router.GET("/products/create", authMiddleware(), adminMiddleware(), func(c *gin.Context) {
-		categories, err := dbQueries.ListAllCategoryTags(c)
+	router.GET("/products/create", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		categories, err := s.Catalog.Categories(c)
 		if err != nil {
 			slog.Warn(err.Error())
-			categories = []db.ListAllCategoryTagsRow{}
+			categories = []db.ListCategoriesRow{}
+		}
+		attributes, err := s.Catalog.Attributes(c)
+		if err != nil {
+			attributes = []db.ListAttributesRow{}
 		}
-		err = views.CreateProductPage(categories, "").Render(c.Request.Context(), c.Writer)
+		err = views.CreateProductPage(categories, attributes, "").Render(c.Request.Context(), c.Writer)
 		if err != nil {
 			log.Fatalf("failed to render in /products/create: %v", err)
 		}
 	})
 
-	router.POST("/products/create", authMiddleware(), adminMiddleware(), func(c *gin.Context) {
-		categories, err := dbQueries.ListAllCategoryTags(c)
+	router.POST("/products/create", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		categories, err := s.Catalog.Categories(c)
+		if err != nil {
+			categories = []db.ListCategoriesRow{}
+		}
+		attributes, err := s.Catalog.Attributes(c)
 		if err != nil {
-			categories = []db.ListAllCategoryTagsRow{}
+			attributes = []db.ListAttributesRow{}
 		}
 
 		var productForm ProductCreateEdit
 		err = c.ShouldBind(&productForm)
+		productForm.Attributes = formAttributes(c)
 		if err != nil {
 			slog.Warn(err.Error())
-			err = views.CreateProductPage(categories, "wrong fields").Render(c.Request.Context(), c.Writer)
+			err = views.CreateProductPage(categories, attributes, "wrong fields").Render(c.Request.Context(), c.Writer)
 			if err != nil {
 				log.Fatalf("failed to render in /products/create: %v", err)
 			}
 			return
 		}
-		err = validate.Struct(productForm)
+		err = s.validate.Struct(productForm)
 		formErrMsg := ""
 		if err != nil {
 			for _, err := range err.(validator.ValidationErrors) {
@@ -168,7 +440,7 @@ func StartServer() {
 				formErrMsg += curr
 				slog.Warn(curr)
 			}
-			err = views.CreateProductPage(categories, formErrMsg).Render(c.Request.Context(), c.Writer)
+			err = views.CreateProductPage(categories, attributes, formErrMsg).Render(c.Request.Context(), c.Writer)
 			if err != nil {
 				log.Fatalf("failed to render in /products/create: %v", err)
 			}
@@ -178,88 +450,42 @@ func StartServer() {
 		file, err := c.FormFile("file")
 		if err != nil {
 			slog.Warn(err.Error())
-			err = views.CreateProductPage(categories, err.Error()).Render(c.Request.Context(), c.Writer)
-			if err != nil {
-				log.Fatalf("failed to render in /products/create: %v", err)
-			}
-			return
-		}
-
-		uploadDir := "./upload"
-
-		ext := filepath.Ext(file.Filename)
-		if ext != ".svg" && ext != ".jpeg" && ext != ".jpg" && ext != ".png" {
-			err = views.CreateProductPage(categories, "File must be an image").Render(c.Request.Context(), c.Writer)
+			err = views.CreateProductPage(categories, attributes, err.Error()).Render(c.Request.Context(), c.Writer)
 			if err != nil {
 				log.Fatalf("failed to render in /products/create: %v", err)
 			}
 			return
 		}
-
-		newFileName := fmt.Sprintf("IMG-%d%s", time.Now().Unix(), ext)
-		dst := filepath.Join(uploadDir, newFileName)
-
-		if err := c.SaveUploadedFile(file, dst); err != nil {
+		img, err := s.Catalog.SaveImage(file)
+		if err != nil {
 			slog.Warn(err.Error())
-			err = views.CreateProductPage(categories, "failed to save file").Render(c.Request.Context(), c.Writer)
+			errMsg := "failed to save file"
+			if errors.Is(err, ErrInvalidImage) {
+				errMsg = "File must be an image"
+			}
+			err = views.CreateProductPage(categories, attributes, errMsg).Render(c.Request.Context(), c.Writer)
 			if err != nil {
 				log.Fatalf("failed to render in /products/create: %v", err)
 			}
 			return
 		}
-		log.Println("Uploaded:", dst)
+		log.Println("Uploaded:", img)
 
-		priceNumeric := pgtype.Numeric{}
-		err = priceNumeric.Scan(productForm.Price)
+		_, err = s.Catalog.CreateProduct(c, productForm, img)
 		if err != nil {
 			slog.Warn(err.Error())
-			_ = os.Remove(dst)
-			err = views.CreateProductPage(categories, "Failed to get price").Render(c.Request.Context(), c.Writer)
-			if err != nil {
-				log.Fatalf("failed to render in /products/create: %v", err)
+			s.Catalog.RemoveImage(img)
+			errMsg := "Failed to create product try again!"
+			if errors.Is(err, ErrInvalidPrice) {
+				errMsg = "Failed to get price"
 			}
-			return
-		}
-		typeTag, err := dbQueries.GetTagByName(c, productForm.Type)
-		if err != nil {
-			typeTag, err = dbQueries.CreateTag(c, productForm.Type)
-			if err != nil {
-				slog.Warn(err.Error())
-				_ = os.Remove(dst)
-				err = views.CreateProductPage(categories, "Failed create type").Render(c.Request.Context(), c.Writer)
-				if err != nil {
-					log.Fatalf("failed to render in /products/create: %v", err)
-				}
-				return
+			if errors.Is(err, ErrUnknownCategory) {
+				errMsg = "Unknown category"
 			}
-		}
-
-		categoryTag, err := dbQueries.GetTagByName(c, productForm.Category)
-		if err != nil {
-			categoryTag, err = dbQueries.CreateTag(c, productForm.Category)
-			if err != nil {
-				slog.Warn(err.Error())
-				_ = os.Remove(dst)
-				err = views.CreateProductPage(categories, "Failed create category").Render(c.Request.Context(), c.Writer)
-				if err != nil {
-					log.Fatalf("failed to render in /products/create: %v", err)
-				}
-				return
+			if errors.Is(err, ErrInvalidAttributeValue) {
+				errMsg = err.Error()
 			}
-		}
-
-		dbProduct := db.CreateProductParams{Name: productForm.Name,
-			Price:       priceNumeric,
-			Description: pgtype.Text{String: productForm.Description, Valid: true},
-			Type:        typeTag.ID,
-			Category:    categoryTag.ID,
-			Img:         newFileName,
-		}
-		err = dbQueries.CreateProduct(c, dbProduct)
-		if err != nil {
-			slog.Warn(err.Error())
-			_ = os.Remove(dst)
-			err = views.CreateProductPage(categories, "Failed to create product try again!").Render(c.Request.Context(), c.Writer)
+			err = views.CreateProductPage(categories, attributes, errMsg).Render(c.Request.Context(), c.Writer)
 			if err != nil {
 				log.Fatalf("failed to render in /products/create: %v", err)
 			}
@@ -269,43 +495,15 @@ func StartServer() {
 		c.Redirect(http.StatusFound, "/profile")
 	})
 
-	router.GET("/cart", authMiddleware(), func(c *gin.Context) {
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+	router.GET("/cart", s.authMiddleware(), func(c *gin.Context) {
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
-			slog.Warn(fmt.Sprintf("sessionStore.Get error: %v", err))
+			slog.Warn(fmt.Sprintf("session error: %v", err))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/"))
 			return
 		}
 
-		shoppingList, ok := session.Values["shoppingList"].([]struct {
-			ID       string
-			Quantity int
-		})
-		if !ok {
-			shoppingList = []struct {
-				ID       string
-				Quantity int
-			}{}
-		}
-		var products []db.GetProductByIdRow
-		var quants []int
-		for _, shopping := range shoppingList {
-			productId, err := StrToUUID(shopping.ID)
-			if err != nil {
-				continue
-			}
-
-			product, err := dbQueries.GetProductById(c, productId)
-			if err != nil {
-				continue
-			}
-			products = append(products, product)
-			quants = append(quants, shopping.Quantity)
-		}
-		err = views.CartPage(products, quants).Render(c, c.Writer)
-		if err != nil {
-			log.Fatalf("failed to render in /cart: %v", err)
-		}
+		s.renderCartPage(c, getShoppingList(session), "")
 	})
 
 	// GET & DELETE /products/:id.
@@ -318,16 +516,13 @@ func StartServer() {
 			return
 		}
 
-		product, err := dbQueries.GetProductById(c, productId)
+		product, err := s.Catalog.Product(c, productId)
 		if err != nil {
 			return
 		}
-		err = views.ProductPage(product).Render(c, c.Writer)
-		if err != nil {
-			log.Fatalf("failed to render in /products/view: %v", err)
-		}
+		s.renderProductPage(c, product, "")
 	})
-	router.GET("/products/:id/delete", authMiddleware(), adminMiddleware(), func(c *gin.Context) {
+	router.GET("/products/:id/delete", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
 		productId, err := StrToUUID(id)
 		if err != nil {
@@ -336,7 +531,7 @@ func StartServer() {
 			c.Abort()
 			return
 		}
-		err = dbQueries.DeleteProduct(c, productId)
+		err = s.Catalog.DeleteProduct(c, productId)
 		if err != nil {
 			slog.Warn(err.Error())
 		}
@@ -345,35 +540,35 @@ func StartServer() {
 	})
 
 	// POST /products/:id/buy saves the current shopping list in the session.
-	router.POST("/products/:id/buy", authMiddleware(), func(c *gin.Context) {
+	router.POST("/products/:id/buy", s.authMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
-			slog.Warn(fmt.Sprintf("sessionStore.Get error: %v", err))
+			slog.Warn(fmt.Sprintf("session error: %v", err))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
 			return
 		}
 
-		shoppingList, ok := session.Values["shoppingList"].([]struct {
-			ID       string
-			Quantity int
-		})
-		if !ok {
-			shoppingList = []struct {
-				ID       string
-				Quantity int
-			}{}
-		}
+		shoppingList := getShoppingList(session)
 		quantity, err := strconv.Atoi(c.PostForm("quantity"))
 		if quantity < 1 || err != nil {
 			quantity = 1
 		}
-		shoppingList = append(shoppingList, struct {
-			ID       string
-			Quantity int
-		}{ID: id, Quantity: quantity})
+		productId, err := StrToUUID(id)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/buy : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
+		}
+		_, err = s.Cart.CheckStock(c, productId, quantity)
+		if err != nil {
+			slog.Info(fmt.Sprintf("From /products/:id/buy: %v", err))
+			c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
+			return
+		}
+		shoppingList = append(shoppingList, CartItem{ID: id, Quantity: quantity})
 		session.Values["shoppingList"] = shoppingList
-		if err := sessionStore.Save(c.Request, c.Writer, session); err != nil {
+		if err := s.sessions.Save(c.Request, c.Writer, session); err != nil {
 			slog.Warn(err.Error())
 			c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
 			return
@@ -381,60 +576,109 @@ func StartServer() {
 		c.Redirect(http.StatusFound, "/")
 	})
 
-	// GET & POST /products/:id/edit.
-	router.GET("/products/:id/edit", authMiddleware(), adminMiddleware(), func(c *gin.Context) {
+	// POST /products/:id/favorite saves the product to the favorites or removes it from them.
+	router.POST("/products/:id/favorite", s.authMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
-		categories, err := dbQueries.ListAllCategoryTags(c)
+		next := localRedirect(c.PostForm("next"), fmt.Sprintf("/products/%s", id))
+		productId, err := StrToUUID(id)
 		if err != nil {
-			categories = []db.ListAllCategoryTagsRow{}
+			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/favorite : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
 		}
-		pid, err := StrToUUID(id)
+		userID, err := StrToUUID(c.GetString("userID"))
 		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /products/:id/favorite : %v", err))
+			c.Redirect(http.StatusFound, "/login")
 			return
 		}
-		product, err := dbQueries.GetProductById(c, pid)
+		err = s.Catalog.ToggleFavorite(c, userID, productId)
 		if err != nil {
-			slog.Warn(fmt.Sprintf("Product not found /products/%s/edit: %v", id, err))
-			c.Redirect(http.StatusFound, "/")
-			c.Abort()
+			slog.Warn(fmt.Sprintf("Can't toggle favorite /products/:id/favorite : %v", err))
+		}
+		c.Redirect(http.StatusFound, next)
+	})
+
+	// GET /favorites lists the products the user saved.
+	router.GET("/favorites", s.authMiddleware(), func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /favorites : %v", err))
+			c.Redirect(http.StatusFound, "/login")
 			return
 		}
+		favorites, err := s.Catalog.Favorites(c, userID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list favorites in /favorites : %v", err))
+			favorites = []db.ListAllProductsRow{}
+		}
+		errMsg := ""
+		if c.Query("error") == "out_of_stock" {
+			errMsg = "This product is out of stock"
+		}
+		err = views.FavoritesPage(favorites, errMsg).Render(c, c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /favorites: %v", err)
+		}
+	})
 
-		err = views.EditProductPage(product, categories, "").Render(c, c.Writer)
+	// POST /favorites/:id/cart moves a saved product to the shopping list.
+	router.POST("/favorites/:id/cart", s.authMiddleware(), func(c *gin.Context) {
+		productId, err := StrToUUID(c.Param("id"))
 		if err != nil {
-			log.Fatalf("failed to render in /products/edit: %v", err)
+			slog.Warn(fmt.Sprintf("Id is not UUID in /favorites/:id/cart : %v", err))
+			c.Redirect(http.StatusFound, "/favorites")
+			return
+		}
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /favorites/:id/cart : %v", err))
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+		err = s.moveFavoriteToCart(c, userID, productId)
+		if errors.Is(err, inventory.ErrOutOfStock) {
+			slog.Info(fmt.Sprintf("From /favorites/:id/cart: %v", err))
+			c.Redirect(http.StatusFound, "/favorites?error=out_of_stock")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't move favorite to cart /favorites/:id/cart : %v", err))
+			c.Redirect(http.StatusFound, "/favorites")
+			return
 		}
+		c.Redirect(http.StatusFound, "/cart")
 	})
 
-	router.POST("/products/:id/edit", func(c *gin.Context) {
-		var categories []db.ListAllCategoryTagsRow
-		var productForm ProductCreateEdit
+	// POST /products/:id/reviews adds a star rating review by a customer who received the product.
+	router.POST("/products/:id/reviews", s.authMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
-		pid, err := StrToUUID(id)
+		productId, err := StrToUUID(id)
 		if err != nil {
-			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/edit : %v", err))
-			c.Redirect(http.StatusFound, "/profile")
-			c.Abort()
+			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/reviews : %v", err))
+			c.Redirect(http.StatusFound, "/products")
 			return
 		}
-		product, err := dbQueries.GetProductById(c, pid)
+		product, err := s.Catalog.Product(c, productId)
 		if err != nil {
-			slog.Warn(fmt.Sprintf("Such product doesn't exist /products/:id/edit : %v", err))
-			c.Redirect(http.StatusFound, "/profile")
-			c.Abort()
+			slog.Warn(fmt.Sprintf("Such product doesn't exist /products/:id/reviews : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
+		}
+		user, ok := s.sessionUser(c)
+		if !ok {
+			c.Redirect(http.StatusFound, "/login")
 			return
 		}
 
-		err = c.ShouldBind(&productForm)
+		var reviewForm ReviewCreate
+		err = c.ShouldBind(&reviewForm)
 		if err != nil {
 			slog.Warn(err.Error())
-			err = views.EditProductPage(product, categories, "wrong fields").Render(c.Request.Context(), c.Writer)
-			if err != nil {
-				log.Fatalf("failed to render in /products/:id/create : %v", err)
-			}
+			s.renderProductPage(c, product, "wrong fields")
 			return
 		}
-		err = validate.Struct(productForm)
+		err = s.validate.Struct(reviewForm)
 		formErrMsg := ""
 		if err != nil {
 			for _, err := range err.(validator.ValidationErrors) {
@@ -442,112 +686,367 @@ func StartServer() {
 				formErrMsg += curr
 				slog.Warn(curr)
 			}
-			err = views.EditProductPage(product, categories, formErrMsg).Render(c.Request.Context(), c.Writer)
-			if err != nil {
-				log.Fatalf("failed to render in /products/:id/edit : %v", err)
-			}
+			s.renderProductPage(c, product, formErrMsg)
 			return
 		}
 
-		file, err := c.FormFile("file")
-		var dst string
-		var newFileName string
-		if err == nil {
-			uploadDir := "./upload"
+		err = s.Catalog.AddReview(c, user, productId, reviewForm)
+		if errors.Is(err, ErrCannotReview) {
+			slog.Info(fmt.Sprintf("From /products/:id/reviews: %s can't review %s", user.ID.String(), id))
+			s.renderProductPage(c, product, "Only customers with a completed order of this product can review it, once")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create review /products/:id/reviews : %v", err))
+			s.renderProductPage(c, product, "Can't add the review")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
+	})
 
-			ext := filepath.Ext(file.Filename)
-			if ext != ".svg" && ext != ".jpeg" && ext != ".jpg" && ext != ".png" {
-				err = views.EditProductPage(product, categories, "File must be an image").Render(c.Request.Context(), c.Writer)
-				if err != nil {
-					log.Fatalf("failed to render in /products/edit: %v", err)
-				}
-				return
+	// POST /products/:id/questions asks a public question about a product.
+	router.POST("/products/:id/questions", s.authMiddleware(), func(c *gin.Context) {
+		id := c.Param("id")
+		productId, err := StrToUUID(id)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/questions : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
+		}
+		product, err := s.Catalog.Product(c, productId)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Such product doesn't exist /products/:id/questions : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
+		}
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /products/:id/questions : %v", err))
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+
+		var questionForm QuestionCreate
+		err = c.ShouldBind(&questionForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			s.renderProductPage(c, product, "wrong fields")
+			return
+		}
+		err = s.validate.Struct(questionForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
 			}
+			s.renderProductPage(c, product, formErrMsg)
+			return
+		}
+
+		err = s.Catalog.AskQuestion(c, userID, productId, questionForm)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create question /products/:id/questions : %v", err))
+			s.renderProductPage(c, product, "Can't add the question")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
+	})
 
-			newFileName = fmt.Sprintf("IMG-%d%s", time.Now().Unix(), ext)
-			dst = filepath.Join(uploadDir, newFileName)
+	// POST /products/:id/questions/:questionId/answer lets support staff answer a question.
+	router.POST("/products/:id/questions/:questionId/answer", s.authMiddleware(), s.supportMiddleware(), func(c *gin.Context) {
+		id := c.Param("id")
+		productId, err := StrToUUID(id)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/questions/:questionId/answer : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
+		}
+		product, err := s.Catalog.Product(c, productId)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Such product doesn't exist /products/:id/questions/:questionId/answer : %v", err))
+			c.Redirect(http.StatusFound, "/products")
+			return
+		}
+		questionId, err := StrToUUID(c.Param("questionId"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Question id is not UUID in /products/:id/questions/:questionId/answer : %v", err))
+			c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
+			return
+		}
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /products/:id/questions/:questionId/answer : %v", err))
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
 
-			if err := c.SaveUploadedFile(file, dst); err != nil {
-				slog.Warn(err.Error())
-				err = views.EditProductPage(product, categories, "failed to save file").Render(c.Request.Context(), c.Writer)
-				if err != nil {
-					log.Fatalf("failed to render in /products/create: %v", err)
-				}
-				return
+		var answerForm QuestionAnswer
+		err = c.ShouldBind(&answerForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			s.renderProductPage(c, product, "wrong fields")
+			return
+		}
+		err = s.validate.Struct(answerForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
 			}
-			log.Println("Uploaded:", dst)
+			s.renderProductPage(c, product, formErrMsg)
+			return
+		}
+
+		err = s.Catalog.AnswerQuestion(c, userID, productId, questionId, answerForm)
+		if errors.Is(err, ErrNoSuchQuestion) {
+			slog.Warn(fmt.Sprintf("Such question doesn't exist /products/:id/questions/:questionId/answer : %v", err))
+			c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't answer question /products/:id/questions/:questionId/answer : %v", err))
+			s.renderProductPage(c, product, "Can't answer the question")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s", id))
+	})
+
+	// GET & POST /products/:id/edit.
+	router.GET("/products/:id/edit", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		id := c.Param("id")
+		categories, err := s.Catalog.Categories(c)
+		if err != nil {
+			categories = []db.ListCategoriesRow{}
+		}
+		attributes, err := s.Catalog.Attributes(c)
+		if err != nil {
+			attributes = []db.ListAttributesRow{}
+		}
+		pid, err := StrToUUID(id)
+		if err != nil {
+			return
+		}
+		product, err := s.Catalog.Product(c, pid)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Product not found /products/%s/edit: %v", id, err))
+			c.Redirect(http.StatusFound, "/")
+			c.Abort()
+			return
+		}
+
+		values, err := s.Catalog.ProductAttributes(c, pid)
+		if err != nil {
+			values = []db.ListProductAttributeValuesRow{}
+		}
+		movements, err := s.Catalog.StockMovements(c, pid)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list stock movements /products/%s/edit: %v", id, err))
+			movements = []db.StockMovement{}
+		}
 
+		err = views.EditProductPage(product, categories, attributes, values, movements, "").Render(c, c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /products/edit: %v", err)
+		}
+	})
+
+	router.POST("/products/:id/edit", func(c *gin.Context) {
+		categories, err := s.Catalog.Categories(c)
+		if err != nil {
+			categories = []db.ListCategoriesRow{}
+		}
+		attributes, err := s.Catalog.Attributes(c)
+		if err != nil {
+			attributes = []db.ListAttributesRow{}
+		}
+		var productForm ProductCreateEdit
+		id := c.Param("id")
+		pid, err := StrToUUID(id)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/edit : %v", err))
+			c.Redirect(http.StatusFound, "/profile")
+			c.Abort()
+			return
+		}
+		product, err := s.Catalog.Product(c, pid)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Such product doesn't exist /products/:id/edit : %v", err))
+			c.Redirect(http.StatusFound, "/profile")
+			c.Abort()
+			return
+		}
+		values, err := s.Catalog.ProductAttributes(c, pid)
+		if err != nil {
+			values = []db.ListProductAttributeValuesRow{}
+		}
+		movements, err := s.Catalog.StockMovements(c, pid)
+		if err != nil {
+			movements = []db.StockMovement{}
 		}
 
-		priceNumeric := pgtype.Numeric{}
-		err = priceNumeric.Scan(productForm.Price)
+		err = c.ShouldBind(&productForm)
+		productForm.Attributes = formAttributes(c)
 		if err != nil {
 			slog.Warn(err.Error())
-			_ = os.Remove(dst)
-			err = views.EditProductPage(product, categories, "Failed to get price").Render(c.Request.Context(), c.Writer)
+			err = views.EditProductPage(product, categories, attributes, values, movements, "wrong fields").Render(c.Request.Context(), c.Writer)
 			if err != nil {
-				log.Fatalf("failed to render in /products/create: %v", err)
+				log.Fatalf("failed to render in /products/:id/create : %v", err)
 			}
 			return
 		}
-		typeTag, err := dbQueries.GetTagByName(c, productForm.Type)
+		err = s.validate.Struct(productForm)
+		formErrMsg := ""
 		if err != nil {
-			typeTag, err = dbQueries.CreateTag(c, productForm.Type)
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
+			}
+			err = views.EditProductPage(product, categories, attributes, values, movements, formErrMsg).Render(c.Request.Context(), c.Writer)
+			if err != nil {
+				log.Fatalf("failed to render in /products/:id/edit : %v", err)
+			}
+			return
+		}
+
+		newFileName := ""
+		file, err := c.FormFile("file")
+		if err == nil {
+			newFileName, err = s.Catalog.SaveImage(file)
 			if err != nil {
 				slog.Warn(err.Error())
-				_ = os.Remove(dst)
-				err = views.EditProductPage(product, categories, "Failed create type").Render(c.Request.Context(), c.Writer)
+				errMsg := "failed to save file"
+				if errors.Is(err, ErrInvalidImage) {
+					errMsg = "File must be an image"
+				}
+				err = views.EditProductPage(product, categories, attributes, values, movements, errMsg).Render(c.Request.Context(), c.Writer)
 				if err != nil {
-					log.Fatalf("failed to render in /products/create: %v", err)
+					log.Fatalf("failed to render in /products/edit: %v", err)
 				}
 				return
 			}
+			log.Println("Uploaded:", newFileName)
 		}
 
-		categoryTag, err := dbQueries.GetTagByName(c, productForm.Category)
+		err = s.Catalog.UpdateProduct(c, product, productForm, newFileName)
 		if err != nil {
-			categoryTag, err = dbQueries.CreateTag(c, productForm.Category)
+			slog.Warn(err.Error())
+			if newFileName != "" {
+				s.Catalog.RemoveImage(newFileName)
+			}
+			errMsg := "Failed to update product try again!"
+			if errors.Is(err, ErrInvalidPrice) {
+				errMsg = "Failed to get price"
+			}
+			if errors.Is(err, ErrUnknownCategory) {
+				errMsg = "Unknown category"
+			}
+			if errors.Is(err, ErrInvalidAttributeValue) {
+				errMsg = err.Error()
+			}
+			err = views.EditProductPage(product, categories, attributes, values, movements, errMsg).Render(c.Request.Context(), c.Writer)
 			if err != nil {
-				slog.Warn(err.Error())
-				_ = os.Remove(dst)
-				err = views.EditProductPage(product, categories, "Failed create category").Render(c.Request.Context(), c.Writer)
-				if err != nil {
-					log.Fatalf("failed to render in /products/create: %v", err)
-				}
-				return
+				log.Fatalf("failed to render in /products/edit: %v", err)
 			}
+			return
 		}
 
-		dbProduct := db.UpdateProductParams{Name: productForm.Name,
-			Price:       priceNumeric,
-			Description: pgtype.Text{String: productForm.Description, Valid: true},
-			Type:        typeTag.ID,
-			Category:    categoryTag.ID,
-			Img:         newFileName,
+		c.Redirect(http.StatusFound, "/profile")
+	})
+
+	// POST /products/:id/stock records a stock receipt or manual adjustment.
+	router.POST("/products/:id/stock", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		id := c.Param("id")
+		pid, err := StrToUUID(id)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Id is not UUID in /products/:id/stock : %v", err))
+			c.Redirect(http.StatusFound, "/profile")
+			return
+		}
+		product, err := s.Catalog.Product(c, pid)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Such product doesn't exist /products/:id/stock : %v", err))
+			c.Redirect(http.StatusFound, "/profile")
+			return
+		}
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /products/:id/stock : %v", err))
+			c.Redirect(http.StatusFound, "/profile")
+			return
+		}
+		categories, err := s.Catalog.Categories(c)
+		if err != nil {
+			categories = []db.ListCategoriesRow{}
+		}
+		attributes, err := s.Catalog.Attributes(c)
+		if err != nil {
+			attributes = []db.ListAttributesRow{}
+		}
+		values, err := s.Catalog.ProductAttributes(c, pid)
+		if err != nil {
+			values = []db.ListProductAttributeValuesRow{}
+		}
+		movements, err := s.Catalog.StockMovements(c, pid)
+		if err != nil {
+			movements = []db.StockMovement{}
 		}
-		err = dbQueries.UpdateProduct(c, dbProduct)
+
+		var stockForm StockAdjust
+		err = c.ShouldBind(&stockForm)
 		if err != nil {
 			slog.Warn(err.Error())
-			_ = os.Remove(dst)
-			err = views.EditProductPage(product, categories, "Failed to update product try again!").Render(c.Request.Context(), c.Writer)
+			err = views.EditProductPage(product, categories, attributes, values, movements, "wrong fields").Render(c.Request.Context(), c.Writer)
 			if err != nil {
-				log.Fatalf("failed to render in /products/create: %v", err)
+				log.Fatalf("failed to render in /products/:id/stock : %v", err)
+			}
+			return
+		}
+		err = s.validate.Struct(stockForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
+			}
+			err = views.EditProductPage(product, categories, attributes, values, movements, formErrMsg).Render(c.Request.Context(), c.Writer)
+			if err != nil {
+				log.Fatalf("failed to render in /products/:id/stock : %v", err)
 			}
 			return
 		}
 
-		c.Redirect(http.StatusFound, "/profile")
+		err = s.Catalog.AdjustStock(c, pid, userID, stockForm)
+		if err != nil {
+			errMsg := "Failed to adjust stock try again!"
+			if errors.Is(err, inventory.ErrNegativeStock) {
+				errMsg = "Stock can't go below zero"
+			} else {
+				slog.Warn(fmt.Sprintf("Can't adjust stock /products/:id/stock : %v", err))
+			}
+			err = views.EditProductPage(product, categories, attributes, values, movements, errMsg).Render(c.Request.Context(), c.Writer)
+			if err != nil {
+				log.Fatalf("failed to render in /products/:id/stock : %v", err)
+			}
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/products/%s/edit", id))
 	})
 
 	// GET /profile redirects to /users/:id based on session information.
-	router.GET("/profile", authMiddleware(), func(c *gin.Context) {
+	router.GET("/profile", s.authMiddleware(), func(c *gin.Context) {
 		userID := c.MustGet("userID")
 		c.Redirect(http.StatusFound, fmt.Sprintf("/users/%v", userID))
 	})
 
-	router.GET("/users/:id", authMiddleware(), func(c *gin.Context) {
+	router.GET("/users/:id", s.authMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("Can't get session in /users/:id/edit : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
@@ -572,7 +1071,7 @@ func StartServer() {
 			c.Abort()
 			return
 		}
-		user, err := dbQueries.GetUserById(c, uid)
+		user, err := s.Users.User(c, uid)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("Error on finding user /users/:id : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/"))
@@ -583,34 +1082,96 @@ func StartServer() {
 		var orders []db.Order
 		var users []db.ListAllUsersRow
 
-		products, err = dbQueries.ListAllProducts(c)
+		products, err = s.Catalog.Products(c, "", "")
 		if err != nil {
 			products = []db.ListAllProductsRow{}
 		}
-		orders, err = dbQueries.ListAllOrders(c)
+		orders, err = s.Orders.Orders(c)
 		if err != nil {
 			orders = []db.Order{}
 		}
-		users, err = dbQueries.ListAllUsers(c)
+		users, err = s.Users.Users(c)
 		if err != nil {
 			users = []db.ListAllUsersRow{}
 		}
-
-		chats, err := dbQueries.ListAllChats(c)
+		apiKeys, err := s.Users.APIKeys(c)
 		if err != nil {
-			chats = []db.Chat{}
+			apiKeys = []db.ListApiKeysRow{}
 		}
 
-		err = views.UserPage(user, user.Role == "admin", products, orders, users, chats).Render(c.Request.Context(), c.Writer)
+		err = views.UserPage(user, user.Role == "admin", products, orders, users, apiKeys, apiScopes).Render(c.Request.Context(), c.Writer)
 		if err != nil {
 			log.Fatalf("Can't render /users/:id : %v", err)
 		}
 	})
 
+	// POST /api-keys mints an API key for a machine client and shows it once.
+	router.POST("/api-keys", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /api-keys : %v", err))
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+
+		var keyForm ApiKeyCreate
+		err = c.ShouldBind(&keyForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			err = views.ApiKeyPage("", "Невалидни данни за ключа.").Render(c.Request.Context(), c.Writer)
+			if err != nil {
+				log.Fatalf("Can't render /api-keys : %v", err)
+			}
+			return
+		}
+		err = s.validate.Struct(keyForm)
+		if err != nil {
+			errMsg := ""
+			for _, err := range err.(validator.ValidationErrors) {
+				errMsg += fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+			}
+			slog.Warn(errMsg)
+			err = views.ApiKeyPage("", errMsg).Render(c.Request.Context(), c.Writer)
+			if err != nil {
+				log.Fatalf("Can't render /api-keys : %v", err)
+			}
+			return
+		}
+
+		key, err := s.Users.IssueAPIKey(c, userID, keyForm)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create API key in /api-keys : %v", err))
+			err = views.ApiKeyPage("", "Ключът не можа да бъде създаден.").Render(c.Request.Context(), c.Writer)
+			if err != nil {
+				log.Fatalf("Can't render /api-keys : %v", err)
+			}
+			return
+		}
+		err = views.ApiKeyPage(key, "").Render(c.Request.Context(), c.Writer)
+		if err != nil {
+			log.Fatalf("Can't render /api-keys : %v", err)
+		}
+	})
+
+	// POST /api-keys/:id/revoke deletes an API key, so it stops working immediately.
+	router.POST("/api-keys/:id/revoke", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
+		keyID, err := StrToUUID(c.Param("id"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Id is not UUID in /api-keys/:id/revoke : %v", err))
+			c.Redirect(http.StatusFound, "/profile")
+			return
+		}
+		err = s.Users.RevokeAPIKey(c, keyID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't revoke API key in /api-keys/:id/revoke : %v", err))
+		}
+		c.Redirect(http.StatusFound, "/profile")
+	})
+
 	// GET & POST /users/:id/edit.
-	router.GET("/users/:id/edit", authMiddleware(), func(c *gin.Context) {
+	router.GET("/users/:id/edit", s.authMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("Can't get session in /users/:id/edit : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
@@ -628,7 +1189,7 @@ func StartServer() {
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
 			return
 		}
-		requestUser, err := dbQueries.GetUserById(c, requestUid)
+		requestUser, err := s.Users.User(c, requestUid)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("No such user as requester in /users/:id/edit : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
@@ -647,7 +1208,7 @@ func StartServer() {
 			return
 		}
 
-		user, err := dbQueries.GetUserById(c, uid)
+		user, err := s.Users.User(c, uid)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("No such user in /users/:id/edit : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
@@ -660,9 +1221,9 @@ func StartServer() {
 			return
 		}
 	})
-	router.POST("/users/:id/edit", authMiddleware(), func(c *gin.Context) {
+	router.POST("/users/:id/edit", s.authMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("Can't get session in /users/:id/edit : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
@@ -680,7 +1241,7 @@ func StartServer() {
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
 			return
 		}
-		requestUser, err := dbQueries.GetUserById(c, requestUid)
+		requestUser, err := s.Users.User(c, requestUid)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("No such user as requester in /users/:id/edit : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
@@ -699,7 +1260,7 @@ func StartServer() {
 			return
 		}
 
-		user, err := dbQueries.GetUserById(c, uid)
+		user, err := s.Users.User(c, uid)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("No such user in /users/:id/edit : %v", err.Error()))
 			c.Redirect(http.StatusFound, fmt.Sprintf("/profile"))
@@ -709,35 +1270,26 @@ func StartServer() {
 		var userForm UserEdit
 		err = c.ShouldBind(&userForm)
 		if err != nil {
-			slog.Warn(fmt.Sprintf("Can't render /users/:id/edit : %v", err.Error()))
-			views.UserEditPage("can't get fields", user, requestUser.Role == "admin")
+			slog.Warn(fmt.Sprintf("Can't bind fields in /users/:id/edit : %v", err.Error()))
+			err = views.UserEditPage("can't get fields", user, requestUser.Role == "admin").Render(c, c.Writer)
+			if err != nil {
+				log.Fatalf("Can't render /users/:id/edit : %v", err)
+			}
 			return
 		}
 
-		_, err = dbQueries.UpdateUserNames(c, db.UpdateUserNamesParams{ID: uid,
-			Fname: userForm.FirstName,
-			Lname: userForm.LastName})
+		err = s.Users.Update(c, requestUser, uid, userForm, c.PostForm("role"))
 		if err != nil {
-			slog.Warn(fmt.Sprintf("Can't update user names /users/:id/edit : %v", err.Error()))
-			views.UserEditPage("can't update user names try again", user, requestUser.Role == "admin")
-			return
-		}
-		if requestUser.Role == "admin" {
-			roleForm := c.PostForm("role")
-			log.Println(roleForm, ok)
-			if roleForm != "admin" && roleForm != "user" {
-				slog.Warn(fmt.Sprintf("Can't update role Non DB /users/:id/edit"))
-				views.UserEditPage("User role is invalid", user, requestUser.Role == "admin")
-				return
+			slog.Warn(fmt.Sprintf("Can't update user in /users/:id/edit : %v", err.Error()))
+			errMsg := "can't update user try again"
+			if errors.Is(err, ErrInvalidRole) {
+				errMsg = "User role is invalid"
 			}
-			if suid != id {
-				_, err = dbQueries.UpdateUserRole(c, db.UpdateUserRoleParams{ID: uid, Role: db.UserRole(roleForm)})
-				if err != nil {
-					slog.Warn(fmt.Sprintf("Can't update role DB /users/:id/edit : %v", err.Error()))
-					views.UserEditPage("Can't update role try again", user, requestUser.Role == "admin")
-					return
-				}
+			err = views.UserEditPage(errMsg, user, requestUser.Role == "admin").Render(c, c.Writer)
+			if err != nil {
+				log.Fatalf("Can't render /users/:id/edit : %v", err)
 			}
+			return
 		}
 
 		c.Redirect(http.StatusFound, "/profile")
@@ -745,7 +1297,7 @@ func StartServer() {
 	})
 
 	// DELETE /users/:id.
-	router.GET("/users/:id/delete", authMiddleware(), adminMiddleware(), func(c *gin.Context) {
+	router.GET("/users/:id/delete", s.authMiddleware(), s.adminMiddleware(), func(c *gin.Context) {
 		id := c.Param("id")
 		uuid, err := StrToUUID(id)
 		if err != nil {
@@ -754,20 +1306,20 @@ func StartServer() {
 			c.Abort()
 			return
 		}
-		err = dbQueries.DeleteUser(c, uuid)
+		err = s.Users.Delete(c, uuid)
 		if err != nil {
 			slog.Warn(fmt.Sprintf("Error delete user: %v", err.Error()))
 		}
 	})
 
 	// GET & POST /login.
-	router.GET("/login", notAuthMiddleware(), func(c *gin.Context) {
-		err = views.LoginPage("").Render(c.Request.Context(), c.Writer)
+	router.GET("/login", s.notAuthMiddleware(), func(c *gin.Context) {
+		err := views.LoginPage("").Render(c.Request.Context(), c.Writer)
 		if err != nil {
 			log.Fatal(err)
 		}
 	})
-	router.POST("/login", notAuthMiddleware(), func(c *gin.Context) {
+	router.POST("/login", s.notAuthMiddleware(), func(c *gin.Context) {
 		var userForm UserLogin
 		err := c.ShouldBind(&userForm)
 		if err != nil {
@@ -778,7 +1330,7 @@ func StartServer() {
 			}
 		}
 
-		err = validate.Struct(userForm)
+		err = s.validate.Struct(userForm)
 		formErrMsg := ""
 		if err != nil {
 			for _, err := range err.(validator.ValidationErrors) {
@@ -792,26 +1344,21 @@ func StartServer() {
 			}
 			return
 		}
-		user, err := dbQueries.GetUserByEmail(c, userForm.Email)
+		userID, err := s.Users.Authenticate(c, userForm)
 		if err != nil {
 			slog.Warn(err.Error())
-			err = views.LoginPage("Email or password are wrong").Render(c.Request.Context(), c.Writer)
-			if err != nil {
-				log.Fatal(err)
+			errMsg := "Couldn't login try again"
+			if errors.Is(err, ErrWrongCredentials) {
+				errMsg = "Email or password are wrong"
 			}
-			return
-		}
-		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userForm.Password))
-		if err != nil {
-			slog.Warn(err.Error())
-			err = views.LoginPage("Email or password are wrong").Render(c.Request.Context(), c.Writer)
+			err = views.LoginPage(errMsg).Render(c.Request.Context(), c.Writer)
 			if err != nil {
 				log.Fatal(err)
 			}
 			return
 		}
 
-		session, err := sessionStore.New(c.Request, DefaultSessionName)
+		session, err := s.sessions.New(c.Request, s.cfg.SessionName)
 		if err != nil {
 			slog.Warn(err.Error())
 			err = views.RegisterPage("Couldn't login try again").Render(c.Request.Context(), c.Writer)
@@ -821,10 +1368,10 @@ func StartServer() {
 			return
 		}
 
-		session.Values["userID"] = user.ID.String()
+		session.Values["userID"] = userID.String()
 		log.Println("SID:")
 		log.Println(session.ID)
-		err = sessionStore.Save(c.Request, c.Writer, session)
+		err = s.sessions.Save(c.Request, c.Writer, session)
 		if err != nil {
 			slog.Warn(err.Error())
 			err = views.RegisterPage("Couldn't login try again").Render(c.Request.Context(), c.Writer)
@@ -834,20 +1381,20 @@ func StartServer() {
 			return
 		}
 
-		c.Set("userID", user.ID.String())
+		c.Set("userID", userID.String())
 		c.Redirect(http.StatusFound, "/")
 	})
 
 	// GET & POST /register.
-	router.GET("/register", notAuthMiddleware(), func(c *gin.Context) {
-		err = views.RegisterPage("").Render(c.Request.Context(), c.Writer)
+	router.GET("/register", s.notAuthMiddleware(), func(c *gin.Context) {
+		err := views.RegisterPage("").Render(c.Request.Context(), c.Writer)
 		if err != nil {
 			slog.Warn("From GET /register:")
 			log.Fatal(err)
 		}
 	})
 
-	router.POST("/register", notAuthMiddleware(), func(c *gin.Context) {
+	router.POST("/register", s.notAuthMiddleware(), func(c *gin.Context) {
 		var userForm UserRegister
 		err := c.ShouldBind(&userForm)
 		if err != nil {
@@ -858,7 +1405,7 @@ func StartServer() {
 			}
 		}
 
-		err = validate.Struct(userForm)
+		err = s.validate.Struct(userForm)
 		formErrMsg := ""
 		if err != nil {
 			for _, err := range err.(validator.ValidationErrors) {
@@ -872,16 +1419,20 @@ func StartServer() {
 			}
 			return
 		}
-		_, err = dbQueries.GetUserByEmail(c, userForm.Email)
-		if err == nil {
-			err = views.RegisterPage("Such user already exists").Render(c.Request.Context(), c.Writer)
+		createUser, err := s.Users.Register(c, userForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			errMsg := "Couldn't register try again"
+			if errors.Is(err, ErrUserExists) {
+				errMsg = "Such user already exists"
+			}
+			err = views.RegisterPage(errMsg).Render(c.Request.Context(), c.Writer)
 			if err != nil {
 				log.Fatal(err)
 			}
 			return
 		}
-
-		hash, err := bcrypt.GenerateFromPassword([]byte(userForm.Password), bcrypt.MinCost)
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
 			slog.Warn(err.Error())
 			err = views.RegisterPage("Couldn't register try again").Render(c.Request.Context(), c.Writer)
@@ -890,14 +1441,9 @@ func StartServer() {
 			}
 			return
 		}
-		user := db.CreateUserParams{
-			Email:    userForm.Email,
-			Fname:    userForm.FirstName,
-			Lname:    userForm.LastName,
-			Password: string(hash),
-			Role:     "user"}
 
-		createUser, err := dbQueries.CreateUser(c, user)
+		session.Values["userID"] = createUser.String()
+		err = s.sessions.Save(c.Request, c.Writer, session)
 		if err != nil {
 			slog.Warn(err.Error())
 			err = views.RegisterPage("Couldn't register try again").Render(c.Request.Context(), c.Writer)
@@ -906,76 +1452,617 @@ func StartServer() {
 			}
 			return
 		}
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+		c.Set("userID", createUser.String())
+
+		c.Redirect(http.StatusFound, "/")
+	})
+
+	router.GET("/logout", s.authMiddleware(), func(c *gin.Context) {
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
 		if err != nil {
 			slog.Warn(err.Error())
-			err = views.RegisterPage("Couldn't register try again").Render(c.Request.Context(), c.Writer)
+			c.Redirect(http.StatusFound, "/")
+			return
+		}
+		session.Options.MaxAge = -1
+		err = s.sessions.Save(c.Request, c.Writer, session)
+		if err != nil {
+			slog.Warn(err.Error())
+		}
+		c.Redirect(http.StatusFound, "/")
+	})
+
+	// POST /orders/create turns the shopping list into a pending order and empties the cart.
+	router.POST("/orders/create", s.authMiddleware(), func(c *gin.Context) {
+		session, err := s.sessions.Get(c.Request, s.cfg.SessionName)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("session error: %v", err))
+			c.Redirect(http.StatusFound, "/cart")
+			return
+		}
+		shoppingList := getShoppingList(session)
+		if len(shoppingList) == 0 {
+			slog.Info("From /orders/create: shopping list is empty")
+			c.Redirect(http.StatusFound, "/cart")
+			return
+		}
+
+		var orderForm OrderCreate
+		err = c.ShouldBind(&orderForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			s.renderCartPage(c, shoppingList, "wrong fields")
+			return
+		}
+		err = s.validate.Struct(orderForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
+			}
+			s.renderCartPage(c, shoppingList, formErrMsg)
+			return
+		}
+
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /orders/create : %v", err))
+			c.Redirect(http.StatusFound, "/cart")
+			return
+		}
+		orderID, err := s.Orders.Create(c, userID, orderForm, shoppingList)
+		if errors.Is(err, inventory.ErrOutOfStock) {
+			slog.Info(fmt.Sprintf("From /orders/create: %v", err))
+			s.renderCartPage(c, shoppingList, "Some products are out of stock, lower the quantity and try again")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create order in /orders/create : %v", err))
+			s.renderCartPage(c, shoppingList, "Failed to create order try again!")
+			return
+		}
+
+		session.Values["shoppingList"] = []CartItem{}
+		if err := s.sessions.Save(c.Request, c.Writer, session); err != nil {
+			slog.Warn(fmt.Sprintf("Can't clear shopping list in /orders/create : %v", err))
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/orders/%s", orderID.String()))
+	})
+
+	// GET /orders/events streams the status changes of the current user's orders as Server-Sent Events.
+	// A reconnecting browser sends the last change it saw as Last-Event-ID, or ?after= on the first
+	// connection, and gets every later one.
+	router.GET("/orders/events", s.authMiddleware(), func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /orders/events : %v", err))
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		client, ok := s.hub.Subscribe(ordersRoom(userID.String()), db.Chat{}, db.GetUserByIdRow{ID: userID})
+		if !ok {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		defer s.hub.Unsubscribe(client)
+
+		var backlog []ChatEvent
+		if lastID, err := StrToUUID(resumeID(c)); err == nil {
+			history, err := s.Orders.ChangesSince(c, userID, lastID)
 			if err != nil {
-				log.Fatal(err)
+				slog.Warn(fmt.Sprintf("Can't list missed order changes in /orders/events : %v", err))
 			}
+			for _, h := range history {
+				backlog = append(backlog, orderEvent(h))
+			}
+		}
+		streamEvents(c, client, backlog)
+	})
+
+	// GET /orders lists the order history of the current user.
+	router.GET("/orders", s.authMiddleware(), func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /orders : %v", err))
+			c.Redirect(http.StatusFound, "/")
 			return
 		}
+		orders, err := s.Orders.OrdersByUser(c, userID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list orders in /orders : %v", err))
+			orders = []db.Order{}
+		}
+		err = views.OrdersPage(orders).Render(c, c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /orders: %v", err)
+		}
+	})
 
-		session.Values["userID"] = createUser.String()
-		err = sessionStore.Save(c.Request, c.Writer, session)
+	// GET & POST /orders/:id restricted to order owner, admins and support.
+	router.GET("/orders/:id", s.authMiddleware(), s.orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
+		order := c.MustGet("order").(db.Order)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		s.renderOrderPage(c, order, user, "")
+	})
+
+	// POST /orders/:id moves the order to the requested status.
+	router.POST("/orders/:id", s.authMiddleware(), s.orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
+		order := c.MustGet("order").(db.Order)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+
+		var statusForm OrderStatusUpdate
+		err := c.ShouldBind(&statusForm)
 		if err != nil {
 			slog.Warn(err.Error())
-			err = views.RegisterPage("Couldn't register try again").Render(c.Request.Context(), c.Writer)
-			if err != nil {
-				log.Fatal(err)
+			s.renderOrderPage(c, order, user, "wrong fields")
+			return
+		}
+		err = s.validate.Struct(statusForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
 			}
+			s.renderOrderPage(c, order, user, formErrMsg)
 			return
 		}
-		c.Set("userID", createUser.String())
 
-		c.Redirect(http.StatusFound, "/")
+		if returnStatuses[order.Status] || returnStatuses[db.OrderType(statusForm.Status)] {
+			slog.Warn(fmt.Sprintf("Return status change outside the returns workflow in /orders/:id : %s", statusForm.Status))
+			s.renderOrderPage(c, order, user, "Returns are handled through the return request")
+			return
+		}
+		if deliveryStatuses[db.OrderType(statusForm.Status)] {
+			slog.Warn(fmt.Sprintf("Delivery status change outside the delivery workflow in /orders/:id : %s", statusForm.Status))
+			s.renderOrderPage(c, order, user, "Shipping and delivery are handled through the shipment")
+			return
+		}
+		err = s.Orders.ChangeStatus(c, order.ID, user, db.OrderType(statusForm.Status), statusForm.Note)
+		if errors.Is(err, orderstatus.ErrInvalidTransition) || errors.Is(err, orderstatus.ErrForbiddenTransition) {
+			slog.Warn(fmt.Sprintf("Rejected status change in /orders/:id : %v", err))
+			s.renderOrderPage(c, order, user, "Order can't move to this status")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't change order status in /orders/:id : %v", err))
+			s.renderOrderPage(c, order, user, "Failed to update order try again!")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/orders/%s", order.ID.String()))
+	})
+
+	// POST /orders/:id/return lets the customer request a return of a completed order.
+	router.POST("/orders/:id/return", s.authMiddleware(), s.orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
+		order := c.MustGet("order").(db.Order)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		if user.ID != order.UserID {
+			slog.Warn("From /orders/:id/return: only the order owner can request a return")
+			c.Redirect(http.StatusFound, fmt.Sprintf("/orders/%s", order.ID.String()))
+			return
+		}
+
+		var returnForm ReturnRequest
+		err := c.ShouldBind(&returnForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			s.renderOrderPage(c, order, user, "wrong fields")
+			return
+		}
+		err = s.validate.Struct(returnForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
+			}
+			s.renderOrderPage(c, order, user, formErrMsg)
+			return
+		}
+
+		items, _, err := s.Orders.Items(c, order.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't get order items in /orders/:id/return : %v", err))
+			s.renderOrderPage(c, order, user, "Failed to request return try again!")
+			return
+		}
+		lines, formErrMsg := parseReturnLines(c, items)
+		if formErrMsg != "" {
+			s.renderOrderPage(c, order, user, formErrMsg)
+			return
+		}
+
+		err = s.Orders.RequestReturn(c, order, user, returnForm.Statement, lines)
+		if errors.Is(err, orderstatus.ErrInvalidTransition) || errors.Is(err, orderstatus.ErrForbiddenTransition) {
+			slog.Warn(fmt.Sprintf("Rejected return request in /orders/:id/return : %v", err))
+			s.renderOrderPage(c, order, user, "Only completed orders can be returned")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't request return in /orders/:id/return : %v", err))
+			s.renderOrderPage(c, order, user, "Failed to request return try again!")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/orders/%s", order.ID.String()))
 	})
 
-	router.GET("/logout", authMiddleware(), func(c *gin.Context) {
-		session, err := sessionStore.Get(c.Request, DefaultSessionName)
+	// POST /orders/:id/return/approve & /reject review a return request.
+	router.POST("/orders/:id/return/approve", s.authMiddleware(), s.supportMiddleware(), s.orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
+		order := c.MustGet("order").(db.Order)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		refund, err := s.Orders.ApproveReturn(c, order, user)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't approve return in /orders/:id/return/approve : %v", err))
+			s.renderOrderPage(c, order, user, "Failed to approve return try again!")
+			return
+		}
+		refundValue, _ := refund.Float64Value()
+		slog.Info(fmt.Sprintf("Return of order %s approved with refund %.2f", order.ID.String(), refundValue.Float64))
+		c.Redirect(http.StatusFound, "/returns")
+	})
+	router.POST("/orders/:id/return/reject", s.authMiddleware(), s.supportMiddleware(), s.orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
+		order := c.MustGet("order").(db.Order)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		err := s.Orders.RejectReturn(c, order, user, c.PostForm("note"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't reject return in /orders/:id/return/reject : %v", err))
+			s.renderOrderPage(c, order, user, "Failed to reject return try again!")
+			return
+		}
+		c.Redirect(http.StatusFound, "/returns")
+	})
+
+	// POST /orders/:id/delivery creates the shipment of a paid order.
+	router.POST("/orders/:id/delivery", s.authMiddleware(), s.adminMiddleware(), s.orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
+		order := c.MustGet("order").(db.Order)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+
+		var deliveryForm DeliveryCreate
+		err := c.ShouldBind(&deliveryForm)
 		if err != nil {
 			slog.Warn(err.Error())
+			s.renderOrderPage(c, order, user, "wrong fields")
+			return
+		}
+		err = s.validate.Struct(deliveryForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
+			}
+			s.renderOrderPage(c, order, user, formErrMsg)
+			return
+		}
+
+		_, err = s.Orders.Ship(c, order.ID, user, deliveryForm)
+		if errors.Is(err, orderstatus.ErrInvalidTransition) {
+			slog.Warn(fmt.Sprintf("Rejected shipment in /orders/:id/delivery : %v", err))
+			s.renderOrderPage(c, order, user, "Only paid orders can be shipped")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create delivery in /orders/:id/delivery : %v", err))
+			s.renderOrderPage(c, order, user, "Failed to create shipment try again!")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/orders/%s", order.ID.String()))
+	})
+
+	// POST /orders/:id/delivery/status advances the shipment of an order.
+	router.POST("/orders/:id/delivery/status", s.authMiddleware(), s.adminMiddleware(), s.orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
+		order := c.MustGet("order").(db.Order)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+
+		var statusForm DeliveryStatusUpdate
+		err := c.ShouldBind(&statusForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			s.renderOrderPage(c, order, user, "wrong fields")
+			return
+		}
+		err = s.validate.Struct(statusForm)
+		formErrMsg := ""
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				curr := fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag())
+				formErrMsg += curr
+				slog.Warn(curr)
+			}
+			s.renderOrderPage(c, order, user, formErrMsg)
+			return
+		}
+
+		delivery, err := s.Orders.Delivery(c, order.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("No delivery in /orders/:id/delivery/status : %v", err))
+			s.renderOrderPage(c, order, user, "Order has no shipment")
+			return
+		}
+		err = s.Orders.AdvanceDelivery(c, delivery.ID, user, db.DeliveryStatus(statusForm.Status), statusForm.Note)
+		if errors.Is(err, orderstatus.ErrInvalidTransition) {
+			slog.Warn(fmt.Sprintf("Rejected delivery status in /orders/:id/delivery/status : %v", err))
+			s.renderOrderPage(c, order, user, "Shipment can't move to this status")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't update delivery in /orders/:id/delivery/status : %v", err))
+			s.renderOrderPage(c, order, user, "Failed to update shipment try again!")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/orders/%s", order.ID.String()))
+	})
+
+	// GET /returns is the review queue of return requests, oldest first.
+	router.GET("/returns", s.authMiddleware(), s.supportMiddleware(), func(c *gin.Context) {
+		orders, err := s.Orders.OrdersByStatus(c, db.OrderTypeReturnRequested)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list return requests in /returns : %v", err))
+			orders = []db.Order{}
+		}
+		err = views.ReturnsPage(orders).Render(c, c.Writer)
+		if err != nil {
+			log.Fatalf("failed to render in /returns: %v", err)
+		}
+	})
+
+	// GET /chat redirects customers to their open chat, starting one if needed, and support to the console.
+	router.GET("/chat", s.authMiddleware(), func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /chat : %v", err))
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+		user, err := s.Users.User(c, userID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't get user in /chat : %v", err))
 			c.Redirect(http.StatusFound, "/")
 			return
 		}
-		session.Options.MaxAge = -1
-		err = sessionStore.Save(c.Request, c.Writer, session)
+		if user.Role == db.UserRoleSupport || user.Role == db.UserRoleAdmin {
+			c.Redirect(http.StatusFound, "/support")
+			return
+		}
+		chatID, err := s.Chat.Open(c, userID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't open chat in /chat : %v", err))
+			c.Redirect(http.StatusFound, "/")
+			return
+		}
+		c.Redirect(http.StatusFound, fmt.Sprintf("/chats/%s", chatID.String()))
+	})
+
+	// GET /support shows the support console: the queue of open chats, longest waiting first.
+	router.GET("/support", s.authMiddleware(), s.supportMiddleware(), func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /support : %v", err))
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+		user, err := s.Users.User(c, userID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't get user in /support : %v", err))
+			c.Redirect(http.StatusFound, "/")
+			return
+		}
+		errMsg := ""
+		switch c.Query("error") {
+		case "taken":
+			errMsg = ErrChatTaken.Error()
+		case "invalid":
+			errMsg = "wrong fields"
+		}
+		s.renderSupportPage(c, user, errMsg)
+	})
+
+	// POST /support/canned adds a canned response.
+	router.POST("/support/canned", s.authMiddleware(), s.supportMiddleware(), func(c *gin.Context) {
+		userID, err := StrToUUID(c.GetString("userID"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Session uid is not UUID in /support/canned : %v", err))
+			c.Redirect(http.StatusFound, "/login")
+			return
+		}
+
+		var cannedForm CannedResponseCreate
+		err = c.ShouldBind(&cannedForm)
 		if err != nil {
 			slog.Warn(err.Error())
+			c.Redirect(http.StatusFound, "/support?error=invalid")
+			return
 		}
-		c.Redirect(http.StatusFound, "/")
+		err = s.validate.Struct(cannedForm)
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				slog.Warn(fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag()))
+			}
+			c.Redirect(http.StatusFound, "/support?error=invalid")
+			return
+		}
+
+		err = s.Chat.AddCannedResponse(c, userID, cannedForm)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't create canned response in /support/canned : %v", err))
+		}
+		c.Redirect(http.StatusFound, "/support")
 	})
 
-	router.POST("/orders/create", authMiddleware(), func(c *gin.Context) {
-		// TODO: Create a new order.
-		c.Redirect(http.StatusFound, "/orders")
+	// POST /support/canned/:id/delete removes a canned response.
+	router.POST("/support/canned/:id/delete", s.authMiddleware(), s.supportMiddleware(), func(c *gin.Context) {
+		cannedID, err := StrToUUID(c.Param("id"))
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Id is not UUID in /support/canned/:id/delete : %v", err))
+			c.Redirect(http.StatusFound, "/support")
+			return
+		}
+		err = s.Chat.DeleteCannedResponse(c, cannedID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't delete canned response in /support/canned/:id/delete : %v", err))
+		}
+		c.Redirect(http.StatusFound, "/support")
 	})
 
-	// GET & POST /orders/:id restricted to order owner and admins.
-	router.GET("/orders/:id", authMiddleware(), orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
-		id := c.Param("id")
-		// TODO: Show order details.
-		c.Redirect(http.StatusFound, "/")
+	// GET /chats/:id shows the chat history and connects to the chat websocket.
+	router.GET("/chats/:id", s.authMiddleware(), s.chatParticipantMiddleware(), func(c *gin.Context) {
+		chat := c.MustGet("chat").(db.Chat)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		err := s.Chat.MarkRead(c, chat.ID, user.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't mark chat read in /chats/:id : %v", err))
+		}
+		errMsg := ""
+		switch c.Query("error") {
+		case "closed":
+			errMsg = ErrChatClosed.Error()
+		case "invalid":
+			errMsg = ErrInvalidMessage.Error()
+		case "taken":
+			errMsg = ErrChatTaken.Error()
+		case "assignee":
+			errMsg = ErrNotAssignee.Error()
+		case "agent":
+			errMsg = ErrNotAgent.Error()
+		}
+		s.renderChatPage(c, chat, user, errMsg)
 	})
-	router.POST("/orders/:id", authMiddleware(), orderOwnerOrAdminMiddleware(), func(c *gin.Context) {
-		id := c.Param("id")
-		// TODO: Update order.
-		c.Redirect(http.StatusFound, fmt.Sprintf("/orders/%s", id))
+
+	// POST /chats/:id/claim assigns an unassigned chat to the agent taking it.
+	router.POST("/chats/:id/claim", s.authMiddleware(), s.supportMiddleware(), s.chatParticipantMiddleware(), func(c *gin.Context) {
+		chat := c.MustGet("chat").(db.Chat)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		chatUrl := fmt.Sprintf("/chats/%s", chat.ID.String())
+		err := s.Chat.Claim(c, chat.ID, user.ID)
+		if errors.Is(err, ErrChatTaken) {
+			c.Redirect(http.StatusFound, "/support?error=taken")
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't claim chat in /chats/:id/claim : %v", err))
+			c.Redirect(http.StatusFound, "/support")
+			return
+		}
+		c.Redirect(http.StatusFound, chatUrl)
 	})
 
-	// GET /chat redirects to /chats/:id for the current user.
-	router.GET("/chat", authMiddleware(), func(c *gin.Context) {
-		userID := c.MustGet("userID")
-		c.Redirect(http.StatusFound, fmt.Sprintf("/chats/%v", userID))
+	// POST /chats/:id/transfer hands a chat over to another agent.
+	router.POST("/chats/:id/transfer", s.authMiddleware(), s.supportMiddleware(), s.chatParticipantMiddleware(), func(c *gin.Context) {
+		chat := c.MustGet("chat").(db.Chat)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		chatUrl := fmt.Sprintf("/chats/%s", chat.ID.String())
+
+		var transferForm ChatTransfer
+		err := c.ShouldBind(&transferForm)
+		if err != nil {
+			slog.Warn(err.Error())
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=agent", chatUrl))
+			return
+		}
+		err = s.validate.Struct(transferForm)
+		if err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				slog.Warn(fmt.Sprintf("Field: %v, Error: %v. ", err.StructField(), err.Tag()))
+			}
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=agent", chatUrl))
+			return
+		}
+		agentID, err := StrToUUID(transferForm.AgentID)
+		if err != nil {
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=agent", chatUrl))
+			return
+		}
+
+		err = s.Chat.Transfer(c, chat, user, agentID)
+		switch {
+		case errors.Is(err, ErrChatClosed):
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=closed", chatUrl))
+		case errors.Is(err, ErrNotAssignee):
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=assignee", chatUrl))
+		case errors.Is(err, ErrNotAgent):
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=agent", chatUrl))
+		case err != nil:
+			slog.Warn(fmt.Sprintf("Can't transfer chat in /chats/:id/transfer : %v", err))
+			c.Redirect(http.StatusFound, chatUrl)
+		default:
+			c.Redirect(http.StatusFound, "/support")
+		}
 	})
 
-	// GET & POST /chats.
-	router.GET("/chats/:id", authMiddleware(), func(c *gin.Context) {
-		c.Redirect(http.StatusFound, "/")
+	// GET /chats/:id/ws upgrades to the chat websocket.
+	router.GET("/chats/:id/ws", s.authMiddleware(), s.chatParticipantMiddleware(), func(c *gin.Context) {
+		chat := c.MustGet("chat").(db.Chat)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		s.hub.Handler(chat, user).ServeHTTP(c.Writer, c.Request)
 	})
 
-	// Start the server.
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("failed to run server: %v", err)
-	}
+	// GET /chats/:id/events streams the chat as Server-Sent Events for browsers without websockets.
+	// A reconnecting browser sends the last message it saw as Last-Event-ID, or ?after= on the first
+	// connection, and gets every later one.
+	router.GET("/chats/:id/events", s.authMiddleware(), s.chatParticipantMiddleware(), func(c *gin.Context) {
+		chat := c.MustGet("chat").(db.Chat)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		client, ok := s.hub.Subscribe(chatRoom(chat.ID.String()), chat, user)
+		if !ok {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		defer s.hub.Unsubscribe(client)
+
+		var backlog []ChatEvent
+		if lastID, err := StrToUUID(resumeID(c)); err == nil {
+			messages, err := s.Chat.MessagesSince(c, chat.ID, lastID)
+			if err != nil {
+				slog.Warn(fmt.Sprintf("Can't list missed messages in /chats/:id/events : %v", err))
+			}
+			for _, m := range messages {
+				backlog = append(backlog, messageEvent(m))
+			}
+		}
+		if chat.Status != db.ChatStatusOpen {
+			backlog = append(backlog, ChatEvent{Type: "closed"})
+		}
+		streamEvents(c, client, backlog)
+	})
+
+	// POST /chats/:id/messages posts a message without javascript.
+	router.POST("/chats/:id/messages", s.authMiddleware(), s.chatParticipantMiddleware(), func(c *gin.Context) {
+		chat := c.MustGet("chat").(db.Chat)
+		user := c.MustGet("user").(db.GetUserByIdRow)
+		chatUrl := fmt.Sprintf("/chats/%s", chat.ID.String())
+		msg, err := s.Chat.Post(c, chat.ID, user.ID, c.PostForm("message"))
+		if errors.Is(err, ErrChatClosed) {
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=closed", chatUrl))
+			return
+		}
+		if errors.Is(err, ErrInvalidMessage) {
+			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=invalid", chatUrl))
+			return
+		}
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't post message in /chats/:id/messages : %v", err))
+			c.Redirect(http.StatusFound, chatUrl)
+			return
+		}
+		s.hub.Publish(c, msg)
+		c.Redirect(http.StatusFound, chatUrl)
+	})
+
+	// POST /chats/:id/close lets support close a resolved chat.
+	router.POST("/chats/:id/close", s.authMiddleware(), s.supportMiddleware(), s.chatParticipantMiddleware(), func(c *gin.Context) {
+		chat := c.MustGet("chat").(db.Chat)
+		err := s.Chat.Close(c, chat.ID)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't close chat in /chats/:id/close : %v", err))
+			c.Redirect(http.StatusFound, fmt.Sprintf("/chats/%s", chat.ID.String()))
+			return
+		}
+		s.hub.Closed(c, chat.ID.String())
+		c.Redirect(http.StatusFound, "/support")
+	})
 }
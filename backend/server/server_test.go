@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"agro.store/backend/db"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeQuerier serves the products it holds. Any other query panics on the nil db.Querier
+// it embeds.
+type fakeQuerier struct {
+	db.Querier
+	products map[pgtype.UUID]db.GetProductByIdRow
+}
+
+func (q *fakeQuerier) GetProductById(ctx context.Context, id pgtype.UUID) (db.GetProductByIdRow, error) {
+	p, ok := q.products[id]
+	if !ok {
+		return db.GetProductByIdRow{}, pgx.ErrNoRows
+	}
+	return p, nil
+}
+
+func (q *fakeQuerier) ListProductAttributeValues(ctx context.Context, productID pgtype.UUID) ([]db.ListProductAttributeValuesRow, error) {
+	return nil, nil
+}
+
+// TestServersAreIndependent builds two servers side by side, each with its own config and
+// services, and checks every one answers from its own.
+func TestServersAreIndependent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	productID, err := StrToUUID("6f1c2b0e-8d3a-4e57-9a61-2f0c8e4b7d19")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newServer := func(name string) http.Handler {
+		t.Helper()
+		cfg := DefaultConfig()
+		cfg.PublicDir, cfg.UploadDir = t.TempDir(), t.TempDir()
+		err := os.WriteFile(filepath.Join(cfg.PublicDir, "name.txt"), []byte(name), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := &fakeQuerier{products: map[pgtype.UUID]db.GetProductByIdRow{productID: {ID: productID, Name: name}}}
+		s, err := NewServer(cfg, nil, NewServices(cfg, q, nil), NewHub(nil, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Handler()
+	}
+	servers := map[string]http.Handler{"first": newServer("first"), "second": newServer("second")}
+
+	for name, handler := range servers {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public/name.txt", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != name {
+			t.Errorf("%s server: GET /public/name.txt = %d %q, want 200 %q", name, rec.Code, rec.Body.String(), name)
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/"+productID.String(), nil))
+		var resp struct {
+			Data ProductResponse `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s server: GET /api/v1/products/:id = %d %s: %v", name, rec.Code, rec.Body.String(), err)
+		}
+		if rec.Code != http.StatusOK || resp.Data.Name != name {
+			t.Errorf("%s server: GET /api/v1/products/:id = %d %q, want 200 %q", name, rec.Code, resp.Data.Name, name)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"mime/multipart"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TxBeginner starts the transactions that multi-statement changes run in.
+// *pgx.Conn and *pgxpool.Pool implement it.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// CatalogService is the product catalog: products and their categories and attributes,
+// stock, favorites, reviews and questions.
+type CatalogService interface {
+	// Products returns the catalog, optionally only the product named name or the products of a type.
+	Products(ctx context.Context, name string, productType string) ([]db.ListAllProductsRow, error)
+	// Browse returns a page of the catalog narrowed by filter, with the facet counts.
+	Browse(ctx context.Context, filter CatalogFilter) (CatalogPage, error)
+	Product(ctx context.Context, id pgtype.UUID) (db.GetProductByIdRow, error)
+	// Search returns the products matching a web search style query in language lang
+	// (bg, ru or en), best matches first.
+	Search(ctx context.Context, query string, lang string) ([]db.SearchProductsRow, error)
+	// Suggest returns product names completing the words typed so far.
+	Suggest(ctx context.Context, prefix string) ([]string, error)
+	// Categories returns the category tree, every category followed by its subcategories.
+	Categories(ctx context.Context) ([]db.ListCategoriesRow, error)
+	// Category returns the category with slug, or ErrUnknownCategory.
+	Category(ctx context.Context, slug string) (db.Category, error)
+	// Breadcrumbs returns the category with slug preceded by its ancestors, root first.
+	Breadcrumbs(ctx context.Context, slug string) ([]db.Category, error)
+	CreateCategory(ctx context.Context, form CategoryCreateEdit, img string) (db.Category, error)
+	// UpdateCategory changes a category. An empty img keeps the current image.
+	UpdateCategory(ctx context.Context, category db.Category, form CategoryCreateEdit, img string) error
+	// DeleteCategory removes a category without subcategories and products.
+	DeleteCategory(ctx context.Context, slug string) error
+	// Attributes returns the product attributes of every category.
+	Attributes(ctx context.Context) ([]db.ListAttributesRow, error)
+	// Attribute returns the attribute with slug, or ErrUnknownAttribute.
+	Attribute(ctx context.Context, slug string) (db.Attribute, error)
+	// CreateAttribute defines an attribute for the products of a category and its subcategories.
+	CreateAttribute(ctx context.Context, category db.Category, form AttributeCreateEdit) (db.Attribute, error)
+	// UpdateAttribute changes an attribute, except its slug and kind.
+	UpdateAttribute(ctx context.Context, attribute db.Attribute, form AttributeCreateEdit) error
+	DeleteAttribute(ctx context.Context, attribute db.Attribute) error
+	// ProductAttributes returns the spec values of a product.
+	ProductAttributes(ctx context.Context, productID pgtype.UUID) ([]db.ListProductAttributeValuesRow, error)
+	// SaveImage stores an uploaded product image under a unique name and returns the name.
+	SaveImage(file *multipart.FileHeader) (string, error)
+	// RemoveImage deletes a saved image whose product couldn't be stored.
+	RemoveImage(img string)
+	// CreateProduct adds a product with an already saved image and its spec values, and
+	// returns its ID.
+	CreateProduct(ctx context.Context, form ProductCreateEdit, img string) (pgtype.UUID, error)
+	// UpdateProduct changes a product. An empty img keeps the current image.
+	UpdateProduct(ctx context.Context, product db.GetProductByIdRow, form ProductCreateEdit, img string) error
+	DeleteProduct(ctx context.Context, id pgtype.UUID) error
+	AdjustStock(ctx context.Context, productID pgtype.UUID, actorID pgtype.UUID, form StockAdjust) error
+	StockMovements(ctx context.Context, productID pgtype.UUID) ([]db.StockMovement, error)
+	// FavoriteIDs returns the set of product IDs a user saved, keyed by their string form.
+	FavoriteIDs(ctx context.Context, userID pgtype.UUID) (map[string]bool, error)
+	Favorites(ctx context.Context, userID pgtype.UUID) ([]db.ListAllProductsRow, error)
+	ToggleFavorite(ctx context.Context, userID pgtype.UUID, productID pgtype.UUID) error
+	RemoveFavorite(ctx context.Context, userID pgtype.UUID, productID pgtype.UUID) error
+	// Interactions returns the reviews and the questions of a product.
+	Interactions(ctx context.Context, productID pgtype.UUID) ([]db.ListProductInteractionsByProductIdRow, []db.ListProductInteractionsByProductIdRow, error)
+	CanReview(ctx context.Context, user db.GetUserByIdRow, productID pgtype.UUID, reviews []db.ListProductInteractionsByProductIdRow) bool
+	AddReview(ctx context.Context, user db.GetUserByIdRow, productID pgtype.UUID, form ReviewCreate) error
+	AskQuestion(ctx context.Context, userID pgtype.UUID, productID pgtype.UUID, form QuestionCreate) error
+	AnswerQuestion(ctx context.Context, agentID pgtype.UUID, productID pgtype.UUID, questionID pgtype.UUID, form QuestionAnswer) error
+}
+
+// UserService manages accounts and the credentials API clients authenticate with.
+type UserService interface {
+	Register(ctx context.Context, form UserRegister) (pgtype.UUID, error)
+	Authenticate(ctx context.Context, form UserLogin) (pgtype.UUID, error)
+	User(ctx context.Context, id pgtype.UUID) (db.GetUserByIdRow, error)
+	Users(ctx context.Context) ([]db.ListAllUsersRow, error)
+	// UsersPage returns a page of the users ordered by name and the number of all users.
+	UsersPage(ctx context.Context, page Page) ([]db.ListUsersPageRow, int64, error)
+	Update(ctx context.Context, actor db.GetUserByIdRow, userID pgtype.UUID, form UserEdit, role string) error
+	Delete(ctx context.Context, id pgtype.UUID) error
+	IssueToken(ctx context.Context, userID pgtype.UUID) (TokenResponse, error)
+	// RevokeToken deletes a token from a login. API keys are left alone, see RevokeAPIKey.
+	RevokeToken(ctx context.Context, token string) error
+	// UserByToken returns the user a bearer token or API key acts for, with the scopes of an API key.
+	UserByToken(ctx context.Context, token string) (db.GetUserByIdRow, []string, error)
+	IssueAPIKey(ctx context.Context, adminID pgtype.UUID, form ApiKeyCreate) (string, error)
+	APIKeys(ctx context.Context) ([]db.ListApiKeysRow, error)
+	RevokeAPIKey(ctx context.Context, id pgtype.UUID) error
+}
+
+// CartService prices and checks the shopping lists kept in the session or sent by API clients.
+type CartService interface {
+	// CheckStock returns the product if it has at least quantity units in stock.
+	CheckStock(ctx context.Context, productID pgtype.UUID, quantity int) (db.GetProductByIdRow, error)
+	// Lines loads the products in the shopping list together with their quantities.
+	Lines(ctx context.Context, shoppingList []CartItem) ([]db.GetProductByIdRow, []int)
+}
+
+// OrderService is the order lifecycle: checkout, status changes, shipments and returns.
+type OrderService interface {
+	Order(ctx context.Context, id pgtype.UUID) (db.Order, error)
+	Orders(ctx context.Context) ([]db.Order, error)
+	OrdersByUser(ctx context.Context, userID pgtype.UUID) ([]db.Order, error)
+	// OrdersPage returns a page of the orders, newest first, and the number of all orders.
+	OrdersPage(ctx context.Context, page Page) ([]db.Order, int64, error)
+	// OrdersByUserPage returns a page of a user's orders, newest first, and the number of them.
+	OrdersByUserPage(ctx context.Context, userID pgtype.UUID, page Page) ([]db.Order, int64, error)
+	OrdersByStatus(ctx context.Context, status db.OrderType) ([]db.Order, error)
+	Create(ctx context.Context, userID pgtype.UUID, form OrderCreate, shoppingList []CartItem) (pgtype.UUID, error)
+	Details(ctx context.Context, orderID pgtype.UUID) (db.OrderDetail, error)
+	// Items returns the items of an order together with the product each item refers to.
+	Items(ctx context.Context, orderID pgtype.UUID) ([]db.OrderItem, []db.GetProductByIdRow, error)
+	History(ctx context.Context, orderID pgtype.UUID) ([]db.ListOrderStatusHistoryByOrderIdRow, error)
+	// ChangesSince returns the status changes of a user's orders after the change afterID,
+	// all of them when afterID isn't one of the user's changes.
+	ChangesSince(ctx context.Context, userID pgtype.UUID, afterID pgtype.UUID) ([]db.OrderStatusHistory, error)
+	ReturnItems(ctx context.Context, orderID pgtype.UUID) ([]db.ListOrderReturnItemsByOrderIdRow, error)
+	Delivery(ctx context.Context, orderID pgtype.UUID) (db.Delivery, error)
+	DeliveryEvents(ctx context.Context, deliveryID pgtype.UUID) ([]db.DeliveryEvent, error)
+	ChangeStatus(ctx context.Context, orderID pgtype.UUID, actor db.GetUserByIdRow, to db.OrderType, note string) error
+	Ship(ctx context.Context, orderID pgtype.UUID, actor db.GetUserByIdRow, form DeliveryCreate) (db.Delivery, error)
+	AdvanceDelivery(ctx context.Context, deliveryID pgtype.UUID, actor db.GetUserByIdRow, to db.DeliveryStatus, note string) error
+	RequestReturn(ctx context.Context, order db.Order, actor db.GetUserByIdRow, statement string, lines []ReturnLine) error
+	ApproveReturn(ctx context.Context, order db.Order, actor db.GetUserByIdRow) (pgtype.Numeric, error)
+	RejectReturn(ctx context.Context, order db.Order, actor db.GetUserByIdRow, note string) error
+}
+
+// ChatService is the customer support chat and the console support staff work it from.
+type ChatService interface {
+	Chat(ctx context.Context, id pgtype.UUID) (db.Chat, error)
+	ChatByCreator(ctx context.Context, userID pgtype.UUID) (db.Chat, error)
+	// Open returns the open chat of a customer, starting a new one if there is none.
+	Open(ctx context.Context, userID pgtype.UUID) (pgtype.UUID, error)
+	Messages(ctx context.Context, chatID pgtype.UUID) ([]db.Message, error)
+	// MessagesPage returns a page of the messages of a chat, oldest first, and the number of them.
+	MessagesPage(ctx context.Context, chatID pgtype.UUID, page Page) ([]db.Message, int64, error)
+	MessagesSince(ctx context.Context, chatID pgtype.UUID, afterID pgtype.UUID) ([]db.Message, error)
+	Post(ctx context.Context, chatID pgtype.UUID, userID pgtype.UUID, content string) (db.Message, error)
+	MarkRead(ctx context.Context, chatID pgtype.UUID, userID pgtype.UUID) error
+	Close(ctx context.Context, chatID pgtype.UUID) error
+	Queue(ctx context.Context, agentID pgtype.UUID) ([]db.ListChatQueueRow, error)
+	Claim(ctx context.Context, chatID pgtype.UUID, agentID pgtype.UUID) error
+	Transfer(ctx context.Context, chat db.Chat, actor db.GetUserByIdRow, agentID pgtype.UUID) error
+	Agents(ctx context.Context) ([]db.ListSupportAgentsRow, error)
+	CannedResponses(ctx context.Context) ([]db.CannedResponse, error)
+	AddCannedResponse(ctx context.Context, authorID pgtype.UUID, form CannedResponseCreate) error
+	DeleteCannedResponse(ctx context.Context, id pgtype.UUID) error
+}
+
+// Services are the business logic the HTML and JSON handlers share.
+type Services struct {
+	Catalog CatalogService
+	Users   UserService
+	Cart    CartService
+	Orders  OrderService
+	Chat    ChatService
+}
+
+// NewServices creates the services backed by the database. q runs single statements
+// and conn starts the transactions for changes spanning several.
+func NewServices(cfg Config, q db.Querier, conn TxBeginner) Services {
+	return Services{Catalog: &catalogService{q: q, conn: conn, uploadDir: cfg.UploadDir},
+		Users:  &userService{q: q, tokenTTL: cfg.APITokenTTL},
+		Cart:   &cartService{q: q},
+		Orders: &orderService{q: q, conn: conn},
+		Chat:   &chatService{q: q}}
+}
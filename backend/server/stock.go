@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+
+	"agro.store/backend/db"
+	"agro.store/backend/inventory"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AdjustStock applies an admin stock receipt or correction to a product in one transaction.
+func (s *catalogService) AdjustStock(ctx context.Context, productID pgtype.UUID, actorID pgtype.UUID, stockForm StockAdjust) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = inventory.Adjust(ctx, db.New(tx), productID, actorID, int32(stockForm.Change), db.StockMovementType(stockForm.Reason), stockForm.Note)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *catalogService) StockMovements(ctx context.Context, productID pgtype.UUID) ([]db.StockMovement, error) {
+	return s.q.ListStockMovementsByProductId(ctx, productID)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+
+	"agro.store/backend/db"
+	"agro.store/frontend/views"
+	"github.com/gin-gonic/gin"
+)
+
+// renderSupportPage renders the support console with the chat queue as seen by viewer.
+func (s *Server) renderSupportPage(c *gin.Context, viewer db.GetUserByIdRow, errMsg string) {
+	queue, err := s.Chat.Queue(c, viewer.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list chat queue in %s : %v", c.FullPath(), err))
+		queue = []db.ListChatQueueRow{}
+	}
+	canned, err := s.Chat.CannedResponses(c)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list canned responses in %s : %v", c.FullPath(), err))
+		canned = []db.CannedResponse{}
+	}
+	err = views.SupportPage(views.SupportPageData{Queue: queue,
+		Canned: canned,
+		Viewer: viewer,
+		ErrMsg: errMsg}).Render(c, c.Writer)
+	if err != nil {
+		log.Fatalf("failed to render in %s: %v", c.FullPath(), err)
+	}
+}
+
+// renderChatPage renders a chat with the assignment controls and canned responses staff need.
+func (s *Server) renderChatPage(c *gin.Context, chat db.Chat, viewer db.GetUserByIdRow, errMsg string) {
+	messages, err := s.Chat.Messages(c, chat.ID)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Can't list messages in %s : %v", c.FullPath(), err))
+		messages = []db.Message{}
+	}
+	data := views.ChatPageData{Chat: chat, Messages: messages, Viewer: viewer, ErrMsg: errMsg}
+	if viewer.Role == db.UserRoleSupport || viewer.Role == db.UserRoleAdmin {
+		data.Agents, err = s.Chat.Agents(c)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list support agents in %s : %v", c.FullPath(), err))
+		}
+		data.Canned, err = s.Chat.CannedResponses(c)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Can't list canned responses in %s : %v", c.FullPath(), err))
+		}
+	}
+	err = views.ChatPage(data).Render(c, c.Writer)
+	if err != nil {
+		log.Fatalf("failed to render in %s: %v", c.FullPath(), err)
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"agro.store/backend/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrUserExists is returned when registering with an email that already has an account.
+var ErrUserExists = errors.New("such user already exists")
+
+// ErrWrongCredentials is returned when the email or the password of a login don't match.
+var ErrWrongCredentials = errors.New("email or password are wrong")
+
+// ErrInvalidRole is returned when an admin assigns a role that doesn't exist.
+var ErrInvalidRole = errors.New("user role is invalid")
+
+// userService implements UserService on the database.
+type userService struct {
+	q        db.Querier
+	tokenTTL time.Duration
+}
+
+// Register creates a customer account and returns its ID.
+func (s *userService) Register(ctx context.Context, form UserRegister) (pgtype.UUID, error) {
+	_, err := s.q.GetUserByEmail(ctx, form.Email)
+	if err == nil {
+		return pgtype.UUID{}, ErrUserExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, err
+	}
+	hash, err := hashPass(form.Password)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("hash password: %w", err)
+	}
+	return s.q.CreateUser(ctx, db.CreateUserParams{Email: form.Email,
+		Fname:    form.FirstName,
+		Lname:    form.LastName,
+		Password: string(hash),
+		Role:     db.UserRoleUser})
+}
+
+// Authenticate checks the credentials of a login and returns the ID of the user.
+func (s *userService) Authenticate(ctx context.Context, form UserLogin) (pgtype.UUID, error) {
+	user, err := s.q.GetUserByEmail(ctx, form.Email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, ErrWrongCredentials
+	}
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	if comparePass([]byte(user.Password), form.Password) != nil {
+		return pgtype.UUID{}, ErrWrongCredentials
+	}
+	return user.ID, nil
+}
+
+func (s *userService) User(ctx context.Context, id pgtype.UUID) (db.GetUserByIdRow, error) {
+	return s.q.GetUserById(ctx, id)
+}
+
+func (s *userService) Users(ctx context.Context) ([]db.ListAllUsersRow, error) {
+	return s.q.ListAllUsers(ctx)
+}
+
+func (s *userService) UsersPage(ctx context.Context, page Page) ([]db.ListUsersPageRow, int64, error) {
+	users, err := s.q.ListUsersPage(ctx, db.ListUsersPageParams{Limit: page.Limit, Offset: page.Offset})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	total, err := s.q.CountUsers(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+	return users, total, nil
+}
+
+// Update changes the names of a user. Admins may also change the role of other users,
+// an empty role leaves it as it is.
+func (s *userService) Update(ctx context.Context, actor db.GetUserByIdRow, userID pgtype.UUID, form UserEdit, role string) error {
+	_, err := s.q.UpdateUserNames(ctx, db.UpdateUserNamesParams{ID: userID,
+		Fname: form.FirstName,
+		Lname: form.LastName})
+	if err != nil {
+		return fmt.Errorf("update names: %w", err)
+	}
+	if actor.Role != db.UserRoleAdmin || role == "" || actor.ID == userID {
+		return nil
+	}
+	switch db.UserRole(role) {
+	case db.UserRoleUser, db.UserRoleSupport, db.UserRoleAdmin:
+	default:
+		return ErrInvalidRole
+	}
+	_, err = s.q.UpdateUserRole(ctx, db.UpdateUserRoleParams{ID: userID, Role: db.UserRole(role)})
+	if err != nil {
+		return fmt.Errorf("update role: %w", err)
+	}
+	return nil
+}
+
+func (s *userService) Delete(ctx context.Context, id pgtype.UUID) error {
+	return s.q.DeleteUser(ctx, id)
+}
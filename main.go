@@ -1,15 +1,142 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"agro.store/backend/db"
+	"agro.store/backend/migrate"
+	"agro.store/backend/seed"
 	"agro.store/backend/server"
 	_ "github.com/a-h/templ"
 	_ "github.com/gin-gonic/gin"
-	_ "github.com/gorilla/sessions"
-	_ "github.com/jackc/pgx/v5"
 	_ "github.com/go-playground/validator/v10"
+	_ "github.com/gorilla/sessions"
+	"github.com/jackc/pgx/v5"
 	_ "github.com/joho/godotenv"
 )
 
+const usage = `usage:
+  agro.store                                  run the shop
+  agro.store migrate up|down|status|redo      change the database schema
+  agro.store migrate baseline                 adopt a database created from sql/schema.sql,
+                                              recording 0001_init as applied before migrate up
+  agro.store seed [-seed n] [-customers n] [-orders n] [-chats n]
+                                              fill the database with demo data`
+
 func main() {
-	server.StartServer()
+	if len(os.Args) < 2 {
+		server.StartServer()
+		return
+	}
+	switch os.Args[1] {
+	case "migrate":
+		if len(os.Args) != 3 {
+			log.Fatal(usage)
+		}
+		runMigrate(os.Args[2])
+	case "seed":
+		runSeed(os.Args[2:])
+	default:
+		log.Fatal(usage)
+	}
+}
+
+// runMigrate runs a migrate subcommand against the database of DB_URI.
+func runMigrate(cmd string) {
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	migrator, err := migrate.New(conn)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+	switch cmd {
+	case "up":
+		ran, err := migrator.Up(ctx)
+		for _, mig := range ran {
+			fmt.Printf("applied %s\n", mig.Describe())
+		}
+		if err != nil {
+			log.Fatalf("failed to migrate up: %v", err)
+		}
+		if len(ran) == 0 {
+			fmt.Println("the schema is up to date")
+		}
+	case "down":
+		mig, err := migrator.Down(ctx)
+		if err != nil {
+			log.Fatalf("failed to migrate down: %v", err)
+		}
+		fmt.Printf("rolled back %s\n", mig.Describe())
+	case "baseline":
+		mig, err := migrator.Baseline(ctx)
+		if err != nil {
+			log.Fatalf("failed to baseline: %v", err)
+		}
+		fmt.Printf("recorded %s as applied\n", mig.Describe())
+	case "redo":
+		mig, err := migrator.Redo(ctx)
+		if err != nil {
+			log.Fatalf("failed to redo migration: %v", err)
+		}
+		fmt.Printf("redid %s\n", mig.Describe())
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("failed to read migration status: %v", err)
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if st.Modified {
+				state += ", modified since"
+			}
+			fmt.Printf("%-40s %s\n", st.Describe(), state)
+		}
+	default:
+		log.Fatal(usage)
+	}
+}
+
+// runSeed fills the database of DB_URI with demo data.
+func runSeed(args []string) {
+	opts := seed.DefaultOptions()
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	flags.Uint64Var(&opts.Seed, "seed", opts.Seed, "seed of the random choices")
+	flags.IntVar(&opts.Customers, "customers", opts.Customers, "number of customer accounts")
+	flags.IntVar(&opts.Orders, "orders", opts.Orders, "number of orders")
+	flags.IntVar(&opts.Chats, "chats", opts.Chats, "number of support chats")
+	_ = flags.Parse(args)
+
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	opts.UploadDir = cfg.UploadDir
+	ctx := context.Background()
+	pool, err := server.NewPool(ctx, cfg.DatabaseURL, cfg.DB)
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	err = seed.Run(ctx, db.New(pool), server.NewServices(cfg, db.New(pool), pool), opts)
+	if err != nil {
+		log.Fatalf("failed to seed: %v", err)
+	}
+	fmt.Printf("seeded, log in as admin@agro.store, support@agro.store or user@agro.store with password %s\n", seed.Password)
 }
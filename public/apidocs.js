@@ -0,0 +1,7 @@
+window.addEventListener("load", () => {
+	// Without the viewer the operations listed by the page stay.
+	if (typeof SwaggerUIBundle === "undefined") {
+		return;
+	}
+	SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+});
@@ -15,6 +15,22 @@ SELECT id, email, fname, lname, role
 FROM users
 ORDER BY fname, lname;
 
+-- name: ListUsersPage :many
+SELECT id, email, fname, lname, role
+FROM users
+ORDER BY fname, lname, id
+LIMIT $1 OFFSET $2;
+
+-- name: CountUsers :one
+SELECT COUNT(*)
+FROM users;
+
+-- name: ListSupportAgents :many
+SELECT id, fname, lname, role
+FROM users
+WHERE role IN ('support', 'admin')
+ORDER BY fname, lname;
+
 -- name: CreateUser :one
 INSERT INTO users (email, fname, lname, password, role)
 VALUES ($1, $2, $3, $4, $5)
@@ -44,6 +60,50 @@ DELETE
 FROM users
 WHERE id = $1;
 
+-- name: CreateApiToken :one
+INSERT INTO api_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: CreateApiKey :one
+INSERT INTO api_tokens (user_id, kind, name, prefix, token_hash, scopes, expires_at)
+VALUES ($1, 'key', $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetUserByApiToken :one
+SELECT U.id, U.email, U.fname, U.lname, U.role, A.id as token_id, A.scopes
+FROM api_tokens A
+         JOIN users U on U.id = A.user_id
+WHERE A.token_hash = $1
+  AND A.expires_at > NOW()
+LIMIT 1;
+
+-- name: TouchApiToken :exec
+UPDATE api_tokens
+SET last_used_at = NOW()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
+
+-- name: ListApiKeys :many
+SELECT A.id, A.name, A.prefix, A.scopes, A.expires_at, A.last_used_at, A.created_at, U.fname, U.lname
+FROM api_tokens A
+         JOIN users U on U.id = A.user_id
+WHERE A.kind = 'key'
+ORDER BY A.created_at DESC;
+
+-- name: DeleteApiToken :exec
+-- Only tokens from a login are revoked this way, API keys are revoked by an admin.
+DELETE
+FROM api_tokens
+WHERE token_hash = $1
+  AND kind = 'login';
+
+-- name: DeleteApiKey :exec
+DELETE
+FROM api_tokens
+WHERE id = $1
+  AND kind = 'key';
+
 -- name: GetChatById :one
 SELECT *
 from chats
@@ -57,13 +117,82 @@ WHERE created_by = $1
   and status = 'open'
 LIMIT 1;
 
+-- name: CountChatsByCreator :one
+SELECT COUNT(*)
+FROM chats
+WHERE created_by = $1;
+
 -- name: ListAllChats :many
 SELECT *
 FROM chats;
 
+-- name: ListChatQueue :many
+SELECT C.id,
+       C.status,
+       C.created_by,
+       C.assigned_to,
+       C.created_at,
+       U.fname,
+       U.lname,
+       A.fname                       as agent_fname,
+       A.lname                       as agent_lname,
+       W.waiting_since::timestamptz  as waiting_since,
+       (SELECT COUNT(*)
+        FROM messages M
+        WHERE M.chat_id = C.id
+          AND M.user_id <> sqlc.arg(viewer_id)
+          AND M.created_at > COALESCE(R.last_read_at, '-infinity'))::int as unread
+FROM chats C
+         JOIN users U on U.id = C.created_by
+         LEFT JOIN users A on A.id = C.assigned_to
+         LEFT JOIN chat_reads R on R.chat_id = C.id AND R.user_id = sqlc.arg(viewer_id)
+         LEFT JOIN LATERAL (SELECT MIN(M.created_at) as waiting_since
+                            FROM messages M
+                            WHERE M.chat_id = C.id
+                              AND M.user_id = C.created_by
+                              AND M.created_at > COALESCE((SELECT MAX(S.created_at)
+                                                           FROM messages S
+                                                           WHERE S.chat_id = C.id
+                                                             AND S.user_id <> C.created_by),
+                                                          '-infinity')) W on TRUE
+WHERE C.status = 'open'
+ORDER BY W.waiting_since NULLS LAST, C.created_at;
+
+-- name: ClaimChat :execrows
+UPDATE chats
+SET assigned_to = $2
+WHERE id = $1
+  AND status = 'open'
+  AND assigned_to IS NULL;
+
+-- name: AssignChat :exec
+UPDATE chats
+SET assigned_to = $2
+WHERE id = $1;
+
+-- name: MarkChatRead :exec
+INSERT INTO chat_reads (chat_id, user_id, last_read_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (chat_id, user_id) DO UPDATE SET last_read_at = NOW();
+
+-- name: ListCannedResponses :many
+SELECT *
+FROM canned_responses
+ORDER BY title;
+
+-- name: CreateCannedResponse :exec
+INSERT INTO canned_responses (title, content, created_by)
+VALUES ($1, $2, $3);
+
+-- name: DeleteCannedResponse :exec
+DELETE
+FROM canned_responses
+WHERE id = $1;
+
 -- name: CreateChat :one
 INSERT INTO chats (status, created_by)
 VALUES ('open', $1)
+ON CONFLICT (created_by) WHERE status = 'open' DO NOTHING
 RETURNING id;
 
 -- name: UpdateChatStatus :exec
@@ -76,17 +205,48 @@ DELETE
 FROM chats
 WHERE id = $1;
 
--- name: ListAllMessagesByChatId :one
+-- name: ListAllMessagesByChatId :many
 SELECT *
 FROM messages
 WHERE chat_id = $1
-ORDER BY created_at desc;
+ORDER BY seq;
+
+-- name: ListMessagesByChatIdPage :many
+SELECT *
+FROM messages
+WHERE chat_id = $1
+ORDER BY seq
+LIMIT $2 OFFSET $3;
+
+-- name: CountMessagesByChatId :one
+SELECT COUNT(*)
+FROM messages
+WHERE chat_id = $1;
 
 -- name: CreateMessage :one
 INSERT INTO messages (chat_id, user_id, content)
 VALUES ($1, $2, $3)
 RETURNING *;
 
+-- name: ListMessagesByChatIdAfter :many
+-- Messages are resumed in the order they were stored, by seq. An unknown after_id, a
+-- deleted message for example, returns the whole chat.
+WITH after AS (SELECT seq FROM messages WHERE chat_id = sqlc.arg(chat_id) AND id = sqlc.arg(after_id))
+SELECT M.*
+FROM messages M
+WHERE M.chat_id = sqlc.arg(chat_id)
+  AND (NOT EXISTS (SELECT 1 FROM after) OR M.seq > (SELECT seq FROM after))
+ORDER BY M.seq;
+
+-- name: GetMessageById :one
+SELECT *
+FROM messages
+WHERE id = $1
+LIMIT 1;
+
+-- name: NotifyChannel :exec
+SELECT pg_notify(sqlc.arg(channel)::text, sqlc.arg(payload)::text);
+
 -- name: ListAllOrders :many
 SELECT *
 FROM orders;
@@ -94,28 +254,119 @@ FROM orders;
 -- name: ListAllOrdersByUserId :many
 SELECT *
 FROM orders
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListOrdersPage :many
+SELECT *
+FROM orders
+ORDER BY created_at DESC, id
+LIMIT $1 OFFSET $2;
+
+-- name: CountOrders :one
+SELECT COUNT(*)
+FROM orders;
+
+-- name: ListOrdersByUserIdPage :many
+SELECT *
+FROM orders
+WHERE user_id = $1
+ORDER BY created_at DESC, id
+LIMIT $2 OFFSET $3;
+
+-- name: CountOrdersByUserId :one
+SELECT COUNT(*)
+FROM orders
 WHERE user_id = $1;
 
+-- name: ListAllOrdersByStatus :many
+SELECT *
+FROM orders
+WHERE status = $1
+ORDER BY updated_at;
+
 -- name: GetOrderById :one
 SELECT *
 FROM orders
 WHERE id = $1
 LIMIT 1;
 
--- name: CreateOrder :exec
+-- name: GetOrderByIdForUpdate :one
+SELECT *
+FROM orders
+WHERE id = $1
+LIMIT 1 FOR UPDATE;
+
+-- name: CreateOrder :one
 INSERT INTO orders (user_id, status)
-VALUES ($1, $2);
+VALUES ($1, $2)
+RETURNING id;
 
 -- name: UpdateOrderStatus :exec
 UPDATE orders
 SET status =$2
 WHERE id = $1;
 
+-- name: BackdateOrder :exec
+WITH history AS (
+    UPDATE order_status_history
+        SET created_at = created_at - sqlc.arg(age)::interval
+        WHERE order_id = sqlc.arg(id))
+UPDATE orders
+SET created_at = created_at - sqlc.arg(age)::interval
+WHERE id = sqlc.arg(id);
+
+-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetOrderStatusHistoryById :one
+SELECT *
+FROM order_status_history
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListOrderStatusHistoryByUserIdAfter :many
+-- Changes are resumed in the order they were stored, by seq. An unknown after_id returns
+-- every change of the user's orders.
+WITH after AS (SELECT A.seq
+               FROM order_status_history A
+                        JOIN orders AO on AO.id = A.order_id
+               WHERE AO.user_id = sqlc.arg(user_id)
+                 AND A.id = sqlc.arg(after_id))
+SELECT H.*
+FROM order_status_history H
+         JOIN orders O on O.id = H.order_id
+WHERE O.user_id = sqlc.arg(user_id)
+  AND (NOT EXISTS (SELECT 1 FROM after) OR H.seq > (SELECT seq FROM after))
+ORDER BY H.seq;
+
+-- name: ListOrderStatusHistoryByOrderId :many
+SELECT H.id,
+       H.order_id,
+       H.from_status,
+       H.to_status,
+       H.changed_by,
+       H.note,
+       H.created_at,
+       U.fname,
+       U.lname,
+       U.role
+FROM order_status_history H
+         LEFT JOIN users U on U.id = H.changed_by
+WHERE H.order_id = $1
+ORDER BY H.created_at;
+
 -- name: DeleteOrder :exec
 DELETE
 FROM orders
 WHERE id = $1;
 
+-- name: CreateOrderDetails :exec
+INSERT INTO order_details (order_id, address, phone_number)
+VALUES ($1, $2, $3);
+
 -- name: GetOrderDetailsById :one
 SELECT *
 FROM order_details
@@ -129,10 +380,58 @@ SET address         = $2,
     return_statement=$4
 WHERE order_id = $1;
 
+-- name: UpdateOrderReturnStatement :exec
+UPDATE order_details
+SET return_statement=$2
+WHERE order_id = $1;
+
+-- name: SetOrderRefundAmount :one
+UPDATE order_details
+SET refund_amount=(SELECT COALESCE(SUM(R.quantity * I.price_at_purchase), 0)
+                   FROM order_return_items R
+                            JOIN order_items I on I.id = R.order_item_id
+                   WHERE R.order_id = $1)
+WHERE order_id = $1
+RETURNING refund_amount;
+
+-- name: CreateOrderReturnItem :exec
+INSERT INTO order_return_items (order_id, order_item_id, quantity, reason)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListOrderReturnItemsByOrderId :many
+SELECT R.id,
+       R.order_id,
+       R.order_item_id,
+       R.quantity,
+       R.reason,
+       R.created_at,
+       I.product_id,
+       I.price_at_purchase
+FROM order_return_items R
+         JOIN order_items I on I.id = R.order_item_id
+WHERE R.order_id = $1
+ORDER BY R.created_at;
+
+-- name: DeleteOrderReturnItemsByOrderId :exec
+DELETE
+FROM order_return_items
+WHERE order_id = $1;
+
 -- name: ListAllOrderItemsById :many
 SELECT *
 FROM order_items
-WHERE order_id = $1;
+WHERE order_id = $1
+ORDER BY created_at;
+
+-- name: CreateOrderItem :one
+INSERT INTO order_items (order_id, product_id, quantity, price_at_purchase)
+SELECT sqlc.arg(order_id)::uuid,
+       P.id,
+       sqlc.arg(quantity)::int,
+       ROUND(P.price * (100 - COALESCE(P.discount, 0)) / 100, 2)
+FROM products P
+WHERE P.id = sqlc.arg(product_id)
+RETURNING price_at_purchase;
 
 -- name: GetOrderItemById :one
 SELECT *
@@ -150,6 +449,41 @@ DELETE
 FROM order_items
 WHERE id = $1;
 
+-- name: CreateDelivery :one
+INSERT INTO deliveries (order_id, status, tracking_number, estimated_delivery)
+VALUES ($1, 'shipped', $2, $3)
+RETURNING *;
+
+-- name: GetDeliveryByOrderId :one
+SELECT *
+FROM deliveries
+WHERE order_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetDeliveryByIdForUpdate :one
+SELECT *
+FROM deliveries
+WHERE id = $1
+LIMIT 1 FOR UPDATE;
+
+-- name: UpdateDeliveryStatus :one
+UPDATE deliveries
+SET status       = $2,
+    delivered_at = CASE WHEN $2 = 'delivered'::DELIVERY_STATUS THEN NOW() ELSE delivered_at END
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateDeliveryEvent :exec
+INSERT INTO delivery_events (delivery_id, status, note, created_by)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListDeliveryEventsByDeliveryId :many
+SELECT *
+FROM delivery_events
+WHERE delivery_id = $1
+ORDER BY created_at;
+
 -- name: ListAllProducts :many
 SELECT DISTINCT P.id,
                 P.name,
@@ -159,11 +493,156 @@ SELECT DISTINCT P.id,
                 P.created_at,
                 P.updated_at,
                 P.img,
-                TYP.name as type,
-                CAT.name as category
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category,
+                COALESCE(R.avg_rating, 0)::float8 as avg_rating,
+                COALESCE(R.review_count, 0)::int  as review_count
 FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category;
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id;
+
+-- name: ListCatalogProducts :many
+WITH catalog AS (SELECT P.id,
+                        P.name,
+                        P.price,
+                        P.discount,
+                        P.description,
+                        P.created_at,
+                        P.updated_at,
+                        P.img,
+                        P.stock,
+                        TYP.slug                                                   as type,
+                        CAT.slug                                                   as category,
+                        COALESCE(R.avg_rating, 0)::float8                          as avg_rating,
+                        COALESCE(R.review_count, 0)::int                           as review_count,
+                        CASE sqlc.arg(sort)::text
+                            WHEN 'price_asc' THEN (P.price * (100 - COALESCE(P.discount, 0)) / 100)::float8
+                            WHEN 'price_desc' THEN -(P.price * (100 - COALESCE(P.discount, 0)) / 100)::float8
+                            WHEN 'popular' THEN -COALESCE(S.sold, 0)::float8
+                            WHEN 'rating' THEN -COALESCE(R.avg_rating, 0)::float8
+                            ELSE -EXTRACT(EPOCH FROM COALESCE(P.created_at, 'epoch'))::float8
+                            END::float8                                            as sort_key
+                 FROM products P
+                          JOIN categories CAT on CAT.id = P.category
+                          JOIN categories TYP on TYP.id = CAT.path[1]
+                          LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                                     FROM product_interactions
+                                     WHERE type = 'review'
+                                     GROUP BY product_id) R on R.product_id = P.id
+                          LEFT JOIN (SELECT OI.product_id, SUM(OI.quantity) as sold
+                                     FROM order_items OI
+                                              JOIN orders O on O.id = OI.order_id
+                                     WHERE O.status <> 'cancelled'
+                                     GROUP BY OI.product_id) S on S.product_id = P.id
+                 WHERE (sqlc.narg(types)::text[] IS NULL OR TYP.slug = ANY (sqlc.narg(types)::text[]))
+                   AND (sqlc.narg(categories)::text[] IS NULL OR CAT.slug = ANY (sqlc.narg(categories)::text[]))
+                   AND (sqlc.narg(min_price)::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 >= sqlc.narg(min_price)::numeric)
+                   AND (sqlc.narg(max_price)::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 <= sqlc.narg(max_price)::numeric)
+                   AND (NOT sqlc.arg(on_discount)::bool OR COALESCE(P.discount, 0) > 0)
+                   AND (NOT sqlc.arg(in_stock)::bool OR P.stock > 0)
+                   AND (sqlc.narg(subtree)::uuid IS NULL OR sqlc.narg(subtree)::uuid = ANY (CAT.path))
+                   AND product_matches_attributes(P.id, sqlc.narg(attribute_ids)::uuid[], sqlc.narg(attribute_texts)::text[],
+                                                  sqlc.narg(attribute_mins)::numeric[], sqlc.narg(attribute_maxs)::numeric[]))
+SELECT id,
+       name,
+       price,
+       discount,
+       description,
+       created_at,
+       updated_at,
+       img,
+       stock,
+       type,
+       category,
+       avg_rating,
+       review_count,
+       sort_key
+FROM catalog
+WHERE sqlc.narg(after_key)::float8 IS NULL
+   OR (sort_key, id) > (sqlc.narg(after_key)::float8, sqlc.narg(after_id)::uuid)
+ORDER BY sort_key, id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListCatalogFacets :many
+WITH matches AS (SELECT TYP.slug                                                                       as type,
+                        CAT.slug                                                                       as category,
+                        COALESCE(P.discount, 0) > 0                                                    as discounted,
+                        P.stock > 0                                                                    as stocked,
+                        (sqlc.narg(types)::text[] IS NULL OR TYP.slug = ANY (sqlc.narg(types)::text[])) as type_match,
+                        (sqlc.narg(categories)::text[] IS NULL OR
+                         CAT.slug = ANY (sqlc.narg(categories)::text[]))                               as category_match,
+                        (sqlc.narg(min_price)::numeric IS NULL OR
+                         P.price * (100 - COALESCE(P.discount, 0)) / 100 >= sqlc.narg(min_price)::numeric) AND
+                        (sqlc.narg(max_price)::numeric IS NULL OR
+                         P.price * (100 - COALESCE(P.discount, 0)) / 100 <= sqlc.narg(max_price)::numeric) as price_match,
+                        (NOT sqlc.arg(on_discount)::bool OR COALESCE(P.discount, 0) > 0)               as discount_match,
+                        (NOT sqlc.arg(in_stock)::bool OR P.stock > 0)                                  as stock_match
+                 FROM products P
+                          JOIN categories CAT on CAT.id = P.category
+                          JOIN categories TYP on TYP.id = CAT.path[1]
+                 WHERE (sqlc.narg(subtree)::uuid IS NULL OR sqlc.narg(subtree)::uuid = ANY (CAT.path))
+                   AND product_matches_attributes(P.id, sqlc.narg(attribute_ids)::uuid[], sqlc.narg(attribute_texts)::text[],
+                                                  sqlc.narg(attribute_mins)::numeric[], sqlc.narg(attribute_maxs)::numeric[]))
+SELECT 'total'::text as facet, ''::text as value, COUNT(*)::int as count
+FROM matches
+WHERE type_match AND category_match AND price_match AND discount_match AND stock_match
+UNION ALL
+SELECT 'type', type, COUNT(*)::int
+FROM matches
+WHERE category_match AND price_match AND discount_match AND stock_match
+GROUP BY type
+UNION ALL
+SELECT 'category', category, COUNT(*)::int
+FROM matches
+WHERE type_match AND price_match AND discount_match AND stock_match
+GROUP BY category
+UNION ALL
+SELECT 'on_discount', 'true', COUNT(*)::int
+FROM matches
+WHERE type_match AND category_match AND price_match AND stock_match AND discounted
+UNION ALL
+SELECT 'in_stock', 'true', COUNT(*)::int
+FROM matches
+WHERE type_match AND category_match AND price_match AND discount_match AND stocked
+ORDER BY facet, value;
+
+-- name: ListCatalogAttributeFacets :many
+WITH matches AS (SELECT P.id
+                 FROM products P
+                          JOIN categories CAT on CAT.id = P.category
+                          JOIN categories TYP on TYP.id = CAT.path[1]
+                 WHERE (sqlc.narg(types)::text[] IS NULL OR TYP.slug = ANY (sqlc.narg(types)::text[]))
+                   AND (sqlc.narg(categories)::text[] IS NULL OR CAT.slug = ANY (sqlc.narg(categories)::text[]))
+                   AND (sqlc.narg(min_price)::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 >= sqlc.narg(min_price)::numeric)
+                   AND (sqlc.narg(max_price)::numeric IS NULL OR
+                        P.price * (100 - COALESCE(P.discount, 0)) / 100 <= sqlc.narg(max_price)::numeric)
+                   AND (NOT sqlc.arg(on_discount)::bool OR COALESCE(P.discount, 0) > 0)
+                   AND (NOT sqlc.arg(in_stock)::bool OR P.stock > 0)
+                   AND (sqlc.narg(subtree)::uuid IS NULL OR sqlc.narg(subtree)::uuid = ANY (CAT.path)))
+SELECT A.id,
+       A.slug,
+       A.name,
+       A.kind,
+       A.unit,
+       (CASE A.kind WHEN 'enum' THEN V.text_value ELSE '' END)::text as value,
+       COUNT(*)::int                                                as count,
+       COALESCE(MIN(V.min_value), 0)::float8                        as min_value,
+       COALESCE(MAX(V.max_value), 0)::float8                        as max_value
+FROM matches M
+         JOIN product_attribute_values V on V.product_id = M.id
+         JOIN attributes A on A.id = V.attribute_id
+WHERE product_matches_attributes(M.id, sqlc.narg(attribute_ids)::uuid[], sqlc.narg(attribute_texts)::text[],
+                                 sqlc.narg(attribute_mins)::numeric[], sqlc.narg(attribute_maxs)::numeric[], A.id)
+GROUP BY A.id, A.slug, A.name, A.kind, A.unit, A.position, value
+ORDER BY A.position, A.name, A.slug, value;
 
 -- name: ListAllProductsByType :many
 SELECT DISTINCT P.id,
@@ -174,12 +653,19 @@ SELECT DISTINCT P.id,
                 P.created_at,
                 P.updated_at,
                 P.img,
-                TYP.name as type,
-                CAT.name as category
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category,
+                COALESCE(R.avg_rating, 0)::float8 as avg_rating,
+                COALESCE(R.review_count, 0)::int  as review_count
 FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category
-WHERE TYP.name = $1;
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id
+WHERE TYP.slug = $1;
 
 -- name: GetProductById :one
 SELECT DISTINCT P.id,
@@ -190,11 +676,12 @@ SELECT DISTINCT P.id,
                 P.created_at,
                 P.updated_at,
                 P.img,
-                TYP.name as type,
-                CAT.name as category
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category
 FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
 WHERE P.id = $1
 LIMIT 1;
 
@@ -207,17 +694,61 @@ SELECT DISTINCT P.id,
                 P.created_at,
                 P.updated_at,
                 P.img,
-                TYP.name as type,
-                CAT.name as category
+                P.stock,
+                TYP.slug as type,
+                CAT.slug as category
 FROM products P
-         JOIN tags TYP on TYP.id = P.type
-         JOIN tags CAT on CAT.id = P.category
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
 WHERE P.name = $1
 LIMIT 1;
 
--- name: CreateProduct :exec
-INSERT INTO products (name, price, discount, description, type, category, img)
-VALUES ($1, $2, 0, $3, $4, $5, $6);
+-- name: SearchProducts :many
+WITH search AS (SELECT fts_websearch(sqlc.arg(config)::text::regconfig, sqlc.arg(query)::text) ||
+                       websearch_to_tsquery('simple', sqlc.arg(query)::text) AS q,
+                       fts_headline_query(sqlc.arg(config)::text::regconfig, sqlc.arg(query)::text) AS headline_q)
+SELECT P.id,
+       P.name,
+       P.price,
+       P.discount,
+       P.description,
+       P.created_at,
+       P.updated_at,
+       P.img,
+       P.stock,
+       TYP.slug as type,
+       CAT.slug as category,
+       COALESCE(R.avg_rating, 0)::float8                         as avg_rating,
+       COALESCE(R.review_count, 0)::int                          as review_count,
+       ts_rank(P.search_vector, search.q, 1)::float8             as rank,
+       ts_headline(sqlc.arg(config)::text::regconfig, P.name, search.headline_q,
+                   'HighlightAll=true, StartSel="' || chr(2) || '", StopSel="' || chr(3) || '"')::text as name_headline,
+       ts_headline(sqlc.arg(config)::text::regconfig, COALESCE(P.description, ''), search.headline_q,
+                   'MaxFragments=2, MaxWords=25, MinWords=8, StartSel="' || chr(2) || '", StopSel="' || chr(3) ||
+                   '"')::text                                    as snippet
+FROM products P
+         CROSS JOIN search
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id
+WHERE P.search_vector @@ search.q
+ORDER BY rank DESC, P.name
+LIMIT sqlc.arg(max_results);
+
+-- name: SuggestProducts :many
+SELECT P.id, P.name
+FROM products P
+WHERE P.search_vector @@ to_tsquery('simple', sqlc.arg(prefix_query)::text)
+ORDER BY ts_rank(P.search_vector, to_tsquery('simple', sqlc.arg(prefix_query)::text)) DESC, P.name
+LIMIT sqlc.arg(max_results);
+
+-- name: CreateProduct :one
+INSERT INTO products (name, price, discount, description, category, img)
+VALUES ($1, $2, 0, $3, $4, $5)
+RETURNING id;
 
 -- name: UpdateProduct :exec
 UPDATE products
@@ -226,8 +757,7 @@ SET name= $2,
     discount=$4,
     description=$5,
     img=$6,
-    category=$7,
-    type=$8
+    category=$7
 WHERE id = $1;
 
 -- name: DeleteProduct :exec
@@ -235,28 +765,223 @@ DELETE
 FROM products
 WHERE id = $1;
 
--- name: GetTagByName :one
+-- name: ReserveProductStock :one
+UPDATE products
+SET stock = stock - sqlc.arg(quantity)::int
+WHERE id = sqlc.arg(id)
+  AND stock >= sqlc.arg(quantity)::int
+RETURNING stock;
+
+-- name: AddProductStock :one
+UPDATE products
+SET stock = stock + sqlc.arg(quantity)::int
+WHERE id = sqlc.arg(id)
+RETURNING stock;
+
+-- name: CreateStockMovement :exec
+INSERT INTO stock_movements (product_id, quantity, reason, order_id, note, created_by)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListStockMovementsByProductId :many
+SELECT *
+FROM stock_movements
+WHERE product_id = $1
+ORDER BY created_at DESC
+LIMIT 20;
+
+-- name: CreateProductInteraction :exec
+INSERT INTO product_interactions (product_id, user_id, type, content, rating)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetProductInteractionById :one
+SELECT *
+FROM product_interactions
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListProductInteractionsByProductId :many
+SELECT PI.id,
+       PI.product_id,
+       PI.user_id,
+       PI.type,
+       PI.content,
+       PI.rating,
+       PI.is_answered,
+       PI.response,
+       PI.created_at,
+       U.fname,
+       U.lname
+FROM product_interactions PI
+         LEFT JOIN users U on U.id = PI.user_id
+WHERE PI.product_id = $1
+ORDER BY PI.created_at DESC;
+
+-- name: AnswerProductQuestion :exec
+UPDATE product_interactions
+SET response    = $2,
+    answered_by = $3,
+    is_answered = TRUE
+WHERE id = $1
+  AND type = 'question';
+
+-- name: HasCompletedOrderWithProduct :one
+SELECT EXISTS (SELECT 1
+               FROM orders O
+                        JOIN order_items OI on OI.order_id = O.id
+               WHERE O.user_id = $1
+                 AND OI.product_id = $2
+                 AND O.status = 'completed');
+
+-- name: AddFavorite :exec
+INSERT INTO favorites (user_id, product_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFavorite :exec
+DELETE
+FROM favorites
+WHERE user_id = $1
+  AND product_id = $2;
+
+-- name: ListFavoriteProductIdsByUserId :many
+SELECT product_id
+FROM favorites
+WHERE user_id = $1;
+
+-- name: ListFavoriteProductsByUserId :many
+SELECT P.id,
+       P.name,
+       P.price,
+       P.discount,
+       P.description,
+       P.created_at,
+       P.updated_at,
+       P.img,
+       P.stock,
+       TYP.slug as type,
+       CAT.slug as category,
+       COALESCE(R.avg_rating, 0)::float8 as avg_rating,
+       COALESCE(R.review_count, 0)::int  as review_count
+FROM favorites F
+         JOIN products P on P.id = F.product_id
+         JOIN categories CAT on CAT.id = P.category
+         JOIN categories TYP on TYP.id = CAT.path[1]
+         LEFT JOIN (SELECT product_id, AVG(rating)::float8 as avg_rating, COUNT(*)::int as review_count
+                    FROM product_interactions
+                    WHERE type = 'review'
+                    GROUP BY product_id) R on R.product_id = P.id
+WHERE F.user_id = $1
+ORDER BY F.created_at DESC;
+
+-- name: ListCategories :many
+SELECT C.*, COALESCE(N.product_count, 0)::int as product_count
+FROM categories C
+         LEFT JOIN (SELECT A.id, COUNT(*) as product_count
+                    FROM products P
+                             JOIN categories PC on PC.id = P.category
+                             JOIN categories A on A.id = ANY (PC.path)
+                    GROUP BY A.id) N on N.id = C.id
+ORDER BY C.position, C.name;
+
+-- name: GetCategoryBySlug :one
 SELECT *
-FROM tags
-WHERE name = $1
+FROM categories
+WHERE slug = $1
 LIMIT 1;
 
--- name: GetTagById :one
+-- name: GetCategoryById :one
 SELECT *
-FROM tags
+FROM categories
 WHERE id = $1
 LIMIT 1;
 
--- name: ListAllTags :many
+-- name: ListCategoryAncestors :many
+SELECT A.*
+FROM categories C
+         JOIN categories A on A.id = ANY (C.path)
+WHERE C.id = $1
+ORDER BY array_position(C.path, A.id);
+
+-- name: CreateCategory :one
+INSERT INTO categories (parent_id, slug, name, description, img, position)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateCategory :exec
+UPDATE categories
+SET parent_id=$2,
+    slug=$3,
+    name=$4,
+    description=$5,
+    img=$6,
+    position=$7
+WHERE id = $1;
+
+-- name: DeleteCategory :exec
+DELETE
+FROM categories
+WHERE id = $1;
+
+-- name: ListAttributes :many
+SELECT A.*, C.slug as category
+FROM attributes A
+         JOIN categories C on C.id = A.category_id
+ORDER BY A.position, A.name;
+
+-- name: ListCategoryAttributes :many
+SELECT A.*
+FROM categories C
+         JOIN attributes A on A.category_id = ANY (C.path)
+WHERE C.id = $1
+ORDER BY array_position(C.path, A.category_id), A.position, A.name;
+
+-- name: GetAttributeBySlug :one
 SELECT *
-FROM tags;
+FROM attributes
+WHERE slug = $1
+LIMIT 1;
 
--- name: ListAllCategoryTags :many
-SELECT DISTINCT P.id, P.name
-FROM tags T
-         JOIN products P ON T.id = P.category;
+-- name: CreateAttribute :one
+INSERT INTO attributes (category_id, slug, name, kind, unit, options, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
 
--- name: CreateTag :one
-INSERT INTO tags (name)
-VALUES ($1)
-RETURNING *;
\ No newline at end of file
+-- name: UpdateAttribute :exec
+UPDATE attributes
+SET name=$2,
+    unit=$3,
+    options=$4,
+    position=$5
+WHERE id = $1;
+
+-- name: DeleteAttribute :exec
+DELETE
+FROM attributes
+WHERE id = $1;
+
+-- name: DeleteStaleAttributeValues :exec
+DELETE
+FROM product_attribute_values V
+    USING attributes A
+WHERE A.id = V.attribute_id
+  AND A.id = $1
+  AND A.kind = 'enum'
+  AND NOT (V.text_value = ANY (A.options));
+
+-- name: ListProductAttributeValues :many
+SELECT A.id as attribute_id, A.slug, A.name, A.kind, A.unit, V.text_value, V.min_value, V.max_value
+FROM product_attribute_values V
+         JOIN attributes A on A.id = V.attribute_id
+         JOIN products P on P.id = V.product_id
+         JOIN categories C on C.id = P.category
+WHERE V.product_id = $1
+ORDER BY array_position(C.path, A.category_id), A.position, A.name;
+
+-- name: CreateProductAttributeValue :exec
+INSERT INTO product_attribute_values (product_id, attribute_id, text_value, min_value, max_value)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: DeleteProductAttributeValues :exec
+DELETE
+FROM product_attribute_values
+WHERE product_id = $1;
\ No newline at end of file